@@ -48,7 +48,7 @@ func addTestRunnerUser() {
 	if err := store.Sql().Insert(testRunnerUser); err != nil {
 		panic(err)
 	}
-	addToken(adminToken, testRunnerUser.ID)
+	addToken(db.HashAPIToken(adminToken), testRunnerUser.ID)
 }
 
 func truncateAll() {
@@ -73,7 +73,7 @@ func truncateAll() {
 func removeTestRunnerUser(transactions []*transaction.Transaction) {
 	dbConnect()
 	defer store.Sql().Db.Close()
-	deleteToken(adminToken, testRunnerUser.ID)
+	deleteToken(db.HashAPIToken(adminToken), testRunnerUser.ID)
 	deleteObject(testRunnerUser)
 }
 
@@ -85,7 +85,7 @@ func setupObjectsAndPaths(t *transaction.Transaction) {
 
 // Object Lifecycle
 func addUserProjectRelation(pid int, user int) {
-	_, err := store.Sql().Exec("insert into project__user (project_id, user_id, `admin`) values (?, ?, 1)", pid, user)
+	_, err := store.Sql().Exec("insert into project__user (project_id, user_id, `role`) values (?, ?, 'owner')", pid, user)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -173,22 +173,23 @@ func deleteObject(i interface{}) {
 	}
 }
 
-// Token Handling
-func addToken(tok string, user int) {
+// Token Handling, tokens are stored by hash
+func addToken(tokenID string, user int) {
 	token := db.APIToken{
-		ID:      tok,
+		ID:      tokenID,
 		Created: time.Now(),
 		UserID:  user,
 		Expired: false,
+		Scopes:  db.APITokenScopeAdmin,
 	}
 	if err := store.Sql().Insert(&token); err != nil {
 		fmt.Println(err)
 	}
 }
 
-func deleteToken(tok string, user int) {
+func deleteToken(tokenID string, user int) {
 	token := db.APIToken{
-		ID:     tok,
+		ID:     tokenID,
 		UserID: user,
 	}
 	deleteObject(&token)
@@ -0,0 +1,536 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/context"
+)
+
+const passwordResetMailTemplate = `Subject: Reset your Semaphore password
+
+Hello {{ .Name }},
+
+somebody requested to reset the password of your Semaphore account {{ .Username }}.
+Follow the link to set a new password: <a href='{{ .Link }}'>{{ .Link }}</a>
+The link expires at {{ .Expires }}. If you did not request it, ignore this email.`
+
+const emailVerificationMailTemplate = `Subject: Verify your Semaphore email
+
+Hello {{ .Name }},
+
+follow the link to verify the email of your Semaphore account {{ .Username }}: <a href='{{ .Link }}'>{{ .Link }}</a>
+The link expires at {{ .Expires }}.`
+
+const invitationMailTemplate = `Subject: Invitation to Semaphore project '{{ .Name }}'
+
+You are invited to join Semaphore project '{{ .Name }}'.
+Follow the link to create your account: <a href='{{ .Link }}'>{{ .Link }}</a>
+The invitation expires at {{ .Expires }}.`
+
+// accountMail is the data of account email templates.
+type accountMail struct {
+	Name     string
+	Username string
+	Link     string
+	Expires  string
+}
+
+// sendMail dispatches emails, it is replaced in tests.
+var sendMail = util.SendMail
+
+// createAccountToken stores the hash of a new random token and returns the token signed
+// with the cookie key, so forged tokens are rejected before they are looked up.
+func createAccountToken(store db.Store, token db.AccountToken, lifetime time.Duration) (string, db.AccountToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", token, err
+	}
+	tokenString := base64.RawURLEncoding.EncodeToString(secret)
+
+	token.ID = db.HashAccountToken(tokenString)
+	token.Expires = time.Now().Add(lifetime)
+
+	token, err := store.CreateAccountToken(token)
+	if err != nil {
+		return "", token, err
+	}
+
+	signed, err := util.Cookie.Encode("account_token", tokenString)
+	return signed, token, err
+}
+
+// getAccountToken returns the active token of the kind by its signed value.
+func getAccountToken(store db.Store, signed string, kind string) (token db.AccountToken, err error) {
+	var tokenString string
+	if err = util.Cookie.Decode("account_token", signed, &tokenString); err != nil {
+		err = db.ErrNotFound
+		return
+	}
+
+	token, err = store.GetAccountToken(db.HashAccountToken(tokenString))
+	if err != nil {
+		return
+	}
+
+	if !token.IsActive(kind, time.Now()) {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+// writeInvalidAccountToken responds that the link is not valid anymore if the token is not found.
+func writeInvalidAccountToken(w http.ResponseWriter, err error) {
+	if err == db.ErrNotFound {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "The link is invalid or expired",
+		})
+		return
+	}
+	helpers.WriteError(w, err)
+}
+
+func getAccountLink(path string, token string) string {
+	return util.Config.WebHost + path + "?token=" + url.QueryEscape(token)
+}
+
+func sendAccountMail(recipient string, mailTemplate string, mail accountMail) error {
+	tpl, err := template.New("account mail template").Parse(mailTemplate)
+	if err != nil {
+		return err
+	}
+
+	var mailBuffer bytes.Buffer
+	if err = tpl.Execute(&mailBuffer, mail); err != nil {
+		return err
+	}
+
+	return sendMail(util.Config.EmailHost+":"+util.Config.EmailPort, util.Config.EmailSender, recipient, mailBuffer)
+}
+
+// sendEmailVerification sends the link to verify the email to the new user.
+func sendEmailVerification(store db.Store, user db.User) error {
+	token, accountToken, err := createAccountToken(store, db.AccountToken{
+		Kind:   db.AccountTokenEmailVerification,
+		UserID: &user.ID,
+		Email:  user.Email,
+	}, util.Config.GetInvitationLifetime())
+
+	if err != nil {
+		return err
+	}
+
+	return sendAccountMail(user.Email, emailVerificationMailTemplate, accountMail{
+		Name:     user.Name,
+		Username: user.Username,
+		Link:     getAccountLink("/auth/verify-email", token),
+		Expires:  accountToken.Expires.Format(time.RFC1123),
+	})
+}
+
+// requestPasswordReset sends the password reset link to the local user.
+// It responds with success even if the user does not exist, so it can't be used to find users.
+func requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Auth string `json:"auth" binding:"required"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if !util.Config.IsEmailConfigured() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Password reset is not available, ask the administrator to change your password",
+		})
+		return
+	}
+
+	login := strings.ToLower(body.Auth)
+
+	user, err := helpers.Store(r).GetUserByLoginOrEmail(login, login)
+
+	if err == db.ErrNotFound || (err == nil && (user.External || user.Service)) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	token, accountToken, err := createAccountToken(helpers.Store(r), db.AccountToken{
+		Kind:   db.AccountTokenPasswordReset,
+		UserID: &user.ID,
+		Email:  user.Email,
+	}, util.Config.GetPasswordResetLifetime())
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	err = sendAccountMail(user.Email, passwordResetMailTemplate, accountMail{
+		Name:     user.Name,
+		Username: user.Username,
+		Link:     getAccountLink("/auth/reset-password", token),
+		Expires:  accountToken.Expires.Format(time.RFC1123),
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resetPassword sets the new password of the user by the password reset link
+// and signs out all sessions of the user.
+func resetPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	token, err := getAccountToken(store, body.Token, db.AccountTokenPasswordReset)
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	user, err := store.GetUser(*token.UserID)
+	if err == nil && (user.External || user.Service || user.Email != token.Email) {
+		err = db.ErrNotFound
+	}
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	if writePasswordPolicyError(w, db.ValidatePassword(body.Password)) {
+		return
+	}
+
+	if err = store.UseAccountToken(token.ID); err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	if err = store.SetUserPassword(user.ID, body.Password); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	// the user has received the link, so the email is verified
+	if user.EmailUnverified {
+		if err = store.VerifyUserEmail(user.ID); err != nil {
+			log.Error(err)
+		}
+	}
+
+	if err = store.ExpireSessions(user.ID, 0); err != nil {
+		log.Error(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyEmail confirms the email of the user by the email verification link.
+func verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	token, err := getAccountToken(store, body.Token, db.AccountTokenEmailVerification)
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	user, err := store.GetUser(*token.UserID)
+	if err == nil && user.Email != token.Email {
+		err = db.ErrNotFound
+	}
+	if err == nil {
+		err = store.UseAccountToken(token.ID)
+	}
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	if err = store.VerifyUserEmail(user.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resendEmailVerification sends a new email verification link to the user.
+func resendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "_user").(db.User)
+	editor := context.Get(r, "user").(*db.User)
+
+	if !editor.Admin {
+		log.Warn(editor.Username + " is not permitted to send email verification")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if !user.EmailUnverified {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Email is already verified",
+		})
+		return
+	}
+
+	if !util.Config.IsEmailConfigured() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Email is not configured",
+		})
+		return
+	}
+
+	if err := sendEmailVerification(helpers.Store(r), user); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addProjectInvitation creates the link which allows a new user to create an account
+// and join the project with the role. The link is sent to the email if it is configured.
+func addProjectInvitation(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	editor := context.Get(r, "user").(*db.User)
+
+	var body struct {
+		Email string             `json:"email" binding:"required"`
+		Role  db.ProjectUserRole `json:"role"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if body.Role == "" {
+		body.Role = db.ProjectManager
+	}
+
+	if !body.Role.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	email := strings.TrimSpace(body.Email)
+	store := helpers.Store(r)
+
+	_, err := store.GetUserByLoginOrEmail("", email)
+
+	if err == nil {
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error": "User with the email already exists, add the user to the project instead",
+		})
+		return
+	}
+
+	if err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	token, invitation, err := createAccountToken(store, db.AccountToken{
+		Kind:      db.AccountTokenInvitation,
+		Email:     email,
+		ProjectID: &project.ID,
+		Role:      body.Role,
+		CreatedBy: &editor.ID,
+	}, util.Config.GetInvitationLifetime())
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	link := getAccountLink("/auth/invitation", token)
+
+	if util.Config.IsEmailConfigured() {
+		err = sendAccountMail(email, invitationMailTemplate, accountMail{
+			Name:    project.Name,
+			Link:    link,
+			Expires: invitation.Expires.Format(time.RFC1123),
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	objType := "project"
+	desc := "Invitation to join the project as " + string(body.Role) + " sent to " + email
+
+	_, err = store.CreateEvent(db.Event{
+		UserID:      &editor.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &project.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"link":    link,
+		"email":   email,
+		"role":    body.Role,
+		"expires": invitation.Expires,
+	})
+}
+
+// getInvitation returns the project and the email of the invitation.
+func getInvitation(w http.ResponseWriter, r *http.Request) {
+	store := helpers.Store(r)
+
+	invitation, err := getAccountToken(store, r.URL.Query().Get("token"), db.AccountTokenInvitation)
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	project, err := store.GetProject(*invitation.ProjectID)
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"email":        invitation.Email,
+		"project_name": project.Name,
+		"role":         invitation.Role,
+		"expires":      invitation.Expires,
+	})
+}
+
+// acceptInvitation creates the account of the invited user, adds it to the project and logs it in.
+func acceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token    string `json:"token" binding:"required"`
+		Username string `json:"username" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	invitation, err := getAccountToken(store, body.Token, db.AccountTokenInvitation)
+	if err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	_, err = store.GetUserByLoginOrEmail(body.Username, invitation.Email)
+
+	if err == nil {
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error": "User already exists",
+		})
+		return
+	}
+
+	if err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = db.ValidateUsername(body.Username); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if writePasswordPolicyError(w, db.ValidatePassword(body.Password)) {
+		return
+	}
+
+	if err = store.UseAccountToken(invitation.ID); err != nil {
+		writeInvalidAccountToken(w, err)
+		return
+	}
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd: body.Password,
+		User: db.User{
+			Username: body.Username,
+			Name:     body.Name,
+			Email:    invitation.Email,
+		},
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	_, err = store.CreateProjectUser(db.ProjectUser{
+		ProjectID: *invitation.ProjectID,
+		UserID:    user.ID,
+		Role:      invitation.Role,
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	objType := "user"
+	desc := "User ID " + strconv.Itoa(user.ID) + " joined the project by invitation as " + string(invitation.Role)
+
+	_, err = store.CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   invitation.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &user.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	startSession(w, r, user)
+}
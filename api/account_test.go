@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+var accountLinkToken = regexp.MustCompile(`token=([^'"\s<]+)`)
+
+// captureAccountMail replaces sending of emails and returns the function
+// which returns the token from the last link sent to the recipient.
+func captureAccountMail(t *testing.T) func(recipient string) string {
+	mails := make(map[string]string)
+
+	sendMail = func(emailHost, mailSender, mailRecipient string, mail bytes.Buffer) error {
+		mails[mailRecipient] = mail.String()
+		return nil
+	}
+
+	return func(recipient string) string {
+		match := accountLinkToken.FindStringSubmatch(mails[recipient])
+		if match == nil {
+			t.Fatalf("no link sent to %s", recipient)
+		}
+
+		token, err := url.QueryUnescape(html.UnescapeString(match[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		delete(mails, recipient)
+		return token
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	util.Config = &util.ConfigType{EmailHost: "localhost", EmailPort: "25", EmailSender: "semaphore@example.com"}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil; sendMail = util.SendMail }()
+
+	getToken := captureAccountMail(t)
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "user", Name: "User", Email: "user@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+
+	if rr := client.do("POST", "/api/auth/password_reset", map[string]string{"auth": "nobody"}); rr.Code != http.StatusNoContent {
+		t.Fatalf("unknown users must not be revealed: %d", rr.Code)
+	}
+
+	if rr := client.do("POST", "/api/auth/password_reset", map[string]string{"auth": user.Email}); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	token := getToken(user.Email)
+	reset := map[string]string{"token": token, "password": "new password"}
+
+	forged := map[string]string{"token": token + "x", "password": "new password"}
+	if rr := client.do("POST", "/api/auth/password_reset/confirm", forged); rr.Code != http.StatusBadRequest {
+		t.Fatalf("forged token must be rejected: %d", rr.Code)
+	}
+
+	if rr := client.do("POST", "/api/auth/password_reset/confirm", reset); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr := client.do("POST", "/api/auth/password_reset/confirm", reset); rr.Code != http.StatusBadRequest {
+		t.Fatalf("token must be used only once: %d", rr.Code)
+	}
+
+	if rr := client.do("POST", "/api/auth/login", map[string]string{"auth": "user", "password": "password"}); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("old password must not be accepted: %d", rr.Code)
+	}
+
+	if rr := client.do("POST", "/api/auth/login", map[string]string{"auth": "user", "password": "new password"}); rr.Code != http.StatusNoContent {
+		t.Fatalf("new password must be accepted: %d", rr.Code)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	util.Config = &util.ConfigType{
+		EmailHost:         "localhost",
+		EmailPort:         "25",
+		EmailSender:       "semaphore@example.com",
+		EmailVerification: true,
+	}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil; sendMail = util.SendMail }()
+
+	getToken := captureAccountMail(t)
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	_, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &testClient{router: router}
+	admin.do("POST", "/api/auth/login", map[string]string{"auth": "admin", "password": "password"})
+
+	rr := admin.do("POST", "/api/users", map[string]string{
+		"username": "user",
+		"name":     "User",
+		"email":    "user@example.com",
+		"password": "password",
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	client := &testClient{router: router}
+	login := map[string]string{"auth": "user", "password": "password"}
+
+	if rr = client.do("POST", "/api/auth/login", login); rr.Code != http.StatusForbidden {
+		t.Fatalf("user with unverified email must not log in: %d", rr.Code)
+	}
+
+	verify := map[string]string{"token": getToken("user@example.com")}
+
+	if rr = client.do("POST", "/api/auth/email_verification/confirm", verify); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = client.do("POST", "/api/auth/email_verification/confirm", verify); rr.Code != http.StatusBadRequest {
+		t.Fatalf("token must be used only once: %d", rr.Code)
+	}
+
+	if rr = client.do("POST", "/api/auth/login", login); rr.Code != http.StatusNoContent {
+		t.Fatalf("user with verified email must log in: %d", rr.Code)
+	}
+}
+
+func TestProjectInvitation(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+	invitation := map[string]string{"email": "new@example.com", "role": string(db.ProjectTaskRunner)}
+
+	if rr := clients[db.ProjectManager].do("POST", projectURL+"/invitations", invitation); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not invite users: %d", rr.Code)
+	}
+
+	existing := map[string]string{"email": "guest@example.com"}
+	if rr := clients[db.ProjectOwner].do("POST", projectURL+"/invitations", existing); rr.Code != http.StatusConflict {
+		t.Fatalf("existing users must not be invited: %d", rr.Code)
+	}
+
+	rr := clients[db.ProjectOwner].do("POST", projectURL+"/invitations", invitation)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var res struct {
+		Link string `json:"link"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &res)
+
+	link, err := url.Parse(res.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+
+	client := &testClient{router: router}
+
+	if rr = client.do("GET", "/api/auth/invitation?token="+url.QueryEscape(token), nil); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	accept := map[string]string{"token": token, "username": "new", "name": "New", "password": "password"}
+
+	if rr = client.do("POST", "/api/auth/invitation/accept", accept); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = client.do("POST", projectURL+"/tasks", nil); rr.Code == http.StatusForbidden {
+		t.Fatal("invited user must get the role of the invitation")
+	}
+
+	accept["username"] = "other"
+	if rr = (&testClient{router: router}).do("POST", "/api/auth/invitation/accept", accept); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invitation must be used only once: %d", rr.Code)
+	}
+}
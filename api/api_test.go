@@ -3,11 +3,42 @@ package api
 import (
 	//_ "github.com/snikch/goodman/hooks"
 	//_ "github.com/snikch/goodman/transaction"
+	"github.com/ansible-semaphore/semaphore/api/schedules"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+// createTestRouter returns the router which uses a new Bolt store.
+func createTestRouter(t *testing.T) (db.Store, *mux.Router) {
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_api_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	schedulePool := schedules.CreateSchedulePool(store)
+
+	router := Route()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			context.Set(r, "store", db.Store(store))
+			context.Set(r, "schedule_pool", schedulePool)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	return store, router
+}
+
 func TestApiPing(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/api/ping", nil)
 	rr := httptest.NewRecorder()
@@ -20,3 +51,71 @@ func TestApiPing(t *testing.T) {
 		t.Errorf("Response code should be 200 %d", rr.Code)
 	}
 }
+
+func TestMetricsHandlerRequiresToken(t *testing.T) {
+	util.Config = &util.ConfigType{MetricsToken: "secret"}
+	defer func() { util.Config = nil }()
+
+	req, _ := http.NewRequest("GET", "/api/metrics", nil)
+	rr := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Response code should be 401 %d", rr.Code)
+	}
+
+	req.Header.Set("Authorization", "secret")
+	rr = httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("token without the Bearer scheme must be rejected: %d", rr.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Response code should be 200 %d", rr.Code)
+	}
+}
+
+func TestHealthReadyHidesDetails(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: "/nonexistent/semaphore"}
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	req, _ := http.NewRequest("GET", "/api/health/ready", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Response code should be 503 %d", rr.Code)
+	}
+
+	var report struct {
+		Status string   `json:"status"`
+		Failed []string `json:"failed"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Status != "fail" || len(report.Failed) == 0 || strings.Contains(rr.Body.String(), "nonexistent") {
+		t.Fatalf("only names of failed checks must be returned: %s", rr.Body.String())
+	}
+}
+
+func TestHealthLive(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/api/health/live", nil)
+	rr := httptest.NewRecorder()
+
+	Route().ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Errorf("Response code should be 200 %d", rr.Code)
+	}
+}
@@ -1,24 +1,113 @@
 package api
 
 import (
+	stdcontext "context"
 	log "github.com/Sirupsen/logrus"
 	"github.com/ansible-semaphore/semaphore/api/helpers"
 	"github.com/ansible-semaphore/semaphore/db"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/ansible-semaphore/semaphore/util"
 	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
 )
 
-//nolint: gocyclo
+// readSessionCookie returns IDs of the user and the session stored in the session cookie.
+func readSessionCookie(r *http.Request) (userID int, sessionID int, ok bool) {
+	cookie, err := r.Cookie("semaphore")
+	if err != nil {
+		return
+	}
+
+	value := make(map[string]interface{})
+	if err = util.Cookie.Decode("semaphore", cookie.Value, &value); err != nil {
+		return
+	}
+
+	user, okUser := value["user"]
+	sessionVal, okSession := value["session"]
+	if !okUser || !okSession {
+		return
+	}
+
+	return user.(int), sessionVal.(int), true
+}
+
+// apiTokenTouchInterval limits how often the last usage of a token is saved.
+const apiTokenTouchInterval = time.Minute
+
+// readBearerToken returns the token from the Authorization header.
+// The scheme is case-insensitive, the token itself is not.
+func readBearerToken(r *http.Request) (string, bool) {
+	const prefix = "bearer "
+
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) <= len(prefix) || !strings.EqualFold(authHeader[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(authHeader[len(prefix):])
+	return token, token != ""
+}
+
+func isSafeMethod(r *http.Request) bool {
+	return r.Method == "GET" || r.Method == "HEAD"
+}
+
+func getRemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type peerAddrKey struct{}
+
+// CapturePeerAddress saves the address of the connection peer before it is replaced
+// by the client address from proxy headers, so only requests which really come
+// from trusted proxies can set the client address.
+func CapturePeerAddress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := stdcontext.WithValue(r.Context(), peerAddrKey{}, r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getPeerIP returns the IP address of the connection peer.
+func getPeerIP(r *http.Request) string {
+	addr, ok := r.Context().Value(peerAddrKey{}).(string)
+	if !ok {
+		addr = r.RemoteAddr
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
 func authentication(next http.Handler) http.Handler {
+	return authenticate(next, false)
+}
+
+// totpEnrolmentAuthentication also accepts sessions which wait for TOTP enrolment,
+// so users required to use two-factor authentication are able to enrol.
+func totpEnrolmentAuthentication(next http.Handler) http.Handler {
+	return authenticate(next, true)
+}
+
+//nolint: gocyclo
+func authenticate(next http.Handler, allowTotpEnrolment bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var userID int
 
-		if authHeader := strings.ToLower(r.Header.Get("authorization")); len(authHeader) > 0 && strings.Contains(authHeader, "bearer") {
-			token, err := helpers.Store(r).GetAPIToken(strings.Replace(authHeader, "bearer ", "", 1))
+		if tokenString, ok := readBearerToken(r); ok {
+			token, err := helpers.Store(r).GetAPIToken(db.HashAPIToken(tokenString))
 
 			if err != nil {
 				if err != db.ErrNotFound {
@@ -30,30 +119,42 @@ func authentication(next http.Handler) http.Handler {
 				return
 			}
 
-			userID = token.UserID
-		} else {
-			// fetch session from cookie
-			cookie, err := r.Cookie("semaphore")
-			if err != nil {
+			if !token.IsActive(time.Now()) {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
-			value := make(map[string]interface{})
-			if err = util.Cookie.Decode("semaphore", cookie.Value, &value); err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
+			// requests outside of projects are checked here, project middlewares check the rest
+			if mux.Vars(r)["project_id"] == "" && !token.IsAdmin() && !(token.CanRead() && isSafeMethod(r)) {
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 
-			user, ok := value["user"]
-			sessionVal, okSession := value["session"]
-			if !ok || !okSession {
+			if token.LastUsed == nil || time.Since(*token.LastUsed) > apiTokenTouchInterval {
+				if err = helpers.Store(r).TouchAPIToken(token.UserID, token.ID, getRemoteIP(r)); err != nil {
+					log.Error(err)
+				}
+			}
+
+			userID = token.UserID
+			context.Set(r, "token", token)
+		} else if proxyUserID, ok, err := authenticateProxyUser(r); ok {
+			if err != nil {
+				log.Error(err)
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
-			userID = user.(int)
-			sessionID := sessionVal.(int)
+			userID = proxyUserID
+		} else {
+			// fetch session from cookie
+			var sessionID int
+			var ok bool
+			userID, sessionID, ok = readSessionCookie(r)
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
 
 			// fetch session
 			session, err := helpers.Store(r).GetSession(userID, sessionID)
@@ -63,8 +164,14 @@ func authentication(next http.Handler) http.Handler {
 				return
 			}
 
-			if time.Since(session.LastActive).Hours() > 7*24 {
-				// more than week old unused session
+			if !session.IsVerified() &&
+				!(allowTotpEnrolment && session.VerificationMethod == db.SessionVerificationTotpEnrolment) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if !session.IsActive(time.Now(), util.Config.GetSessionIdleTimeout(), util.Config.GetSessionLifetime()) {
+				// unused or too old session
 				// destroy.
 				if err := helpers.Store(r).ExpireSession(userID, sessionID); err != nil {
 					// it is internal error, it doesn't concern the user
@@ -80,6 +187,8 @@ func authentication(next http.Handler) http.Handler {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
+
+			context.Set(r, "session", session)
 		}
 
 		user, err := helpers.Store(r).GetUser(userID)
@@ -0,0 +1,115 @@
+package configsync
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+// fetchSpec returns the content of the spec file and the commit it was read from.
+// It is a variable to replace git in tests.
+var fetchSpec = fetchSpecFromGit
+
+// CleanSpecPath returns the spec path relative to the repository root, it
+// fails if the path points outside of the repository.
+func CleanSpecPath(path string) (string, error) {
+	cleaned := filepath.Clean("/" + path)[1:]
+	if cleaned == "" || strings.HasPrefix(filepath.Clean(path), "..") {
+		return "", fmt.Errorf("invalid spec path %q", path)
+	}
+	return cleaned, nil
+}
+
+// fetchSpecFromGit makes a shallow clone of the repository to a temporary
+// directory which is removed afterwards.
+func fetchSpecFromGit(store db.Store, sync db.ProjectSync) (data []byte, commit string, err error) {
+	path, err := CleanSpecPath(sync.Path)
+	if err != nil {
+		return
+	}
+
+	repository, err := store.GetRepository(sync.ProjectID, sync.RepositoryID)
+	if err != nil {
+		return
+	}
+
+	key, err := store.GetAccessKey(sync.ProjectID, repository.SSHKeyID)
+	if err != nil {
+		return
+	}
+
+	dir, err := ioutil.TempDir(util.Config.TmpPath, "project_sync_")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	env := append(os.Environ(), "HOME="+dir)
+
+	switch key.Type {
+	case db.AccessKeySSH:
+		if key.SshKey.Passphrase != "" {
+			err = fmt.Errorf("ssh key with passphrase not supported")
+			return
+		}
+		keyPath := filepath.Join(dir, "key")
+		if err = ioutil.WriteFile(keyPath, []byte(key.SshKey.PrivateKey), 0600); err != nil {
+			return
+		}
+		env = append(env, "GIT_SSH_COMMAND=ssh -o StrictHostKeyChecking=no -i "+keyPath)
+	case db.AccessKeyNone:
+	default:
+		err = fmt.Errorf("unsupported access key type: " + key.Type)
+		return
+	}
+
+	repoURL, repoTag := repository.GitURL, "master"
+	if split := strings.Split(repoURL, "#"); len(split) > 1 {
+		repoURL, repoTag = split[0], split[1]
+	}
+
+	repoPath := filepath.Join(dir, "repository")
+
+	cmd := exec.Command("git", "clone", "--depth", "1", "--branch", repoTag, repoURL, repoPath) //nolint: gas
+	cmd.Env = env
+	if out, cmdErr := cmd.CombinedOutput(); cmdErr != nil {
+		err = fmt.Errorf("can not clone repository: %s", strings.TrimSpace(string(out)))
+		return
+	}
+
+	cmd = exec.Command("git", "rev-parse", "HEAD") //nolint: gas
+	cmd.Dir = repoPath
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	commit = strings.TrimSpace(string(out))
+
+	// symbolic links must not lead out of the repository
+	if repoPath, err = filepath.EvalSymlinks(repoPath); err != nil {
+		return
+	}
+
+	specPath, err := filepath.EvalSymlinks(filepath.Join(repoPath, path))
+	if os.IsNotExist(err) {
+		err = fmt.Errorf("spec file %q not found in repository", sync.Path)
+		return
+	} else if err != nil {
+		return
+	}
+
+	if !strings.HasPrefix(specPath, repoPath+string(filepath.Separator)) {
+		err = fmt.Errorf("invalid spec path %q", sync.Path)
+		return
+	}
+
+	data, err = ioutil.ReadFile(specPath)
+	return
+}
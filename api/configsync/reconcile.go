@@ -0,0 +1,617 @@
+package configsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change is a change of the project object required to match the spec.
+type Change struct {
+	Action string        `json:"action"`
+	Kind   string        `json:"kind"`
+	Name   string        `json:"name"`
+	Diff   []FieldChange `json:"diff,omitempty"`
+}
+
+// FieldChange is a field of the object which differs from the spec.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type diff []FieldChange
+
+func (d *diff) add(field string, oldValue string, newValue string) {
+	if oldValue != newValue {
+		*d = append(*d, FieldChange{Field: field, Old: oldValue, New: newValue})
+	}
+}
+
+func (d *diff) addManaged(managed bool) {
+	d.add("managed", strconv.FormatBool(managed), "true")
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// reconciler computes changes and, if apply is set, makes them. Objects are
+// matched with the spec by names; objects with the same name which are not
+// managed yet are taken over by the spec.
+type reconciler struct {
+	store     db.Store
+	projectID int
+	spec      Spec
+	apply     bool
+	// userID is the author of the revisions of changed objects
+	userID  *int
+	changes []Change
+
+	keyIDs        map[string]int
+	keyNames      map[int]string
+	repositoryIDs map[string]int
+	repoNames     map[int]string
+
+	inventories      []db.Inventory
+	inventoryIDs     map[string]int
+	inventoryNames   map[int]string
+	environments     []db.Environment
+	environmentIDs   map[string]int
+	environmentNames map[int]string
+	templates        []db.Template
+
+	// IDs of objects matched with the spec
+	kept map[string]map[int]bool
+}
+
+// reconcile returns changes required to make the project match the spec.
+// Changes are made only if apply is set.
+func reconcile(store db.Store, projectID int, spec Spec, apply bool, userID *int) ([]Change, error) {
+	r := reconciler{
+		store:            store,
+		projectID:        projectID,
+		spec:             spec,
+		apply:            apply,
+		userID:           userID,
+		changes:          []Change{},
+		keyIDs:           make(map[string]int),
+		keyNames:         make(map[int]string),
+		repositoryIDs:    make(map[string]int),
+		repoNames:        make(map[int]string),
+		inventoryIDs:     make(map[string]int),
+		inventoryNames:   make(map[int]string),
+		environmentIDs:   make(map[string]int),
+		environmentNames: make(map[int]string),
+		kept: map[string]map[int]bool{
+			"environment": {},
+			"inventory":   {},
+			"template":    {},
+		},
+	}
+
+	steps := []func() error{
+		r.load,
+		r.syncEnvironments,
+		r.syncInventories,
+		r.syncTemplates,
+		r.deleteTemplates,
+		r.deleteInventories,
+		r.deleteEnvironments,
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return r.changes, err
+		}
+	}
+
+	return r.changes, nil
+}
+
+func (r *reconciler) load() error {
+	keys, err := r.store.GetAccessKeys(r.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !key.Removed {
+			r.keyIDs[key.Name] = key.ID
+			r.keyNames[key.ID] = key.Name
+		}
+	}
+
+	repositories, err := r.store.GetRepositories(r.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, repository := range repositories {
+		if !repository.Removed {
+			r.repositoryIDs[repository.Name] = repository.ID
+			r.repoNames[repository.ID] = repository.Name
+		}
+	}
+
+	inventories, err := r.store.GetInventories(r.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, inventory := range inventories {
+		if inventory.Removed {
+			continue
+		}
+		r.inventories = append(r.inventories, inventory)
+		r.inventoryNames[inventory.ID] = inventory.Name
+	}
+
+	environments, err := r.store.GetEnvironments(r.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, environment := range environments {
+		if environment.Removed {
+			continue
+		}
+		r.environments = append(r.environments, environment)
+		r.environmentNames[environment.ID] = environment.Name
+	}
+
+	templates, err := r.store.GetTemplates(r.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, template := range templates {
+		if !template.Removed {
+			r.templates = append(r.templates, template)
+		}
+	}
+
+	// objects which are not in the spec can be referenced by templates
+	for _, inventory := range r.inventories {
+		if _, ok := r.inventoryIDs[inventory.Name]; !ok || inventory.Managed {
+			r.inventoryIDs[inventory.Name] = inventory.ID
+		}
+	}
+	for _, environment := range r.environments {
+		if _, ok := r.environmentIDs[environment.Name]; !ok || environment.Managed {
+			r.environmentIDs[environment.Name] = environment.ID
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) addChange(action string, kind string, name string, d diff) {
+	r.changes = append(r.changes, Change{Action: action, Kind: kind, Name: name, Diff: d})
+}
+
+func (r *reconciler) recordRevision(objectType db.RevisionObjectType, objectID int) error {
+	_, err := db.RecordRevision(r.store, r.projectID, objectType, objectID, r.userID)
+	return err
+}
+
+func (r *reconciler) lookupKey(name string) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	id, ok := r.keyIDs[name]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", name)
+	}
+
+	return &id, nil
+}
+
+func (r *reconciler) keyName(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return r.keyNames[*id]
+}
+
+func (r *reconciler) syncEnvironments() error {
+	for _, spec := range r.spec.Environments {
+		var existing *db.Environment
+		for i := range r.environments {
+			if r.environments[i].ID == r.environmentIDs[spec.Name] && r.environments[i].Name == spec.Name {
+				existing = &r.environments[i]
+			}
+		}
+
+		if existing == nil {
+			r.addChange(ActionCreate, "environment", spec.Name, nil)
+			r.environmentIDs[spec.Name] = 0
+
+			if !r.apply {
+				continue
+			}
+
+			env, err := r.store.CreateEnvironment(db.Environment{
+				Name:      spec.Name,
+				ProjectID: r.projectID,
+				JSON:      spec.JSON,
+				Managed:   true,
+			})
+			if err != nil {
+				return err
+			}
+			if err = r.recordRevision(db.RevisionEnvironment, env.ID); err != nil {
+				return err
+			}
+
+			r.environmentIDs[spec.Name] = env.ID
+			r.kept["environment"][env.ID] = true
+			continue
+		}
+
+		r.kept["environment"][existing.ID] = true
+
+		var d diff
+		d.add("json", existing.JSON, spec.JSON)
+		d.addManaged(existing.Managed)
+
+		if len(d) == 0 {
+			continue
+		}
+
+		r.addChange(ActionUpdate, "environment", spec.Name, d)
+
+		if r.apply {
+			env := *existing
+			env.JSON = spec.JSON
+			env.Managed = true
+			if err := r.store.UpdateEnvironment(env); err != nil {
+				return err
+			}
+			if err := r.recordRevision(db.RevisionEnvironment, env.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) syncInventories() error {
+	for _, spec := range r.spec.Inventories {
+		sshKeyID, err := r.lookupKey(spec.SSHKey)
+		if err != nil {
+			return fmt.Errorf("inventory %q: %s", spec.Name, err.Error())
+		}
+
+		becomeKeyID, err := r.lookupKey(spec.BecomeKey)
+		if err != nil {
+			return fmt.Errorf("inventory %q: %s", spec.Name, err.Error())
+		}
+
+		var existing *db.Inventory
+		for i := range r.inventories {
+			if r.inventories[i].ID == r.inventoryIDs[spec.Name] && r.inventories[i].Name == spec.Name {
+				existing = &r.inventories[i]
+			}
+		}
+
+		if existing == nil {
+			r.addChange(ActionCreate, "inventory", spec.Name, nil)
+			r.inventoryIDs[spec.Name] = 0
+
+			if !r.apply {
+				continue
+			}
+
+			inventory, err := r.store.CreateInventory(db.Inventory{
+				Name:        spec.Name,
+				ProjectID:   r.projectID,
+				Inventory:   spec.Inventory,
+				SSHKeyID:    sshKeyID,
+				BecomeKeyID: becomeKeyID,
+				Type:        spec.Type,
+				Managed:     true,
+			})
+			if err != nil {
+				return err
+			}
+			if err = r.recordRevision(db.RevisionInventory, inventory.ID); err != nil {
+				return err
+			}
+
+			r.inventoryIDs[spec.Name] = inventory.ID
+			r.kept["inventory"][inventory.ID] = true
+			continue
+		}
+
+		r.kept["inventory"][existing.ID] = true
+
+		var d diff
+		d.add("type", existing.Type, spec.Type)
+		d.add("inventory", existing.Inventory, spec.Inventory)
+		d.add("ssh_key", r.keyName(existing.SSHKeyID), spec.SSHKey)
+		d.add("become_key", r.keyName(existing.BecomeKeyID), spec.BecomeKey)
+		d.addManaged(existing.Managed)
+
+		if len(d) == 0 {
+			continue
+		}
+
+		r.addChange(ActionUpdate, "inventory", spec.Name, d)
+
+		if r.apply {
+			inventory := *existing
+			inventory.Type = spec.Type
+			inventory.Inventory = spec.Inventory
+			inventory.SSHKeyID = sshKeyID
+			inventory.BecomeKeyID = becomeKeyID
+			inventory.Managed = true
+			if err := r.store.UpdateInventory(inventory); err != nil {
+				return err
+			}
+			if err := r.recordRevision(db.RevisionInventory, inventory.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) syncTemplates() error {
+	for _, spec := range r.spec.Templates {
+		repositoryID, ok := r.repositoryIDs[spec.Repository]
+		if !ok {
+			return fmt.Errorf("template %q: repository %q not found", spec.Alias, spec.Repository)
+		}
+
+		inventoryID, ok := r.inventoryIDs[spec.Inventory]
+		if !ok {
+			return fmt.Errorf("template %q: inventory %q not found", spec.Alias, spec.Inventory)
+		}
+
+		var environmentID *int
+		if spec.Environment != "" {
+			id, ok := r.environmentIDs[spec.Environment]
+			if !ok {
+				return fmt.Errorf("template %q: environment %q not found", spec.Alias, spec.Environment)
+			}
+			environmentID = &id
+		}
+
+		vaultPassID, err := r.lookupKey(spec.VaultKey)
+		if err != nil {
+			return fmt.Errorf("template %q: %s", spec.Alias, err.Error())
+		}
+
+		var arguments *string
+		if len(spec.Arguments) > 0 {
+			args, err := json.Marshal(spec.Arguments)
+			if err != nil {
+				return err
+			}
+			str := string(args)
+			arguments = &str
+		}
+
+		var description *string
+		if spec.Description != "" {
+			description = &spec.Description
+		}
+
+		var existing *db.Template
+		for i := range r.templates {
+			if r.templates[i].Alias == spec.Alias && (existing == nil || r.templates[i].Managed) {
+				existing = &r.templates[i]
+			}
+		}
+
+		template := db.Template{
+			ProjectID:         r.projectID,
+			InventoryID:       inventoryID,
+			RepositoryID:      repositoryID,
+			EnvironmentID:     environmentID,
+			Alias:             spec.Alias,
+			Playbook:          spec.Playbook,
+			Arguments:         arguments,
+			OverrideArguments: spec.OverrideArguments,
+			Description:       description,
+			VaultPassID:       vaultPassID,
+			Validate:          spec.Validate,
+			LintProfile:       spec.LintProfile,
+			LintThreshold:     db.FindingSeverity(spec.LintThreshold),
+			CheckFirst:        spec.CheckFirst,
+			RequiredApprovals: spec.RequiredApprovals,
+			ApproverRole:      db.ProjectUserRole(spec.ApproverRole),
+			ApprovalExpiry:    spec.ApprovalExpiry,
+			Managed:           true,
+		}
+
+		if existing == nil {
+			r.addChange(ActionCreate, "template", spec.Alias, nil)
+
+			if r.apply {
+				if template, err = r.store.CreateTemplate(template); err != nil {
+					return err
+				}
+				if err = r.recordRevision(db.RevisionTemplate, template.ID); err != nil {
+					return err
+				}
+				r.kept["template"][template.ID] = true
+			}
+		} else {
+			r.kept["template"][existing.ID] = true
+
+			existingArguments := deref(existing.Arguments)
+			if existingArguments == "[]" {
+				existingArguments = ""
+			}
+
+			environmentName := ""
+			if existing.EnvironmentID != nil {
+				environmentName = r.environmentNames[*existing.EnvironmentID]
+			}
+
+			var d diff
+			d.add("playbook", existing.Playbook, spec.Playbook)
+			d.add("description", deref(existing.Description), spec.Description)
+			d.add("repository", r.repoNames[existing.RepositoryID], spec.Repository)
+			d.add("inventory", r.inventoryNames[existing.InventoryID], spec.Inventory)
+			d.add("environment", environmentName, spec.Environment)
+			d.add("vault_key", r.keyName(existing.VaultPassID), spec.VaultKey)
+			d.add("arguments", existingArguments, deref(arguments))
+			d.add("override_args", strconv.FormatBool(existing.OverrideArguments), strconv.FormatBool(spec.OverrideArguments))
+			d.add("validate", strconv.FormatBool(existing.Validate), strconv.FormatBool(spec.Validate))
+			d.add("lint_profile", existing.LintProfile, spec.LintProfile)
+			d.add("lint_threshold", string(existing.LintThreshold), spec.LintThreshold)
+			d.add("check_first", strconv.FormatBool(existing.CheckFirst), strconv.FormatBool(spec.CheckFirst))
+			d.add("required_approvals", strconv.Itoa(existing.RequiredApprovals), strconv.Itoa(spec.RequiredApprovals))
+			d.add("approver_role", string(existing.ApproverRole), spec.ApproverRole)
+			d.add("approval_expiry", strconv.Itoa(existing.ApprovalExpiry), strconv.Itoa(spec.ApprovalExpiry))
+			d.addManaged(existing.Managed)
+
+			template.ID = existing.ID
+
+			if len(d) > 0 {
+				r.addChange(ActionUpdate, "template", spec.Alias, d)
+
+				if r.apply {
+					if err = r.store.UpdateTemplate(template); err != nil {
+						return err
+					}
+					if err = r.recordRevision(db.RevisionTemplate, template.ID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if err = r.syncSchedules(spec, existing, template.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncSchedules makes schedules of the template match the spec, schedules are matched by cron expressions.
+func (r *reconciler) syncSchedules(spec TemplateSpec, existing *db.Template, templateID int) error {
+	var schedules []db.Schedule
+
+	if existing != nil {
+		var err error
+		if schedules, err = r.store.GetTemplateSchedules(r.projectID, existing.ID); err != nil {
+			return err
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, cronFormat := range spec.Schedules {
+		wanted[cronFormat] = true
+	}
+
+	present := make(map[string]bool)
+	for _, schedule := range schedules {
+		if wanted[schedule.CronFormat] && !present[schedule.CronFormat] {
+			present[schedule.CronFormat] = true
+			continue
+		}
+
+		r.addChange(ActionDelete, "schedule", spec.Alias, diff{{Field: "cron_format", Old: schedule.CronFormat}})
+
+		if r.apply {
+			if err := r.store.DeleteSchedule(r.projectID, schedule.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cronFormat := range spec.Schedules {
+		if present[cronFormat] {
+			continue
+		}
+		present[cronFormat] = true
+
+		r.addChange(ActionCreate, "schedule", spec.Alias, diff{{Field: "cron_format", New: cronFormat}})
+
+		if r.apply {
+			_, err := r.store.CreateSchedule(db.Schedule{
+				ProjectID:  r.projectID,
+				TemplateID: templateID,
+				CronFormat: cronFormat,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) deleteTemplates() error {
+	for _, template := range r.templates {
+		if !template.Managed || r.kept["template"][template.ID] {
+			continue
+		}
+
+		if err := r.syncSchedules(TemplateSpec{Alias: template.Alias}, &template, template.ID); err != nil {
+			return err
+		}
+
+		r.addChange(ActionDelete, "template", template.Alias, nil)
+
+		if r.apply {
+			if err := r.store.DeleteTemplate(r.projectID, template.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) deleteInventories() error {
+	for _, inventory := range r.inventories {
+		if !inventory.Managed || r.kept["inventory"][inventory.ID] {
+			continue
+		}
+
+		r.addChange(ActionDelete, "inventory", inventory.Name, nil)
+
+		if r.apply {
+			if err := r.store.DeleteInventorySoft(r.projectID, inventory.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) deleteEnvironments() error {
+	for _, environment := range r.environments {
+		if !environment.Managed || r.kept["environment"][environment.ID] {
+			continue
+		}
+
+		r.addChange(ActionDelete, "environment", environment.Name, nil)
+
+		if r.apply {
+			if err := r.store.DeleteEnvironmentSoft(r.projectID, environment.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
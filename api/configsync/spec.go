@@ -0,0 +1,148 @@
+package configsync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// Spec is the declarative definition of project objects stored in a repository.
+// Keys and repositories are not managed by the spec because they contain secrets,
+// objects reference them by name.
+type Spec struct {
+	Environments []EnvironmentSpec `yaml:"environments"`
+	Inventories  []InventorySpec   `yaml:"inventories"`
+	Templates    []TemplateSpec    `yaml:"templates"`
+}
+
+type EnvironmentSpec struct {
+	Name string `yaml:"name"`
+	// JSON with extra variables, "{}" if empty.
+	JSON string `yaml:"json"`
+}
+
+type InventorySpec struct {
+	Name string `yaml:"name"`
+	// Type is static or file, static if empty.
+	Type      string `yaml:"type"`
+	Inventory string `yaml:"inventory"`
+	SSHKey    string `yaml:"ssh_key"`
+	BecomeKey string `yaml:"become_key"`
+}
+
+type TemplateSpec struct {
+	Alias             string   `yaml:"alias"`
+	Playbook          string   `yaml:"playbook"`
+	Description       string   `yaml:"description"`
+	Repository        string   `yaml:"repository"`
+	Inventory         string   `yaml:"inventory"`
+	Environment       string   `yaml:"environment"`
+	VaultKey          string   `yaml:"vault_key"`
+	Arguments         []string `yaml:"arguments"`
+	OverrideArguments bool     `yaml:"override_args"`
+	// Validate, LintProfile and LintThreshold configure the pre-flight validation.
+	Validate      bool   `yaml:"validate"`
+	LintProfile   string `yaml:"lint_profile"`
+	LintThreshold string `yaml:"lint_threshold"`
+	// CheckFirst launches preview changes with --check --diff and wait for approval.
+	CheckFirst bool `yaml:"check_first"`
+	// RequiredApprovals, ApproverRole and ApprovalExpiry configure the approval of tasks.
+	RequiredApprovals int    `yaml:"required_approvals"`
+	ApproverRole      string `yaml:"approver_role"`
+	ApprovalExpiry    int    `yaml:"approval_expiry"`
+	// Schedules are cron expressions of template runs.
+	Schedules []string `yaml:"schedules"`
+}
+
+// ParseSpec decodes and validates the spec. Unknown fields are rejected
+// to catch misspelled names.
+func ParseSpec(data []byte) (spec Spec, err error) {
+	if err = yaml.UnmarshalStrict(data, &spec); err != nil {
+		err = fmt.Errorf("invalid spec: %s", err.Error())
+		return
+	}
+
+	err = spec.validate()
+	return
+}
+
+func (spec *Spec) validate() error {
+	environments := make(map[string]bool)
+	for i := range spec.Environments {
+		env := &spec.Environments[i]
+
+		if env.Name == "" {
+			return fmt.Errorf("environment name can not be empty")
+		}
+		if environments[env.Name] {
+			return fmt.Errorf("duplicate environment %q", env.Name)
+		}
+		environments[env.Name] = true
+
+		if env.JSON == "" {
+			env.JSON = "{}"
+		}
+		if !json.Valid([]byte(env.JSON)) {
+			return fmt.Errorf("environment %q must contain valid JSON", env.Name)
+		}
+	}
+
+	inventories := make(map[string]bool)
+	for i := range spec.Inventories {
+		inventory := &spec.Inventories[i]
+
+		if inventory.Name == "" {
+			return fmt.Errorf("inventory name can not be empty")
+		}
+		if inventories[inventory.Name] {
+			return fmt.Errorf("duplicate inventory %q", inventory.Name)
+		}
+		inventories[inventory.Name] = true
+
+		switch inventory.Type {
+		case "":
+			inventory.Type = "static"
+		case "static", "file":
+		default:
+			return fmt.Errorf("inventory %q has unknown type %q", inventory.Name, inventory.Type)
+		}
+	}
+
+	templates := make(map[string]bool)
+	for _, template := range spec.Templates {
+		if template.Alias == "" {
+			return fmt.Errorf("template alias can not be empty")
+		}
+		if templates[template.Alias] {
+			return fmt.Errorf("duplicate template %q", template.Alias)
+		}
+		templates[template.Alias] = true
+
+		if template.Playbook == "" || template.Repository == "" || template.Inventory == "" {
+			return fmt.Errorf("template %q requires playbook, repository and inventory", template.Alias)
+		}
+
+		if template.LintThreshold != "" && !db.FindingSeverity(template.LintThreshold).IsValid() {
+			return fmt.Errorf("template %q has invalid lint threshold %q", template.Alias, template.LintThreshold)
+		}
+
+		if template.RequiredApprovals < 0 || template.ApprovalExpiry < 0 {
+			return fmt.Errorf("template %q has negative approval settings", template.Alias)
+		}
+
+		if template.RequiredApprovals > 0 && !db.ProjectUserRole(template.ApproverRole).IsValid() {
+			return fmt.Errorf("template %q has invalid approver role %q", template.Alias, template.ApproverRole)
+		}
+
+		for _, schedule := range template.Schedules {
+			if _, err := cron.ParseStandard(schedule); err != nil {
+				return fmt.Errorf("template %q has invalid schedule %q", template.Alias, schedule)
+			}
+		}
+	}
+
+	return nil
+}
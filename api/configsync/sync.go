@@ -0,0 +1,243 @@
+// Package configsync reconciles project objects with the declarative spec
+// stored in a git repository of the project.
+package configsync
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/schedules"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+// ErrCommitChanged is returned by Apply if the repository has changed since the plan.
+var ErrCommitChanged = errors.New("repository has changed since the plan")
+
+// maxErrorLength limits the length of the error stored in the sync status.
+const maxErrorLength = 1000
+
+// Result contains changes planned or applied for the commit of the spec.
+type Result struct {
+	Commit  string   `json:"commit"`
+	Changes []Change `json:"changes"`
+}
+
+// syncLocks prevent concurrent reconciliation of a project which could create objects twice.
+// Projects are locked separately, so a slow repository does not block other projects.
+var syncLocks = struct {
+	sync.Mutex
+	items map[int]*sync.Mutex
+}{items: make(map[int]*sync.Mutex)}
+
+// lockProject locks reconciliation of the project and returns the function which unlocks it.
+func lockProject(projectID int) func() {
+	syncLocks.Lock()
+	lock, ok := syncLocks.items[projectID]
+	if !ok {
+		lock = &sync.Mutex{}
+		syncLocks.items[projectID] = lock
+	}
+	syncLocks.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func loadSpec(store db.Store, projectSync db.ProjectSync) (spec Spec, commit string, err error) {
+	data, commit, err := fetchSpec(store, projectSync)
+	if err != nil {
+		return
+	}
+
+	spec, err = ParseSpec(data)
+	return
+}
+
+// Plan returns changes which Apply would make to the project.
+func Plan(store db.Store, projectID int) (Result, error) {
+	defer lockProject(projectID)()
+
+	projectSync, err := store.GetProjectSync(projectID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	spec, commit, err := loadSpec(store, projectSync)
+	if err != nil {
+		return Result{}, err
+	}
+
+	changes, err := reconcile(store, projectID, spec, false, nil)
+	return Result{Commit: commit, Changes: changes}, err
+}
+
+// Apply makes the project match the spec. If commit is not empty, the spec
+// is applied only if it is read from this commit. The outcome is stored in
+// the sync status and an event is created if the project has changed.
+func Apply(store db.Store, projectID int, commit string, userID *int) (Result, error) {
+	defer lockProject(projectID)()
+
+	projectSync, err := store.GetProjectSync(projectID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	spec, headCommit, err := loadSpec(store, projectSync)
+
+	if err == nil && commit != "" && commit != headCommit {
+		return Result{Commit: headCommit}, ErrCommitChanged
+	}
+
+	var changes []Change
+	if err == nil {
+		changes, err = reconcile(store, projectID, spec, true, userID)
+	}
+
+	now := time.Now()
+	projectSync.LastSynced = &now
+	projectSync.LastError = ""
+
+	if err != nil {
+		projectSync.LastError = err.Error()
+		if len(projectSync.LastError) > maxErrorLength {
+			projectSync.LastError = projectSync.LastError[:maxErrorLength]
+		}
+	} else {
+		projectSync.LastCommit = headCommit
+	}
+
+	if statusErr := store.SetProjectSync(projectSync); statusErr != nil {
+		log.Error(statusErr)
+	}
+
+	if len(changes) > 0 {
+		desc := fmt.Sprintf("Project synchronized with commit %s: %d changes", headCommit, len(changes))
+		if err != nil {
+			desc = fmt.Sprintf("Project partially synchronized with commit %s: %d changes", headCommit, len(changes))
+		}
+		oType := "Project"
+
+		_, eventErr := store.CreateEvent(db.Event{
+			UserID:      userID,
+			ProjectID:   &projectID,
+			ObjectType:  &oType,
+			ObjectID:    &projectID,
+			Description: &desc,
+		})
+		if eventErr != nil {
+			log.Error(eventErr)
+		}
+	}
+
+	return Result{Commit: headCommit, Changes: changes}, err
+}
+
+// Release makes objects managed by the spec of the project editable again.
+// Revisions of released objects are recorded on behalf of the user.
+func Release(store db.Store, projectID int, userID *int) error {
+	defer lockProject(projectID)()
+
+	templates, err := store.GetTemplates(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, template := range templates {
+		if template.Managed {
+			template.Managed = false
+			if err = store.UpdateTemplate(template); err != nil {
+				return err
+			}
+			if _, err = db.RecordRevision(store, projectID, db.RevisionTemplate, template.ID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	inventories, err := store.GetInventories(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, inventory := range inventories {
+		if inventory.Managed {
+			inventory.Managed = false
+			if err = store.UpdateInventory(inventory); err != nil {
+				return err
+			}
+			if _, err = db.RecordRevision(store, projectID, db.RevisionInventory, inventory.ID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	environments, err := store.GetEnvironments(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, environment := range environments {
+		if environment.Managed {
+			environment.Managed = false
+			if err = store.UpdateEnvironment(environment); err != nil {
+				return err
+			}
+			if _, err = db.RecordRevision(store, projectID, db.RevisionEnvironment, environment.ID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncProjects applies specs of all projects with enabled auto apply.
+func syncProjects(store db.Store, schedulePool schedules.SchedulePool) {
+	syncs, err := store.GetProjectSyncs()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	refresh := false
+
+	for _, projectSync := range syncs {
+		if !projectSync.AutoApply {
+			continue
+		}
+
+		if _, err = store.GetProject(projectSync.ProjectID); err == db.ErrNotFound {
+			// the project has been deleted
+			if err = store.DeleteProjectSync(projectSync.ProjectID); err != nil {
+				log.Error(err)
+			}
+			continue
+		}
+
+		res, err := Apply(store, projectSync.ProjectID, "", nil)
+		if err != nil {
+			log.Error(fmt.Sprintf("project %d sync failed: %s", projectSync.ProjectID, err.Error()))
+		}
+
+		refresh = refresh || len(res.Changes) > 0
+	}
+
+	if refresh {
+		schedulePool.Refresh(store)
+	}
+}
+
+// StartSync periodically applies specs of projects with enabled auto apply.
+func StartSync(store db.Store, schedulePool schedules.SchedulePool) {
+	if util.Config.ProjectSyncInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(util.Config.ProjectSyncInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		syncProjects(store, schedulePool)
+	}
+}
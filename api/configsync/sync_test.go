@@ -0,0 +1,267 @@
+package configsync
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+const testSpec = `
+environments:
+  - name: Prod
+    json: '{"env": "prod"}'
+inventories:
+  - name: Prod
+    inventory: prod.example.com
+    ssh_key: Deploy
+templates:
+  - alias: Deploy
+    playbook: deploy.yml
+    repository: Playbooks
+    inventory: Prod
+    environment: Prod
+    arguments: ["-v"]
+    schedules: ["0 1 * * *"]
+`
+
+func createSyncedProject(t *testing.T) (db.Store, db.Project) {
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_sync_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{Name: "Deploy", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository, err := store.CreateRepository(db.Repository{
+		Name: "Playbooks", ProjectID: project.ID, GitURL: "git@example.com:playbooks.git", SSHKeyID: key.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.SetProjectSync(db.ProjectSync{ProjectID: project.ID, RepositoryID: repository.ID, Path: "semaphore.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, project
+}
+
+func setSpec(spec string, commit string) {
+	fetchSpec = func(store db.Store, sync db.ProjectSync) ([]byte, string, error) {
+		return []byte(spec), commit, nil
+	}
+}
+
+func TestApply(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil; fetchSpec = fetchSpecFromGit }()
+
+	store, project := createSyncedProject(t)
+	defer store.Close()
+
+	setSpec(testSpec, "a1")
+
+	plan, err := Plan(store, project.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Commit != "a1" || len(plan.Changes) != 4 {
+		t.Fatalf("expected 4 changes, got %v", plan.Changes)
+	}
+
+	if templates, _ := store.GetTemplates(project.ID, db.RetrieveQueryParams{}); len(templates) != 0 {
+		t.Fatal("plan must not change the project")
+	}
+
+	if _, err = Apply(store, project.ID, "b2", nil); err != ErrCommitChanged {
+		t.Fatal("spec must not be applied if the commit differs from the plan")
+	}
+
+	if _, err = Apply(store, project.ID, "a1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := store.GetTemplates(project.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || !templates[0].Managed || *templates[0].Arguments != `["-v"]` {
+		t.Fatal("managed template must be created")
+	}
+
+	schedules, _ := store.GetTemplateSchedules(project.ID, templates[0].ID)
+	if len(schedules) != 1 {
+		t.Fatal("schedule must be created")
+	}
+
+	if plan, _ = Plan(store, project.ID); len(plan.Changes) != 0 {
+		t.Fatalf("applied spec must not have changes, got %v", plan.Changes)
+	}
+
+	sync, _ := store.GetProjectSync(project.ID)
+	if sync.LastCommit != "a1" || sync.LastSynced == nil {
+		t.Fatal("sync status must be updated")
+	}
+
+	setSpec(`
+inventories:
+  - name: Prod
+    inventory: prod.example.com
+    ssh_key: Deploy
+templates:
+  - alias: Deploy
+    playbook: site.yml
+    repository: Playbooks
+    inventory: Prod
+`, "c3")
+
+	res, err := Apply(store, project.ID, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// playbook, environment and arguments of the template, the schedule and the environment
+	if len(res.Changes) != 3 || res.Changes[0].Action != ActionUpdate || len(res.Changes[0].Diff) != 3 {
+		t.Fatalf("unexpected changes %v", res.Changes)
+	}
+
+	environments, _ := store.GetEnvironments(project.ID, db.RetrieveQueryParams{})
+	if len(environments) != 1 || !environments[0].Removed {
+		t.Fatal("environment removed from spec must be deleted")
+	}
+
+	if err = Release(store, project.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	template, _ := store.GetTemplate(project.ID, templates[0].ID)
+	if template.Managed || template.Playbook != "site.yml" {
+		t.Fatal("released template must not be managed")
+	}
+}
+
+func TestApplyTakesOverExistingObjects(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil; fetchSpec = fetchSpecFromGit }()
+
+	store, project := createSyncedProject(t)
+	defer store.Close()
+
+	_, err := store.CreateEnvironment(db.Environment{Name: "Prod", ProjectID: project.ID, JSON: `{"env": "prod"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setSpec("environments:\n  - name: Prod\n    json: '{\"env\": \"prod\"}'\n", "a1")
+
+	res, err := Apply(store, project.ID, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Changes) != 1 || res.Changes[0].Action != ActionUpdate || res.Changes[0].Diff[0].Field != "managed" {
+		t.Fatalf("existing environment must become managed, got %v", res.Changes)
+	}
+}
+
+func TestApplyUnknownReference(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil; fetchSpec = fetchSpecFromGit }()
+
+	store, project := createSyncedProject(t)
+	defer store.Close()
+
+	setSpec("templates:\n  - {alias: Deploy, playbook: deploy.yml, repository: Unknown, inventory: Prod}\n", "a1")
+
+	if _, err := Apply(store, project.ID, "", nil); err == nil {
+		t.Fatal("unknown repository must be rejected")
+	}
+
+	sync, _ := store.GetProjectSync(project.ID)
+	if sync.LastError == "" {
+		t.Fatal("error must be stored in sync status")
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	invalid := []string{
+		"templates:\n  - {alias: Deploy, playbok: deploy.yml}\n",
+		"inventories:\n  - {name: Prod}\n  - {name: Prod}\n",
+		"inventories:\n  - {name: Prod, type: dynamic}\n",
+		"environments:\n  - {name: Prod, json: 'not json'}\n",
+		"templates:\n  - {alias: Deploy, playbook: a.yml, repository: R, inventory: I, schedules: ['daily']}\n",
+	}
+
+	for _, spec := range invalid {
+		if _, err := ParseSpec([]byte(spec)); err == nil {
+			t.Errorf("spec must be rejected: %s", spec)
+		}
+	}
+
+	spec, err := ParseSpec([]byte("inventories:\n  - {name: Prod}\nenvironments:\n  - {name: Prod}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Inventories[0].Type != "static" || spec.Environments[0].JSON != "{}" {
+		t.Fatal("defaults must be set")
+	}
+}
+
+func TestCleanSpecPath(t *testing.T) {
+	for path, expected := range map[string]string{
+		"semaphore.yml":         "semaphore.yml",
+		"./ci/../semaphore.yml": "semaphore.yml",
+		"/semaphore.yml":        "semaphore.yml",
+		"../semaphore.yml":      "",
+		"":                      "",
+	} {
+		cleaned, err := CleanSpecPath(path)
+		if cleaned != expected || (expected == "") != (err != nil) {
+			t.Errorf("%q: expected %q, got %q, %v", path, expected, cleaned, err)
+		}
+	}
+}
+
+func TestLockProject(t *testing.T) {
+	unlock := lockProject(1)
+
+	done := make(chan bool)
+	go func() {
+		lockProject(2)()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("projects must be locked separately")
+	}
+
+	locked := make(chan bool)
+	go func() {
+		lockProject(1)()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("project must not be locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+}
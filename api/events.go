@@ -19,7 +19,7 @@ func getEvents(w http.ResponseWriter, r *http.Request, limit int) {
 	if exists {
 		project := projectObj.(db.Project)
 
-		_, err = helpers.Store(r).GetProjectUser(project.ID, user.ID)
+		_, err = db.GetProjectMemberRole(helpers.Store(r), project.ID, user.ID)
 
 		if err != nil {
 			helpers.WriteError(w, err)
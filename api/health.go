@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/api/tasks"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	versionCheckTimeout = 10 * time.Second
+)
+
+// healthBinaries are the executables required to run tasks.
+var healthBinaries = []string{"ansible-playbook", "ansible-galaxy", "git"}
+
+// healthReport is returned by unauthenticated endpoints, so it contains only names
+// of failed checks. Details of failures are logged.
+type healthReport struct {
+	Status string   `json:"status"`
+	Failed []string `json:"failed,omitempty"`
+}
+
+type binaryVersion struct {
+	modTime time.Time
+	version string
+}
+
+// binaryVersions caches reported versions by binary path,
+// so the binaries are executed again only when they are replaced.
+var binaryVersions = struct {
+	sync.Mutex
+	items map[string]binaryVersion
+}{items: make(map[string]binaryVersion)}
+
+func checkStore(r *http.Request) error {
+	return helpers.Store(r).Ping()
+}
+
+func checkTmpPath() error {
+	f, err := ioutil.TempFile(util.Config.TmpPath, ".health_")
+	if err != nil {
+		return err
+	}
+
+	_ = f.Close()
+
+	return os.Remove(f.Name())
+}
+
+func checkBinary(name string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	binaryVersions.Lock()
+	cached, ok := binaryVersions.items[path]
+	binaryVersions.Unlock()
+
+	if ok && cached.modTime.Equal(stat.ModTime()) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionCheckTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output() //nolint: gas
+	if err != nil {
+		return err
+	}
+
+	version := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	binaryVersions.Lock()
+	binaryVersions.items[path] = binaryVersion{modTime: stat.ModTime(), version: version}
+	binaryVersions.Unlock()
+
+	log.Info("Found " + path + ": " + version)
+
+	return nil
+}
+
+func checkRunner() error {
+	if !tasks.IsRunnerAlive() {
+		return errors.New("task runner is not running")
+	}
+	return nil
+}
+
+// getLiveness reports that the server is able to handle requests.
+func getLiveness(w http.ResponseWriter, r *http.Request) {
+	helpers.WriteJSON(w, http.StatusOK, healthReport{Status: healthStatusOK})
+}
+
+// getReadiness checks the dependencies required to serve requests and run tasks.
+// It responds with 503 and names of the failed checks if any of the checks fails.
+func getReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]error{
+		"store":    checkStore(r),
+		"tmp_path": checkTmpPath(),
+		"runner":   checkRunner(),
+	}
+
+	for _, name := range healthBinaries {
+		checks[name] = checkBinary(name)
+	}
+
+	report := healthReport{Status: healthStatusOK}
+
+	for name, err := range checks {
+		if err != nil {
+			util.LogErrorWithFields(err, log.Fields{"check": name})
+			report.Failed = append(report.Failed, name)
+		}
+	}
+
+	status := http.StatusOK
+
+	if len(report.Failed) > 0 {
+		sort.Strings(report.Failed)
+		report.Status = healthStatusFail
+		status = http.StatusServiceUnavailable
+	}
+
+	helpers.WriteJSON(w, status, report)
+}
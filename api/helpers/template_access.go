@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"net/http"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+func getProjectMember(r *http.Request) (role db.ProjectUserRole, userID int, teamIDs []int) {
+	if projectRole, ok := context.GetOk(r, "projectRole"); ok {
+		role = projectRole.(db.ProjectUserRole)
+	}
+	if ids, ok := context.GetOk(r, "teamIDs"); ok {
+		teamIDs = ids.([]int)
+	}
+	return role, context.Get(r, "user").(*db.User).ID, teamIDs
+}
+
+// GetTemplateAccess returns the access of the current user to the template of the project.
+func GetTemplateAccess(r *http.Request, projectID int, templateID int) (db.TemplateAccess, error) {
+	permissions, err := Store(r).GetTemplatePermissions(projectID, templateID)
+	if err != nil {
+		return db.TemplateNoAccess, err
+	}
+
+	role, userID, teamIDs := getProjectMember(r)
+
+	return db.ResolveTemplateAccess(role, userID, teamIDs, permissions), nil
+}
+
+// GetTemplateAccessResolver returns a function which resolves the access of the current user
+// to templates of the project. It is used to filter lists, so permissions are loaded once.
+func GetTemplateAccessResolver(r *http.Request, projectID int) (func(templateID int) db.TemplateAccess, error) {
+	permissions, err := Store(r).GetProjectTemplatePermissions(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	templatePermissions := make(map[int][]db.TemplatePermission)
+	for _, permission := range permissions {
+		templatePermissions[permission.TemplateID] = append(templatePermissions[permission.TemplateID], permission)
+	}
+
+	role, userID, teamIDs := getProjectMember(r)
+
+	return func(templateID int) db.TemplateAccess {
+		return db.ResolveTemplateAccess(role, userID, teamIDs, templatePermissions[templateID])
+	}, nil
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"gopkg.in/ldap.v2"
+)
+
+var errLDAPUserNotFound = errors.New("User does not exist or too many entries returned")
+
+// getLDAPTLSConfig returns the TLS configuration of the LDAP connection
+// with the custom CA bundle and the client certificate if they are configured.
+func getLDAPTLSConfig() (*tls.Config, error) {
+	serverName := util.Config.LdapServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(util.Config.LdapServer)
+		if err != nil {
+			host = util.Config.LdapServer
+		}
+		serverName = host
+	}
+
+	tlsConf := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: util.Config.LdapSkipVerify, //nolint: gas
+	}
+
+	if util.Config.LdapCACert != "" {
+		pem, err := ioutil.ReadFile(util.Config.LdapCACert)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.RootCAs = x509.NewCertPool()
+		if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", util.Config.LdapCACert)
+		}
+	}
+
+	if util.Config.LdapClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(util.Config.LdapClientCert, util.Config.LdapClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
+}
+
+// ldapDial connects to the LDAP server using the configured connection mode.
+func ldapDial() (*ldap.Conn, error) {
+	mode, err := util.Config.GetLdapConnectionMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == util.LdapConnectionPlain {
+		return ldap.Dial("tcp", util.Config.LdapServer)
+	}
+
+	tlsConf, err := getLDAPTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == util.LdapConnectionLDAPS {
+		return ldap.DialTLS("tcp", util.Config.LdapServer, tlsConf)
+	}
+
+	l, err := ldap.Dial("tcp", util.Config.LdapServer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = l.StartTLS(tlsConf); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// ldapPool keeps idle connections to the LDAP server.
+type ldapPool struct {
+	sync.Mutex
+	idle []*ldap.Conn
+}
+
+var ldapConnections ldapPool
+
+// get returns a connection bound with the read only user. Binding also checks
+// that an idle connection is still alive, broken connections are dropped.
+func (p *ldapPool) get() (*ldap.Conn, error) {
+	for {
+		p.Lock()
+		n := len(p.idle)
+		if n == 0 {
+			p.Unlock()
+			break
+		}
+		l := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.Unlock()
+
+		if err := l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err == nil {
+			return l, nil
+		}
+
+		l.Close()
+	}
+
+	l, err := ldapDial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// put returns the connection to the pool or closes it if the pool is full.
+func (p *ldapPool) put(l *ldap.Conn) {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.idle) >= util.Config.LdapPoolSize {
+		l.Close()
+		return
+	}
+
+	p.idle = append(p.idle, l)
+}
+
+func isLDAPGroupSyncEnabled() bool {
+	return len(util.Config.LdapGroupMappings) > 0
+}
+
+func getLDAPMemberOfAttribute() string {
+	if util.Config.LdapMappings.MemberOf != "" {
+		return util.Config.LdapMappings.MemberOf
+	}
+	return "memberOf"
+}
+
+// getLDAPUserAttributes returns attributes of the user entry required to create the user
+// and to synchronise groups.
+func getLDAPUserAttributes() []string {
+	attributes := []string{
+		util.Config.LdapMappings.DN,
+		util.Config.LdapMappings.Mail,
+		util.Config.LdapMappings.UID,
+		util.Config.LdapMappings.CN,
+	}
+
+	if isLDAPGroupSyncEnabled() && util.Config.LdapGroupSearchFilter == "" {
+		attributes = append(attributes, getLDAPMemberOfAttribute())
+	}
+
+	return attributes
+}
+
+func searchLDAPUser(l *ldap.Conn, username string, attributes []string) (*ldap.Entry, error) {
+	searchRequest := ldap.NewSearchRequest(
+		util.Config.LdapSearchDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(util.Config.LdapSearchFilter, ldap.EscapeFilter(username)),
+		attributes,
+		nil,
+	)
+
+	sr, err := l.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sr.Entries) != 1 {
+		return nil, errLDAPUserNotFound
+	}
+
+	return sr.Entries[0], nil
+}
+
+// getLDAPUserGroups returns DNs of groups of the user found by the group search filter
+// or listed in the memberOf attribute of the user entry.
+func getLDAPUserGroups(l *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	if !isLDAPGroupSyncEnabled() {
+		return nil, nil
+	}
+
+	if util.Config.LdapGroupSearchFilter == "" {
+		return entry.GetAttributeValues(getLDAPMemberOfAttribute()), nil
+	}
+
+	searchDN := util.Config.LdapGroupSearchDN
+	if searchDN == "" {
+		searchDN = util.Config.LdapSearchDN
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		searchDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(util.Config.LdapGroupSearchFilter, ldap.EscapeFilter(entry.DN)),
+		[]string{"dn"},
+		nil,
+	)
+
+	sr, err := l.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, len(sr.Entries))
+	for i, group := range sr.Entries {
+		groups[i] = group.DN
+	}
+
+	return groups, nil
+}
+
+// findLDAPUser authenticates the user and returns the user with DNs of its groups.
+func findLDAPUser(username, password string) (*db.User, []string, error) {
+	if !util.Config.LdapEnable {
+		return nil, nil, fmt.Errorf("LDAP not configured")
+	}
+
+	// LDAP servers may treat a bind without password as an anonymous bind
+	if password == "" {
+		return nil, nil, fmt.Errorf("empty password")
+	}
+
+	l, err := ldapConnections.get()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ldapConnections.put(l)
+
+	// Search for the given username
+	entry, err := searchLDAPUser(l, username, []string{util.Config.LdapMappings.DN})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Bind as the user to verify their password
+	userdn := entry.DN
+	if err = l.Bind(userdn, password); err != nil {
+		return nil, nil, err
+	}
+
+	// Get user info and ensure authentication in case LDAP supports unauthenticated bind
+	entry, err = searchLDAPUser(l, username, getLDAPUserAttributes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ldapUser := db.User{
+		Username: entry.GetAttributeValue(util.Config.LdapMappings.UID),
+		Created:  time.Now(),
+		Name:     entry.GetAttributeValue(util.Config.LdapMappings.CN),
+		Email:    entry.GetAttributeValue(util.Config.LdapMappings.Mail),
+		External: true,
+		Alert:    false,
+	}
+
+	var groups []string
+
+	if isLDAPGroupSyncEnabled() {
+		// groups are searched by the read only user which is allowed to see them
+		if err = l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err != nil {
+			return nil, nil, err
+		}
+
+		if groups, err = getLDAPUserGroups(l, entry); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	log.Info("User " + ldapUser.Name + " with email " + ldapUser.Email + " authorized via LDAP correctly")
+	return &ldapUser, groups, nil
+}
+
+func createLDAPSyncEvent(store db.Store, projectID int, user db.User, description string) {
+	objType := "user"
+	desc := "User " + user.Username + " " + description + " by LDAP group synchronisation"
+
+	_, err := store.CreateEvent(db.Event{
+		ProjectID:   &projectID,
+		ObjectType:  &objType,
+		ObjectID:    &user.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// getLDAPMappingRole returns the project role of members of the mapped group.
+func getLDAPMappingRole(mapping util.LdapGroupMapping) db.ProjectUserRole {
+	if mapping.Role != "" {
+		return db.ProjectUserRole(mapping.Role)
+	}
+	if mapping.Admin {
+		return db.ProjectOwner
+	}
+	return db.ProjectManager
+}
+
+// syncLDAPUserProjects makes the user a member of projects mapped to its groups
+// and removes it from other projects which have group mappings.
+// Projects without group mappings are not changed.
+func syncLDAPUserProjects(store db.Store, user db.User, groups []string) {
+	userGroups := make(map[string]bool)
+	for _, group := range groups {
+		userGroups[strings.ToLower(group)] = true
+	}
+
+	var projectIDs []int
+	managed := make(map[int]bool)
+	// desired membership: project ID -> role
+	desired := make(map[int]db.ProjectUserRole)
+
+	for _, mapping := range util.Config.LdapGroupMappings {
+		if !managed[mapping.ProjectID] {
+			managed[mapping.ProjectID] = true
+			projectIDs = append(projectIDs, mapping.ProjectID)
+		}
+
+		if !userGroups[strings.ToLower(mapping.GroupDN)] {
+			continue
+		}
+
+		role := getLDAPMappingRole(mapping)
+		if !role.IsValid() {
+			log.Error("Invalid role " + string(role) + " of LDAP group " + mapping.GroupDN)
+			continue
+		}
+
+		if current, ok := desired[mapping.ProjectID]; ok {
+			role = db.MaxProjectUserRole(current, role)
+		}
+		desired[mapping.ProjectID] = role
+	}
+
+	for _, projectID := range projectIDs {
+		role, mustBeMember := desired[projectID]
+
+		projectUser, err := store.GetProjectUser(projectID, user.ID)
+		if err != nil && err != db.ErrNotFound {
+			log.Error(err)
+			continue
+		}
+
+		isMember := err == nil
+
+		var description string
+
+		switch {
+		case mustBeMember && !isMember:
+			_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: projectID, UserID: user.ID, Role: role})
+			description = "added to team as " + string(role)
+		case mustBeMember && projectUser.Role != role:
+			projectUser.Role = role
+			err = store.UpdateProjectUser(projectUser)
+			description = "role changed to " + string(role)
+		case !mustBeMember && isMember:
+			err = store.DeleteProjectUser(projectID, user.ID)
+			description = "removed from team"
+		default:
+			continue
+		}
+
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		createLDAPSyncEvent(store, projectID, user, description)
+	}
+}
+
+// syncLDAPUsers synchronises project membership of all external users with their LDAP groups.
+// Users missing from LDAP are removed from the projects mapped to groups.
+func syncLDAPUsers(store db.Store) error {
+	users, err := store.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	l, err := ldapConnections.get()
+	if err != nil {
+		return err
+	}
+	defer ldapConnections.put(l)
+
+	for _, user := range users {
+		if !user.External {
+			continue
+		}
+
+		entry, err := searchLDAPUser(l, user.Username, getLDAPUserAttributes())
+
+		if err == errLDAPUserNotFound {
+			// users of OpenID Connect providers are not managed by LDAP, other external
+			// users have been deleted or disabled in LDAP and lose mapped memberships
+			if user.OidcIssuer == "" && user.OidcSubject == "" {
+				syncLDAPUserProjects(store, user, nil)
+			}
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		groups, err := getLDAPUserGroups(l, entry)
+		if err != nil {
+			return err
+		}
+
+		syncLDAPUserProjects(store, user, groups)
+	}
+
+	return nil
+}
+
+// StartLDAPSync periodically synchronises project membership of LDAP users
+// with their groups if the synchronisation interval is configured.
+func StartLDAPSync(store db.Store) {
+	if !util.Config.LdapEnable || !isLDAPGroupSyncEnabled() || util.Config.LdapSyncInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(util.Config.LdapSyncInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := syncLDAPUsers(store); err != nil {
+			log.Error(err)
+		}
+	}
+}
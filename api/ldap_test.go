@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+func TestSyncLDAPUserProjects(t *testing.T) {
+	store, _ := createTestRouter(t)
+	defer store.Close()
+
+	var projects []db.Project
+	for _, name := range []string{"Managed1", "Managed2", "Unmanaged"} {
+		project, err := store.CreateProject(db.Project{Name: name, Created: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		projects = append(projects, project)
+	}
+
+	user, err := store.CreateUserWithoutPassword(db.User{Username: "ldapuser", Name: "LDAP User", External: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	util.Config = &util.ConfigType{
+		LdapGroupMappings: []util.LdapGroupMapping{
+			{GroupDN: "cn=devs,ou=groups,dc=example", ProjectID: projects[0].ID},
+			{GroupDN: "cn=ops,ou=groups,dc=example", ProjectID: projects[0].ID, Role: string(db.ProjectOwner)},
+			{GroupDN: "cn=devs,ou=groups,dc=example", ProjectID: projects[1].ID},
+		},
+	}
+	defer func() { util.Config = nil }()
+
+	for _, projectID := range []int{projects[1].ID, projects[2].ID} {
+		if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: projectID, UserID: user.ID, Role: db.ProjectManager}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncLDAPUserProjects(store, user, []string{"CN=Ops,OU=Groups,DC=example"})
+
+	projectUser, err := store.GetProjectUser(projects[0].ID, user.ID)
+	if err != nil || projectUser.Role != db.ProjectOwner {
+		t.Fatal("user must be added to the project as owner")
+	}
+
+	if _, err = store.GetProjectUser(projects[1].ID, user.ID); err != db.ErrNotFound {
+		t.Fatal("user must be removed from the managed project")
+	}
+
+	if _, err = store.GetProjectUser(projects[2].ID, user.ID); err != nil {
+		t.Fatal("unmanaged project must not be changed")
+	}
+
+	events, err := store.GetEvents(projects[0].ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+}
+
+func TestGetLDAPTLSConfig(t *testing.T) {
+	util.Config = &util.ConfigType{LdapServer: "ldap.example.com:636"}
+	defer func() { util.Config = nil }()
+
+	conf, err := getLDAPTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.ServerName != "ldap.example.com" || conf.InsecureSkipVerify {
+		t.Fatal("certificate of the server must be verified by default")
+	}
+
+	util.Config.LdapCACert = "/nonexistent/ca.pem"
+	if _, err = getLDAPTLSConfig(); err == nil {
+		t.Fatal("missing CA bundle must be reported")
+	}
+}
@@ -1,8 +1,6 @@
 package api
 
 import (
-	"crypto/tls"
-	"fmt"
 	"github.com/ansible-semaphore/semaphore/api/helpers"
 	"github.com/ansible-semaphore/semaphore/db"
 	"net/http"
@@ -13,87 +11,8 @@ import (
 	"github.com/ansible-semaphore/semaphore/util"
 
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/ldap.v2"
 )
 
-func findLDAPUser(username, password string) (*db.User, error) {
-	if !util.Config.LdapEnable {
-		return nil, fmt.Errorf("LDAP not configured")
-	}
-
-	l, err := ldap.Dial("tcp", util.Config.LdapServer)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
-	// Reconnect with TLS if needed
-	if util.Config.LdapNeedTLS {
-		// TODO: InsecureSkipVerify should be configurable
-		tlsConf := tls.Config{
-			InsecureSkipVerify: true, //nolint: gas
-		}
-		if err = l.StartTLS(&tlsConf); err != nil {
-			return nil, err
-		}
-	}
-
-	// First bind with a read only user
-	if err = l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err != nil {
-		return nil, err
-	}
-
-	// Search for the given username
-	searchRequest := ldap.NewSearchRequest(
-		util.Config.LdapSearchDN,
-		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
-		fmt.Sprintf(util.Config.LdapSearchFilter, username),
-		[]string{util.Config.LdapMappings.DN},
-		nil,
-	)
-
-	sr, err := l.Search(searchRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(sr.Entries) != 1 {
-		return nil, fmt.Errorf("User does not exist or too many entries returned")
-	}
-
-	// Bind as the user to verify their password
-	userdn := sr.Entries[0].DN
-	if err = l.Bind(userdn, password); err != nil {
-		return nil, err
-	}
-
-	// Get user info and ensure authentication in case LDAP supports unauthenticated bind
-	searchRequest = ldap.NewSearchRequest(
-		util.Config.LdapSearchDN,
-		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
-		fmt.Sprintf(util.Config.LdapSearchFilter, username),
-		[]string{util.Config.LdapMappings.DN, util.Config.LdapMappings.Mail, util.Config.LdapMappings.UID, util.Config.LdapMappings.CN},
-		nil,
-	)
-
-	sr, err = l.Search(searchRequest)
-	if err != nil {
-		return nil, err
-	}
-
-	ldapUser := db.User{
-		Username: sr.Entries[0].GetAttributeValue(util.Config.LdapMappings.UID),
-		Created:  time.Now(),
-		Name:     sr.Entries[0].GetAttributeValue(util.Config.LdapMappings.CN),
-		Email:    sr.Entries[0].GetAttributeValue(util.Config.LdapMappings.Mail),
-		External: true,
-		Alert:    false,
-	}
-
-	log.Info("User " + ldapUser.Name + " with email " + ldapUser.Email + " authorized via LDAP correctly")
-	return &ldapUser, nil
-}
-
 //nolint: gocyclo
 func login(w http.ResponseWriter, r *http.Request) {
 	var login struct {
@@ -115,17 +34,22 @@ func login(w http.ResponseWriter, r *http.Request) {
 
 	login.Auth = strings.ToLower(login.Auth)
 
+	if !checkLoginThrottle(w, r, login.Auth) {
+		return
+	}
+
 	var ldapUser *db.User
+	var ldapGroups []string
 	if util.Config.LdapEnable {
 		// search LDAP for users
-		if lu, err := findLDAPUser(login.Auth, login.Password); err == nil {
+		if lu, groups, err := findLDAPUser(login.Auth, login.Password); err == nil {
 			ldapUser = lu
+			ldapGroups = groups
 		} else {
 			log.Info(err.Error())
 		}
 	}
 
-
 	user, err := helpers.Store(r).GetUserByLoginOrEmail(login.Auth, login.Auth)
 
 	if err == db.ErrNotFound {
@@ -136,6 +60,7 @@ func login(w http.ResponseWriter, r *http.Request) {
 				panic(err)
 			}
 		} else {
+			recordLoginFailure(helpers.Store(r), r, login.Auth, nil)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -143,8 +68,9 @@ func login(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	// check if ldap user & no ldap user found
-	if user.External && ldapUser == nil {
+	// check if ldap user & no ldap user found, service accounts can't log in
+	if (user.External && ldapUser == nil) || user.Service {
+		recordLoginFailure(helpers.Store(r), r, login.Auth, &user)
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -152,6 +78,7 @@ func login(w http.ResponseWriter, r *http.Request) {
 	// non-ldap login
 	if !user.External {
 		if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(login.Password)); err != nil {
+			recordLoginFailure(helpers.Store(r), r, login.Auth, &user)
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
@@ -159,13 +86,80 @@ func login(w http.ResponseWriter, r *http.Request) {
 		// authenticated.
 	}
 
+	if user.EmailUnverified && util.Config.EmailVerification {
+		helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Email is not verified",
+		})
+		return
+	}
+
+	if ldapUser != nil && isLDAPGroupSyncEnabled() {
+		syncLDAPUserProjects(helpers.Store(r), user, ldapGroups)
+	}
+
+	// the account counter covers failed TOTP codes too,
+	// so it is reset only when the second step of the login succeeds
+	if startSession(w, r, user) == "" {
+		resetLoginFailures(login.Auth)
+	}
+}
+
+// startSession creates a session of the authenticated user and responds with
+// the second step of the login if it is required. It returns the verification
+// method of the session, which is empty if the login is complete.
+func startSession(w http.ResponseWriter, r *http.Request, user db.User) string {
+	verificationMethod, err := getSessionVerificationMethod(helpers.Store(r), user)
+	if err != nil {
+		panic(err)
+	}
+
+	createSession(w, r, user, verificationMethod)
+
+	if verificationMethod != "" {
+		// the session must be verified by the second step of the login
+		helpers.WriteJSON(w, http.StatusOK, map[string]string{
+			"verification": verificationMethod,
+		})
+		return verificationMethod
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return ""
+}
+
+// getSessionVerificationMethod returns the second step of the login required for the user.
+// Local users must enter the TOTP code if they have enrolled it,
+// or enrol it if two-factor authentication is required.
+func getSessionVerificationMethod(store db.Store, user db.User) (string, error) {
+	if user.External {
+		return "", nil
+	}
+
+	totp, err := store.GetUserTotp(user.ID)
+
+	switch {
+	case err == nil && totp.Confirmed:
+		return db.SessionVerificationTotp, nil
+	case err != nil && err != db.ErrNotFound:
+		return "", err
+	case util.Config.TotpRequired:
+		return db.SessionVerificationTotpEnrolment, nil
+	}
+
+	return "", nil
+}
+
+// createSession creates a new session of the authenticated user and sets the session cookie.
+// A session with the verification method is not usable until it is verified.
+func createSession(w http.ResponseWriter, r *http.Request, user db.User, verificationMethod string) {
 	newSession, err := helpers.Store(r).CreateSession(db.Session{
-		UserID:     user.ID,
-		Created:    time.Now(),
-		LastActive: time.Now(),
-		IP:         r.Header.Get("X-Real-IP"),
-		UserAgent:  r.Header.Get("user-agent"),
-		Expired:    false,
+		UserID:             user.ID,
+		Created:            time.Now(),
+		LastActive:         time.Now(),
+		IP:                 r.Header.Get("X-Real-IP"),
+		UserAgent:          r.Header.Get("user-agent"),
+		Expired:            false,
+		VerificationMethod: verificationMethod,
 	})
 
 	if err != nil {
@@ -185,8 +179,6 @@ func login(w http.ResponseWriter, r *http.Request) {
 		Value: encoded,
 		Path:  "/",
 	})
-
-	w.WriteHeader(http.StatusNoContent)
 }
 
 func logout(w http.ResponseWriter, r *http.Request) {
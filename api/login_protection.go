@@ -0,0 +1,196 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+const (
+	// loginFreeAttempts is the number of failed attempts which are not delayed
+	loginFreeAttempts = 3
+	// loginAttemptsCleanupSize is the number of tracked keys which triggers removal of stale ones
+	loginAttemptsCleanupSize = 1000
+)
+
+type loginAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// loginThrottle tracks failed login attempts in memory by account and by IP address.
+type loginThrottle struct {
+	sync.Mutex
+	attempts map[string]*loginAttempts
+}
+
+var loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+
+func loginAccountKey(login string) string {
+	return "account:" + strings.ToLower(login)
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// getClientIP returns the address attempts are counted by. Forwarded headers are
+// honoured only in requests from trusted proxies, otherwise clients could rotate them
+// to bypass the limit or spoof them to block other addresses.
+func getClientIP(r *http.Request) string {
+	peer := getPeerIP(r)
+	if util.Config.LoginProtection.IsTrustedProxy(peer) {
+		return getRemoteIP(r)
+	}
+	return peer
+}
+
+func getLoginLockoutDuration() time.Duration {
+	if util.Config.LoginProtection.LockoutDuration < 1 {
+		return 15 * time.Minute
+	}
+	return time.Duration(util.Config.LoginProtection.LockoutDuration) * time.Minute
+}
+
+// retryAfter returns how long the next attempt is not allowed for the given keys.
+func (t *loginThrottle) retryAfter(now time.Time, keys ...string) time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	var wait time.Duration
+
+	for _, key := range keys {
+		a, ok := t.attempts[key]
+		if !ok {
+			continue
+		}
+
+		blockedUntil := a.blockedUntil
+
+		if a.failures > loginFreeAttempts {
+			backoff := time.Duration(math.Pow(2, float64(a.failures-loginFreeAttempts-1))) * time.Second
+			if lockout := getLoginLockoutDuration(); backoff > lockout {
+				backoff = lockout
+			}
+			if a.lastFailure.Add(backoff).After(blockedUntil) {
+				blockedUntil = a.lastFailure.Add(backoff)
+			}
+		}
+
+		if d := blockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// fail records the failed attempt and returns true if the key has been locked out by it.
+func (t *loginThrottle) fail(now time.Time, key string, maxAttempts int) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	lockout := getLoginLockoutDuration()
+
+	if len(t.attempts) >= loginAttemptsCleanupSize {
+		for k, a := range t.attempts {
+			if now.Sub(a.lastFailure) > lockout && now.After(a.blockedUntil) {
+				delete(t.attempts, k)
+			}
+		}
+	}
+
+	a, ok := t.attempts[key]
+	if !ok || (now.Sub(a.lastFailure) > lockout && now.After(a.blockedUntil)) {
+		a = &loginAttempts{}
+		t.attempts[key] = a
+	}
+
+	a.failures++
+	a.lastFailure = now
+
+	if maxAttempts > 0 && a.failures >= maxAttempts {
+		a.failures = 0
+		a.blockedUntil = now.Add(lockout)
+		return true
+	}
+
+	return false
+}
+
+func (t *loginThrottle) reset(key string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.attempts, key)
+}
+
+// isIPThrottleEnabled returns true if failed attempts are counted by IP address.
+func isIPThrottleEnabled() bool {
+	return util.Config.LoginProtection.MaxIPAttempts > 0
+}
+
+// checkLoginThrottle responds with 429 if logins of the account or from the IP address are throttled.
+func checkLoginThrottle(w http.ResponseWriter, r *http.Request, login string) bool {
+	keys := []string{loginAccountKey(login)}
+	if isIPThrottleEnabled() {
+		keys = append(keys, loginIPKey(getClientIP(r)))
+	}
+
+	wait := loginFailures.retryAfter(time.Now(), keys...)
+	if wait <= 0 {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(`{"error":"Too many failed login attempts, try again later"}`))
+	return false
+}
+
+func createLockoutEvent(store db.Store, user *db.User, description string) {
+	event := db.Event{Description: &description}
+
+	if user != nil {
+		objType := "user"
+		event.UserID = &user.ID
+		event.ObjectType = &objType
+		event.ObjectID = &user.ID
+	}
+
+	if _, err := store.CreateEvent(event); err != nil {
+		log.Error(err)
+	}
+}
+
+// recordLoginFailure counts the failed attempt and creates events when the account
+// or the IP address are locked out. user is nil if the account does not exist.
+func recordLoginFailure(store db.Store, r *http.Request, login string, user *db.User) {
+	now := time.Now()
+	ip := getClientIP(r)
+	lockout := strconv.Itoa(int(getLoginLockoutDuration().Minutes()))
+
+	if loginFailures.fail(now, loginAccountKey(login), util.Config.LoginProtection.MaxAccountAttempts) {
+		desc := "Login " + login + " locked out for " + lockout + " minutes after too many failed attempts"
+		log.Warn(desc)
+		createLockoutEvent(store, user, desc)
+	}
+
+	if isIPThrottleEnabled() && loginFailures.fail(now, loginIPKey(ip), util.Config.LoginProtection.MaxIPAttempts) {
+		desc := "IP address " + ip + " blocked for " + lockout + " minutes after too many failed login attempts"
+		log.Warn(desc)
+		createLockoutEvent(store, nil, desc)
+	}
+}
+
+func resetLoginFailures(login string) {
+	loginFailures.reset(loginAccountKey(login))
+}
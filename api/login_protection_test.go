@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/securecookie"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	util.Config = &util.ConfigType{LoginProtection: util.LoginProtection{LockoutDuration: 15}}
+	defer func() { util.Config = nil }()
+
+	throttle := &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	now := time.Now()
+
+	for i := 0; i < loginFreeAttempts; i++ {
+		throttle.fail(now, "account:john", 5)
+	}
+
+	if throttle.retryAfter(now, "account:john") != 0 {
+		t.Fatal("first attempts must not be delayed")
+	}
+
+	throttle.fail(now, "account:john", 5)
+	if wait := throttle.retryAfter(now, "account:john"); wait != time.Second {
+		t.Fatalf("next attempt must be delayed: %v", wait)
+	}
+
+	if !throttle.fail(now, "account:john", 5) {
+		t.Fatal("account must be locked out after the limit")
+	}
+
+	if wait := throttle.retryAfter(now.Add(time.Minute), "account:other", "account:john"); wait != 14*time.Minute {
+		t.Fatalf("account must be locked out for the lockout duration: %v", wait)
+	}
+
+	if throttle.retryAfter(now.Add(16*time.Minute), "account:john") != 0 {
+		t.Fatal("lockout must expire")
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	util.Config = &util.ConfigType{
+		LoginProtection: util.LoginProtection{MaxAccountAttempts: 4, MaxIPAttempts: -1},
+		PasswordPolicy:  util.PasswordPolicy{MinLength: 10},
+	}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	defer func() {
+		util.Config = nil
+		loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	}()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	if _, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "short",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	}); err == nil {
+		t.Fatal("password policy must be enforced")
+	}
+
+	if _, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "long password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+
+	for i := 0; i < 4; i++ {
+		if rr := client.do("POST", "/api/auth/login", map[string]string{"auth": "admin", "password": "wrong"}); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Response code should be 401 %d", rr.Code)
+		}
+	}
+
+	rr := client.do("POST", "/api/auth/login", map[string]string{"auth": "admin", "password": "long password"})
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("locked out account must be rejected: %d", rr.Code)
+	}
+}
+
+func TestLoginIPThrottleIsOptIn(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	defer func() {
+		util.Config = nil
+		loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	}()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	client := &testClient{router: router}
+
+	// all requests come from the same address, as they do behind a reverse proxy
+	login := func(auth string) int {
+		return client.do("POST", "/api/auth/login", map[string]string{"auth": auth, "password": "wrong"}).Code
+	}
+
+	for i := 0; i < 10; i++ {
+		if code := login("user" + strconv.Itoa(i)); code != http.StatusUnauthorized {
+			t.Fatalf("failures of other accounts must not throttle the address by default: %d", code)
+		}
+	}
+
+	util.Config.LoginProtection.MaxIPAttempts = 50
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+
+	for i := 0; i <= loginFreeAttempts; i++ {
+		login("user" + strconv.Itoa(i))
+	}
+
+	if code := login("other"); code != http.StatusTooManyRequests {
+		t.Fatalf("configured IP address limit must throttle the address: %d", code)
+	}
+}
+
+func TestGetClientIP(t *testing.T) {
+	util.Config = &util.ConfigType{LoginProtection: util.LoginProtection{TrustedProxies: []string{"10.0.0.0/8"}}}
+	defer func() { util.Config = nil }()
+
+	var clientIP string
+	handler := CapturePeerAddress(handlers.ProxyHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP = getClientIP(r)
+	})))
+
+	req, _ := http.NewRequest("POST", "/api/auth/login", nil)
+	req.RemoteAddr = "203.0.113.5:4321"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if clientIP != "203.0.113.5" {
+		t.Fatalf("forwarded headers of untrusted peers must be ignored: %s", clientIP)
+	}
+
+	req.RemoteAddr = "10.1.2.3:4321"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if clientIP != "198.51.100.1" {
+		t.Fatalf("forwarded headers of trusted proxies must be used: %s", clientIP)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/metrics"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder remembers the response code written by a handler.
+// It supports hijacking, which is required by websocket connections.
+type statusRecorder struct {
+	http.ResponseWriter
+	code     int
+	hijacked bool
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// metricsMiddleware records the latency of requests to matched routes.
+// Hijacked (websocket) connections are not recorded because their duration
+// is the lifetime of the connection.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.hijacked {
+			return
+		}
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		metrics.ObserveHTTPRequest(route, r.Method, recorder.code, time.Since(start))
+	})
+}
+
+// MetricsHandler serves metrics in the Prometheus format.
+// Requests must be authorized with the configured metrics token
+// (Authorization: Bearer <token>) unless no token is configured.
+func MetricsHandler() http.Handler {
+	handler := metrics.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if util.Config.MetricsToken != "" {
+			token, ok := readBearerToken(r)
+
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(util.Config.MetricsToken)) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
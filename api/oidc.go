@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/mux"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcCookieName = "semaphore-oidc"
+	oidcCookieTTL  = 10 * time.Minute
+)
+
+// oidcProviders caches discovered providers by ID.
+var oidcProviders = struct {
+	sync.Mutex
+	items map[string]*oidc.Provider
+}{items: make(map[string]*oidc.Provider)}
+
+type oidcProviderInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func getOidcProvider(ctx context.Context, id string) (*oidc.Provider, util.OidcProvider, error) {
+	conf, ok := util.Config.OidcProviders[id]
+	if !ok {
+		return nil, conf, db.ErrNotFound
+	}
+
+	oidcProviders.Lock()
+	defer oidcProviders.Unlock()
+
+	if provider, ok := oidcProviders.items[id]; ok {
+		return provider, conf, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, conf.ProviderURL)
+	if err != nil {
+		return nil, conf, err
+	}
+
+	oidcProviders.items[id] = provider
+	return provider, conf, nil
+}
+
+func getOidcRedirectURL(id string, conf util.OidcProvider) string {
+	if conf.RedirectURL != "" {
+		return conf.RedirectURL
+	}
+	return strings.TrimSuffix(util.Config.WebHost, "/") + "/api/auth/oidc/" + id + "/redirect"
+}
+
+func getOidcConfig(id string, provider *oidc.Provider, conf util.OidcProvider) oauth2.Config {
+	scopes := conf.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+
+	return oauth2.Config{
+		ClientID:     conf.ClientID,
+		ClientSecret: conf.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  getOidcRedirectURL(id, conf),
+		Scopes:       scopes,
+	}
+}
+
+func randomOidcString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge returns the S256 code challenge of the PKCE code verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func claimString(claims map[string]interface{}, name string, defaultName string) string {
+	if name == "" {
+		name = defaultName
+	}
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimBool returns the boolean claim, some providers send booleans as strings.
+func claimBool(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// mapOidcClaims converts ID token claims to an external user.
+func mapOidcClaims(claims map[string]interface{}, conf util.OidcProvider) (db.User, error) {
+	user := db.User{
+		Username: strings.ToLower(claimString(claims, conf.UsernameClaim, "preferred_username")),
+		Name:     claimString(claims, conf.NameClaim, "name"),
+		Email:    claimString(claims, conf.EmailClaim, "email"),
+		Created:  time.Now(),
+		External: true,
+	}
+
+	if user.Username == "" {
+		return user, errors.New("username claim is missing in the ID token")
+	}
+
+	if user.Name == "" {
+		user.Name = user.Username
+	}
+
+	return user, nil
+}
+
+// errOidcAccountConflict is returned if the account of the provider can not be bound to the user.
+var errOidcAccountConflict = errors.New("account of the identity provider conflicts with an existing user")
+
+// getOidcUser returns the user bound to the account of the provider, the account is
+// identified by the issuer and the subject of the ID token. New users are created for
+// unknown accounts. An existing user is bound only if bindByEmail is set, which requires
+// the provider to opt in and to verify the email, and only if it is an external user
+// which is not bound to another account.
+func getOidcUser(store db.Store, issuer string, subject string, oidcUser db.User, bindByEmail bool) (user db.User, err error) {
+	user, err = store.GetUserByOidcIdentity(issuer, subject)
+	if err != db.ErrNotFound {
+		return
+	}
+
+	if bindByEmail && oidcUser.Email != "" {
+		user, err = store.GetUserByLoginOrEmail("", oidcUser.Email)
+
+		switch err {
+		case nil:
+			if !user.External || user.OidcSubject != "" {
+				return db.User{}, errOidcAccountConflict
+			}
+			user.OidcIssuer = issuer
+			user.OidcSubject = subject
+			err = store.SetUserOidcIdentity(user.ID, issuer, subject)
+			return
+		case db.ErrNotFound:
+		default:
+			return
+		}
+	}
+
+	email := oidcUser.Email
+	if email == "" {
+		email = oidcUser.Username
+	}
+
+	// accounts of the provider can not take over users with the same username or email
+	if _, err = store.GetUserByLoginOrEmail(oidcUser.Username, email); err == nil {
+		return db.User{}, errOidcAccountConflict
+	} else if err != db.ErrNotFound {
+		return
+	}
+
+	oidcUser.OidcIssuer = issuer
+	oidcUser.OidcSubject = subject
+
+	return store.CreateUserWithoutPassword(oidcUser)
+}
+
+func writeOidcError(w http.ResponseWriter, status int, err error) {
+	log.Error(err)
+	helpers.WriteJSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// getLoginOptions returns the configured SSO providers to show on the login page.
+func getLoginOptions(w http.ResponseWriter, r *http.Request) {
+	providers := make([]oidcProviderInfo, 0, len(util.Config.OidcProviders))
+
+	for id, conf := range util.Config.OidcProviders {
+		name := conf.DisplayName
+		if name == "" {
+			name = id
+		}
+		providers = append(providers, oidcProviderInfo{ID: id, Name: name})
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].ID < providers[j].ID
+	})
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"oidc_providers": providers,
+	})
+}
+
+// oidcLogin starts the authorization code flow with PKCE. The state, nonce and
+// code verifier are kept in a short-lived encrypted cookie until the user is redirected back.
+func oidcLogin(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["provider"]
+
+	provider, conf, err := getOidcProvider(r.Context(), id)
+	if err == db.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeOidcError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	values := make(map[string]string)
+	for _, key := range []string{"state", "nonce", "verifier"} {
+		if values[key], err = randomOidcString(); err != nil {
+			panic(err)
+		}
+	}
+	values["provider"] = id
+
+	encoded, err := util.Cookie.Encode(oidcCookieName, values)
+	if err != nil {
+		panic(err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(oidcCookieTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	config := getOidcConfig(id, provider, conf)
+
+	http.Redirect(w, r, config.AuthCodeURL(values["state"],
+		oidc.Nonce(values["nonce"]),
+		oauth2.SetAuthURLParam("code_challenge", pkceChallenge(values["verifier"])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), http.StatusFound)
+}
+
+// oidcRedirect completes the authorization code flow: exchanges the code for tokens,
+// verifies the ID token and logs in the user, creating an external user if needed.
+//nolint: gocyclo
+func oidcRedirect(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["provider"]
+
+	provider, conf, err := getOidcProvider(r.Context(), id)
+	if err == db.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeOidcError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		writeOidcError(w, http.StatusBadRequest, errors.New("login session not found"))
+		return
+	}
+
+	values := make(map[string]string)
+	if err = util.Cookie.Decode(oidcCookieName, cookie.Value, &values); err != nil {
+		writeOidcError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   oidcCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	if values["provider"] != id || values["state"] == "" || r.URL.Query().Get("state") != values["state"] {
+		writeOidcError(w, http.StatusBadRequest, errors.New("invalid login state"))
+		return
+	}
+
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		writeOidcError(w, http.StatusUnauthorized, fmt.Errorf("identity provider returned error: %s", errCode))
+		return
+	}
+
+	config := getOidcConfig(id, provider, conf)
+
+	token, err := config.Exchange(r.Context(), r.URL.Query().Get("code"),
+		oauth2.SetAuthURLParam("code_verifier", values["verifier"]))
+	if err != nil {
+		writeOidcError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		writeOidcError(w, http.StatusUnauthorized, errors.New("ID token is missing in the token response"))
+		return
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: conf.ClientID}).Verify(r.Context(), rawIDToken)
+	if err != nil {
+		writeOidcError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if idToken.Nonce != values["nonce"] {
+		writeOidcError(w, http.StatusUnauthorized, errors.New("invalid ID token nonce"))
+		return
+	}
+
+	claims := make(map[string]interface{})
+	if err = idToken.Claims(&claims); err != nil {
+		writeOidcError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	oidcUser, err := mapOidcClaims(claims, conf)
+	if err != nil {
+		writeOidcError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	bindByEmail := conf.BindByEmail && claimBool(claims, "email_verified")
+
+	user, err := getOidcUser(helpers.Store(r), idToken.Issuer, idToken.Subject, oidcUser, bindByEmail)
+
+	if err == errOidcAccountConflict {
+		writeOidcError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	log.Info("User " + user.Name + " with email " + user.Email + " authorized via OIDC provider " + id)
+
+	createSession(w, r, user, "")
+
+	webPath := "/"
+	if util.WebHostURL != nil && util.WebHostURL.Path != "" {
+		webPath = util.WebHostURL.Path
+	}
+
+	http.Redirect(w, r, webPath, http.StatusFound)
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+// mockOidcIssuer is a minimal OpenID Connect provider which issues
+// an ID token for a single authorization code.
+type mockOidcIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string
+	nonce     string
+	claims    map[string]interface{}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (m *mockOidcIssuer) signIDToken(t *testing.T) string {
+	claims := map[string]interface{}{
+		"iss":   m.server.URL,
+		"aud":   m.clientID,
+		"sub":   "12345",
+		"nonce": m.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := b64(header) + "." + b64(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signingInput + "." + b64(signature)
+}
+
+func newMockOidcIssuer(t *testing.T) *mockOidcIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOidcIssuer{key: key, clientID: "semaphore"}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/auth",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   b64(key.PublicKey.N.Bytes()),
+				"e":   b64(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+		if r.PostForm.Get("code") != "test-code" || b64(verifierHash[:]) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     m.signIDToken(t),
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+
+	return m
+}
+
+func TestOidcLogin(t *testing.T) {
+	issuer := newMockOidcIssuer(t)
+	defer issuer.server.Close()
+
+	issuer.claims = map[string]interface{}{
+		"preferred_username": "John",
+		"name":               "John Doe",
+		"email":              "john@example.com",
+	}
+
+	util.Config = &util.ConfigType{
+		WebHost: "http://semaphore.example.com",
+		OidcProviders: map[string]util.OidcProvider{
+			"mock": {
+				ProviderURL:  issuer.server.URL,
+				ClientID:     issuer.clientID,
+				ClientSecret: "secret",
+			},
+		},
+	}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	// start login
+	req, _ := http.NewRequest("GET", "/api/auth/oidc/mock/login", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusFound {
+		t.Fatalf("Response code should be 302 %d", rr.Code)
+	}
+
+	authURL, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if authURL.Query().Get("code_challenge_method") != "S256" {
+		t.Fatal("PKCE must be used")
+	}
+
+	issuer.challenge = authURL.Query().Get("code_challenge")
+	issuer.nonce = authURL.Query().Get("nonce")
+
+	cookies := rr.Result().Cookies()
+
+	// forged state must be rejected
+	req, _ = http.NewRequest("GET", "/api/auth/oidc/mock/redirect?code=test-code&state=forged", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Response code should be 400 %d", rr.Code)
+	}
+
+	// complete login
+	req, _ = http.NewRequest("GET", "/api/auth/oidc/mock/redirect?code=test-code&state="+
+		url.QueryEscape(authURL.Query().Get("state")), nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusFound {
+		t.Fatalf("Response code should be 302 %d: %s", rr.Code, rr.Body.String())
+	}
+
+	user, err := store.GetUserByLoginOrEmail("john", "john@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.External || user.Name != "John Doe" {
+		t.Fatal("external user must be provisioned from claims")
+	}
+
+	hasSession := false
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "semaphore" && c.Value != "" {
+			hasSession = true
+		}
+	}
+
+	if !hasSession {
+		t.Fatal("session cookie must be set")
+	}
+}
+
+func TestGetOidcUser(t *testing.T) {
+	store, _ := createTestRouter(t)
+	defer store.Close()
+
+	_, err := store.CreateUserWithoutPassword(db.User{Username: "alice", Name: "Alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ldapUser, err := store.CreateUserWithoutPassword(db.User{Username: "bob", Name: "Bob", Email: "bob@example.com", External: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := "https://idp.example.com"
+
+	if _, err = getOidcUser(store, issuer, "1", db.User{Username: "alice", Email: "alice@example.com", External: true}, true); err != errOidcAccountConflict {
+		t.Fatal("local users must not be bound to accounts of the provider")
+	}
+
+	if _, err = getOidcUser(store, issuer, "2", db.User{Username: "robert", Email: "bob@example.com", External: true}, false); err != errOidcAccountConflict {
+		t.Fatal("users must not be bound by email unless the provider opts in")
+	}
+
+	user, err := getOidcUser(store, issuer, "2", db.User{Username: "robert", Email: "bob@example.com", External: true}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != ldapUser.ID {
+		t.Fatal("external user must be bound by verified email if the provider opts in")
+	}
+
+	// the bound user is found by the identity even if the claims change
+	if user, err = getOidcUser(store, issuer, "2", db.User{Username: "bobby", Email: "bobby@example.com", External: true}, false); err != nil || user.ID != ldapUser.ID {
+		t.Fatal("user must be found by the identity of the provider")
+	}
+
+	if _, err = getOidcUser(store, "https://other.example.com", "2", db.User{Username: "bob2", Email: "bob@example.com", External: true}, true); err != errOidcAccountConflict {
+		t.Fatal("users must not be bound to accounts of another provider")
+	}
+
+	user, err = getOidcUser(store, issuer, "3", db.User{Username: "carol", Name: "Carol", Email: "carol@example.com", External: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.OidcSubject != "3" || user.OidcIssuer != issuer {
+		t.Fatal("new user must be bound to the account of the provider")
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bundle"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestProjectExportImport(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateInventory(db.Inventory{Name: "Inventory", ProjectID: project.ID, Type: "static"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+
+	exportURL := "/api/project/" + strconv.Itoa(project.ID) + "/export"
+
+	if rr := clients[db.ProjectManager].do("GET", exportURL, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not export projects: %d", rr.Code)
+	}
+
+	// bundles may contain secrets of keys, read tokens must not export them
+	rr := clients[db.ProjectOwner].do("POST", "/api/user/tokens", map[string]string{"scopes": "read"})
+	var created struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+
+	readClient := &testClient{router: router, token: created.Token}
+
+	if rr = readClient.do("GET", "/api/project/"+strconv.Itoa(project.ID), nil); rr.Code != http.StatusOK {
+		t.Fatalf("read token must read the project: %d", rr.Code)
+	}
+
+	if rr = readClient.do("GET", exportURL, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("read token must not export the project: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectOwner].do("GET", exportURL+"?format=xml", nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format must be rejected: %d", rr.Code)
+	}
+
+	rr = clients[db.ProjectOwner].do("GET", exportURL+"?format=yaml", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+	if !strings.Contains(rr.Header().Get("content-type"), "yaml") {
+		t.Fatalf("bundle must be exported in YAML: %s", rr.Header().Get("content-type"))
+	}
+
+	exported, err := bundle.Unmarshal(rr.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Inventories) != 1 {
+		t.Fatal("bundle must contain project objects")
+	}
+
+	guest := clients[db.ProjectGuest]
+
+	if rr = guest.do("POST", "/api/projects/import?name=Imported", map[string]int{"version": 100}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported version must be rejected: %d", rr.Code)
+	}
+
+	rr = guest.do("POST", "/api/projects/import?name=Imported", exported)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var imported db.Project
+	_ = json.Unmarshal(rr.Body.Bytes(), &imported)
+
+	if imported.Name != "Imported" || imported.ID == project.ID {
+		t.Fatal("new project must be created")
+	}
+
+	if rr = guest.do("GET", "/api/project/"+strconv.Itoa(imported.ID)+"/export", nil); rr.Code != http.StatusOK {
+		t.Fatalf("importer must become owner of the project: %d", rr.Code)
+	}
+}
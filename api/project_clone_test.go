@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func createCloneTestProject(t *testing.T, store db.Store) (db.Project, db.Template) {
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "SSH",
+		Type:      db.AccessKeySSH,
+		ProjectID: &project.ID,
+		SshKey:    db.SshKey{PrivateKey: "private key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository, err := store.CreateRepository(db.Repository{
+		Name: "Repo", ProjectID: project.ID, GitURL: "git@example.com:test.git", SSHKeyID: key.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := store.CreateInventory(db.Inventory{
+		Name: "Inventory", ProjectID: project.ID, Type: "static", Inventory: "localhost", SSHKeyID: &key.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{
+		ProjectID:    project.ID,
+		InventoryID:  inventory.ID,
+		RepositoryID: repository.ID,
+		Alias:        "Deploy",
+		Playbook:     "deploy.yml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateSchedule(db.Schedule{ProjectID: project.ID, TemplateID: template.ID, CronFormat: "* * * * *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return project, template
+}
+
+func TestCloneProject(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, _ := createCloneTestProject(t, store)
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+
+	cloneURL := "/api/project/" + strconv.Itoa(project.ID) + "/clone"
+
+	if rr := clients[db.ProjectManager].do("POST", cloneURL, map[string]interface{}{}); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not clone projects: %d", rr.Code)
+	}
+
+	owner := clients[db.ProjectOwner]
+
+	if rr := owner.do("POST", cloneURL, map[string]string{"keys": "reference"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("keys can not be referenced by cloned project: %d", rr.Code)
+	}
+
+	rr := owner.do("POST", cloneURL, map[string]interface{}{"users": true})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var clone db.Project
+	_ = json.Unmarshal(rr.Body.Bytes(), &clone)
+
+	if clone.ID == project.ID || clone.Name != "Test (copy)" {
+		t.Fatal("new project must be created")
+	}
+
+	templates, err := store.GetTemplates(clone.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 {
+		t.Fatalf("expected 1 template, got %d", len(templates))
+	}
+
+	if _, err = store.GetInventory(clone.ID, templates[0].InventoryID); err != nil {
+		t.Fatal("template must reference inventory of cloned project")
+	}
+
+	schedules, err := store.GetTemplateSchedules(clone.ID, templates[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 0 {
+		t.Fatal("schedules must not be copied unless requested")
+	}
+
+	user, err := store.GetProjectUser(clone.ID, userIDs[db.ProjectGuest])
+	if err != nil || user.Role != db.ProjectGuest {
+		t.Fatal("project members must be copied")
+	}
+}
+
+func TestCloneTemplate(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, template := createCloneTestProject(t, store)
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+
+	cloneURL := "/api/project/" + strconv.Itoa(project.ID) + "/templates/" + strconv.Itoa(template.ID) + "/clone"
+
+	if rr := clients[db.ProjectGuest].do("POST", cloneURL, map[string]interface{}{}); rr.Code != http.StatusForbidden {
+		t.Fatalf("guest must not clone templates: %d", rr.Code)
+	}
+
+	manager := clients[db.ProjectManager]
+
+	if rr := manager.do("POST", cloneURL, map[string]string{"keys": "copy"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown keys option must be rejected: %d", rr.Code)
+	}
+
+	rr := manager.do("POST", cloneURL, map[string]interface{}{"schedules": true})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var clone db.Template
+	_ = json.Unmarshal(rr.Body.Bytes(), &clone)
+
+	if clone.Alias != "Deploy (copy)" || clone.InventoryID == template.InventoryID || clone.RepositoryID == template.RepositoryID {
+		t.Fatal("template must be cloned with its inventory and repository")
+	}
+
+	inventory, err := store.GetInventory(project.ID, clone.InventoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := store.GetInventory(project.ID, template.InventoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *inventory.SSHKeyID != *original.SSHKeyID {
+		t.Fatal("access key must be referenced by default")
+	}
+
+	schedules, err := store.GetTemplateSchedules(project.ID, clone.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 1 {
+		t.Fatal("schedules must be copied")
+	}
+
+	rr = manager.do("POST", cloneURL, map[string]string{"keys": "duplicate", "name": "Second"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &clone)
+
+	if inventory, err = store.GetInventory(project.ID, clone.InventoryID); err != nil {
+		t.Fatal(err)
+	}
+	if clone.Alias != "Second" || *inventory.SSHKeyID == *original.SSHKeyID {
+		t.Fatal("access key must be duplicated")
+	}
+
+	key, err := store.GetAccessKey(project.ID, *inventory.SSHKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.SshKey.PrivateKey != "private key" {
+		t.Fatal("duplicated key must keep its secret")
+	}
+}
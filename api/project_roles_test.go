@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
+)
+
+// createProjectMembers creates a logged in member of the project for every role.
+// Usernames of the members are the names of their roles.
+func createProjectMembers(t *testing.T, store db.Store, router *mux.Router, projectID int) (map[db.ProjectUserRole]*testClient, map[db.ProjectUserRole]int) {
+	clients := make(map[db.ProjectUserRole]*testClient)
+	userIDs := make(map[db.ProjectUserRole]int)
+
+	for _, role := range []db.ProjectUserRole{db.ProjectOwner, db.ProjectManager, db.ProjectTaskRunner, db.ProjectGuest} {
+		user, err := store.CreateUser(db.UserWithPwd{
+			Pwd:  "password",
+			User: db.User{Username: string(role), Name: string(role), Email: string(role) + "@example.com"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: projectID, UserID: user.ID, Role: role}); err != nil {
+			t.Fatal(err)
+		}
+
+		client := &testClient{router: router}
+		client.do("POST", "/api/auth/login", map[string]string{"auth": user.Username, "password": "password"})
+		clients[role] = client
+		userIDs[role] = user.ID
+	}
+
+	return clients, userIDs
+}
+
+func TestProjectRolePermissions(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+	key := map[string]interface{}{"name": "key", "type": "none", "project_id": project.ID}
+
+	if rr := clients[db.ProjectGuest].do("GET", projectURL+"/keys", nil); rr.Code != http.StatusOK {
+		t.Fatalf("guest must be able to view the project: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectGuest].do("POST", projectURL+"/tasks", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("guest must not run tasks: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("POST", projectURL+"/tasks", nil); rr.Code == http.StatusForbidden {
+		t.Fatal("task runner must be able to run tasks")
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("POST", projectURL+"/keys", key); rr.Code != http.StatusForbidden {
+		t.Fatalf("task runner must not create keys: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectManager].do("POST", projectURL+"/keys", key); rr.Code != http.StatusNoContent {
+		t.Fatalf("manager must be able to create keys: %d", rr.Code)
+	}
+
+	guestURL := projectURL + "/users/" + strconv.Itoa(userIDs[db.ProjectGuest])
+	role := map[string]string{"role": string(db.ProjectTaskRunner)}
+
+	if rr := clients[db.ProjectManager].do("PUT", guestURL, role); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not change roles: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectManager].do("DELETE", projectURL, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not delete the project: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectOwner].do("PUT", guestURL, map[string]string{"role": "root"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown role must be rejected: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectOwner].do("PUT", guestURL, role); rr.Code != http.StatusNoContent {
+		t.Fatalf("owner must be able to change roles: %d", rr.Code)
+	}
+
+	projectUser, err := store.GetProjectUser(project.ID, userIDs[db.ProjectGuest])
+	if err != nil || projectUser.Role != db.ProjectTaskRunner {
+		t.Fatal("role must be changed")
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestManagedObjectsAreReadOnly(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{Name: "None", Type: db.AccessKeyNone, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository, err := store.CreateRepository(db.Repository{Name: "Repo", ProjectID: project.ID, GitURL: "git@example.com:repo.git", SSHKeyID: key.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := store.CreateInventory(db.Inventory{Name: "Inventory", ProjectID: project.ID, Type: "static", Managed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{
+		ProjectID: project.ID, InventoryID: inventory.ID, RepositoryID: repository.ID, Alias: "Deploy", Playbook: "deploy.yml", Managed: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+	manager := clients[db.ProjectManager]
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+	templateURL := projectURL + "/templates/" + strconv.Itoa(template.ID)
+
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusBadRequest {
+		t.Fatalf("managed template must not be updated: %d", rr.Code)
+	}
+
+	if rr := manager.do("DELETE", templateURL, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("managed template must not be deleted: %d", rr.Code)
+	}
+
+	if rr := manager.do("DELETE", projectURL+"/inventory/"+strconv.Itoa(inventory.ID), nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("managed inventory must not be deleted: %d", rr.Code)
+	}
+
+	schedule := db.Schedule{ProjectID: project.ID, TemplateID: template.ID, CronFormat: "* * * * *"}
+	if rr := manager.do("POST", projectURL+"/schedules", schedule); rr.Code != http.StatusBadRequest {
+		t.Fatalf("schedules of managed template must not be added: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("PUT", projectURL+"/sync", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("task runner must not configure sync: %d", rr.Code)
+	}
+
+	if rr := manager.do("PUT", projectURL+"/sync", db.ProjectSync{RepositoryID: repository.ID, Path: "../spec.yml"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("path out of repository must be rejected: %d", rr.Code)
+	}
+
+	if rr := manager.do("PUT", projectURL+"/sync", db.ProjectSync{RepositoryID: repository.ID, Path: "spec.yml"}); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectGuest].do("GET", projectURL+"/sync", nil); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr := manager.do("DELETE", projectURL+"/sync", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	template.Playbook = "site.yml"
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusNoContent {
+		t.Fatalf("template must be editable after sync is removed: %d", rr.Code)
+	}
+}
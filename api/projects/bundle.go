@@ -0,0 +1,105 @@
+package projects
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bundle"
+	"github.com/gorilla/context"
+)
+
+// bundlePassphraseHeader contains the passphrase used to encrypt and decrypt
+// secrets of bundles. It is passed in a header to keep it out of access logs.
+const bundlePassphraseHeader = "X-Bundle-Passphrase"
+
+// maxBundleSize limits the size of imported bundles.
+const maxBundleSize = 32 << 20
+
+// ExportProject writes the bundle of the project in JSON or YAML format
+func ExportProject(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = bundle.FormatJSON
+	}
+
+	exported, err := bundle.Export(helpers.Store(r), project.ID, r.Header.Get(bundlePassphraseHeader))
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	data, err := bundle.Marshal(exported, format)
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	contentType := "application/json"
+	if format == bundle.FormatYAML {
+		contentType = "application/x-yaml"
+	}
+
+	w.Header().Set("content-type", contentType)
+	w.Header().Set("content-disposition", "attachment; filename=\"project_"+strconv.Itoa(project.ID)+"."+format+"\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// ImportProject creates a new project from the bundle in the request body
+func ImportProject(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Can not read bundle",
+		})
+		return
+	}
+
+	imported, err := bundle.Unmarshal(data)
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	project, err := bundle.Import(helpers.Store(r), imported, r.Header.Get(bundlePassphraseHeader), r.URL.Query().Get("name"))
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	_, err = helpers.Store(r).CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: db.ProjectOwner})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	desc := "Project Imported"
+	oType := "Project"
+	_, err = helpers.Store(r).CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		Description: &desc,
+		ObjectType:  &oType,
+		ObjectID:    &project.ID,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, project)
+}
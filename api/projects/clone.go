@@ -0,0 +1,394 @@
+package projects
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bundle"
+	"github.com/gorilla/context"
+)
+
+const (
+	// cloneKeysReference makes copies use the same access keys as the originals.
+	cloneKeysReference = "reference"
+	// cloneKeysDuplicate makes copies of access keys with their secrets.
+	cloneKeysDuplicate = "duplicate"
+)
+
+type cloneOptions struct {
+	// Name is the name of the new project or the alias of the new template.
+	Name string `json:"name"`
+	// Schedules are copied if set.
+	Schedules bool `json:"schedules"`
+	// Users copies members and teams of the project and permissions of templates.
+	Users bool `json:"users"`
+	// Keys is cloneKeysReference or cloneKeysDuplicate.
+	Keys string `json:"keys"`
+}
+
+// CloneProject creates a copy of the project with its keys, repositories,
+// inventories, environments and templates. The user becomes the owner of the copy.
+func CloneProject(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	store := helpers.Store(r)
+
+	var options cloneOptions
+	if !helpers.Bind(w, r, &options) {
+		return
+	}
+
+	switch options.Keys {
+	case "", cloneKeysDuplicate:
+	case cloneKeysReference:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Access keys can be referenced only by templates cloned within the project",
+		})
+		return
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Keys must be reference or duplicate",
+		})
+		return
+	}
+
+	if options.Name == "" {
+		options.Name = project.Name + " (copy)"
+	}
+
+	clone, templateIDs, err := bundle.Clone(store, project.ID, options.Name, options.Schedules)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: clone.ID, UserID: user.ID, Role: db.ProjectOwner})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if options.Users {
+		if err = cloneProjectMembers(store, project.ID, clone.ID, user.ID, templateIDs); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
+	if options.Schedules {
+		refreshSchedulePool(r)
+	}
+
+	desc := "Project cloned from " + project.Name
+	objType := "Project"
+	_, err = store.CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &clone.ID,
+		ObjectType:  &objType,
+		ObjectID:    &clone.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, clone)
+}
+
+// cloneProjectMembers copies users, teams and template permissions to the cloned project.
+func cloneProjectMembers(store db.Store, projectID int, cloneID int, ownerID int, templateIDs map[int]int) error {
+	users, err := store.GetProjectUsers(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.ID == ownerID {
+			continue
+		}
+		_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: cloneID, UserID: user.ID, Role: user.Role})
+		if err != nil {
+			return err
+		}
+	}
+
+	teams, err := store.GetProjectTeams(projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		_, err = store.CreateProjectTeam(db.ProjectTeam{ProjectID: cloneID, TeamID: team.ID, Role: team.Role})
+		if err != nil {
+			return err
+		}
+	}
+
+	permissions, err := store.GetProjectTemplatePermissions(projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		templateID, ok := templateIDs[permission.TemplateID]
+		if !ok {
+			continue
+		}
+
+		permission.ID = 0
+		permission.ProjectID = cloneID
+		permission.TemplateID = templateID
+
+		if _, err = store.CreateTemplatePermission(permission); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateCloner copies the template with its repository, inventory and
+// environment within the project.
+type templateCloner struct {
+	store         db.Store
+	projectID     int
+	duplicateKeys bool
+	// new IDs of duplicated keys by old IDs
+	keys map[int]int
+	// rollback deletes created objects if cloning fails
+	rollback []func() error
+}
+
+func (c *templateCloner) undo() {
+	for i := len(c.rollback) - 1; i >= 0; i-- {
+		if err := c.rollback[i](); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func (c *templateCloner) key(keyID int) (int, error) {
+	if !c.duplicateKeys {
+		return keyID, nil
+	}
+
+	if newID, ok := c.keys[keyID]; ok {
+		return newID, nil
+	}
+
+	key, err := c.store.GetAccessKey(c.projectID, keyID)
+	if err != nil {
+		return 0, err
+	}
+
+	key.ID = 0
+	key.Name += " (copy)"
+	key.Removed = false
+
+	key, err = c.store.CreateAccessKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	c.rollback = append(c.rollback, func() error { return c.store.DeleteAccessKey(c.projectID, key.ID) })
+	c.keys[keyID] = key.ID
+	return key.ID, nil
+}
+
+func (c *templateCloner) optionalKey(keyID *int) (*int, error) {
+	if keyID == nil {
+		return nil, nil
+	}
+
+	newID, err := c.key(*keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newID, nil
+}
+
+func (c *templateCloner) clone(template db.Template, alias string) (clone db.Template, err error) {
+	repository, err := c.store.GetRepository(c.projectID, template.RepositoryID)
+	if err != nil {
+		return
+	}
+
+	repository.ID = 0
+	repository.Name += " (copy)"
+	repository.Removed = false
+	if repository.SSHKeyID, err = c.key(repository.SSHKeyID); err != nil {
+		return
+	}
+
+	if repository, err = c.store.CreateRepository(repository); err != nil {
+		return
+	}
+	c.rollback = append(c.rollback, func() error { return c.store.DeleteRepository(c.projectID, repository.ID) })
+
+	inventory, err := c.store.GetInventory(c.projectID, template.InventoryID)
+	if err != nil {
+		return
+	}
+
+	inventory.ID = 0
+	inventory.Name += " (copy)"
+	inventory.Removed = false
+	inventory.Managed = false
+	if inventory.SSHKeyID, err = c.optionalKey(inventory.SSHKeyID); err != nil {
+		return
+	}
+	if inventory.BecomeKeyID, err = c.optionalKey(inventory.BecomeKeyID); err != nil {
+		return
+	}
+
+	if inventory, err = c.store.CreateInventory(inventory); err != nil {
+		return
+	}
+	c.rollback = append(c.rollback, func() error { return c.store.DeleteInventory(c.projectID, inventory.ID) })
+
+	clone = template
+	clone.ID = 0
+	clone.Alias = alias
+	clone.InventoryID = inventory.ID
+	clone.RepositoryID = repository.ID
+	clone.Removed = false
+	clone.Managed = false
+
+	if template.EnvironmentID != nil {
+		var environment db.Environment
+		if environment, err = c.store.GetEnvironment(c.projectID, *template.EnvironmentID); err != nil {
+			return
+		}
+
+		environment.ID = 0
+		environment.Name += " (copy)"
+		environment.Removed = false
+		environment.Managed = false
+
+		if environment, err = c.store.CreateEnvironment(environment); err != nil {
+			return
+		}
+		c.rollback = append(c.rollback, func() error { return c.store.DeleteEnvironment(c.projectID, environment.ID) })
+
+		clone.EnvironmentID = &environment.ID
+	}
+
+	if clone.VaultPassID, err = c.optionalKey(template.VaultPassID); err != nil {
+		return
+	}
+
+	return c.store.CreateTemplate(clone)
+}
+
+// CloneTemplate creates a copy of the template with its repository,
+// inventory and environment. Access keys are referenced unless duplication is requested.
+func CloneTemplate(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	template := context.Get(r, "template").(db.Template)
+	store := helpers.Store(r)
+
+	var options cloneOptions
+	if !helpers.Bind(w, r, &options) {
+		return
+	}
+
+	switch options.Keys {
+	case "":
+		options.Keys = cloneKeysReference
+	case cloneKeysReference, cloneKeysDuplicate:
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Keys must be reference or duplicate",
+		})
+		return
+	}
+
+	if options.Name == "" {
+		options.Name = template.Alias + " (copy)"
+	}
+
+	cloner := templateCloner{
+		store:         store,
+		projectID:     project.ID,
+		duplicateKeys: options.Keys == cloneKeysDuplicate,
+		keys:          make(map[int]int),
+	}
+
+	clone, err := cloner.clone(template, options.Name)
+	if err == nil {
+		err = cloneTemplateExtras(store, template, clone, options)
+	}
+
+	if err != nil {
+		if clone.ID != 0 {
+			if deleteErr := store.DeleteTemplate(project.ID, clone.ID); deleteErr != nil {
+				log.Error(deleteErr)
+			}
+		}
+		cloner.undo()
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if options.Schedules {
+		refreshSchedulePool(r)
+	}
+
+	recordRevision(r, project.ID, db.RevisionTemplate, clone.ID)
+
+	objType := "template"
+	desc := "Template " + clone.Alias + " cloned from " + template.Alias
+	_, err = store.CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &clone.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, clone)
+}
+
+// cloneTemplateExtras copies schedules and permissions of the template if requested.
+func cloneTemplateExtras(store db.Store, template db.Template, clone db.Template, options cloneOptions) error {
+	if options.Schedules {
+		schedules, err := store.GetTemplateSchedules(template.ProjectID, template.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, schedule := range schedules {
+			schedule.ID = 0
+			schedule.TemplateID = clone.ID
+			if _, err = store.CreateSchedule(schedule); err != nil {
+				return err
+			}
+		}
+	}
+
+	if options.Users {
+		permissions, err := store.GetTemplatePermissions(template.ProjectID, template.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range permissions {
+			permission.ID = 0
+			permission.TemplateID = clone.ID
+			if _, err = store.CreateTemplatePermission(permission); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
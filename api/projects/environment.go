@@ -61,11 +61,19 @@ func GetEnvironment(w http.ResponseWriter, r *http.Request) {
 // UpdateEnvironment updates an existing environment in the database
 func UpdateEnvironment(w http.ResponseWriter, r *http.Request) {
 	oldEnv := context.Get(r, "environment").(db.Environment)
+
+	if oldEnv.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	var env db.Environment
 	if !helpers.Bind(w, r, &env) {
 		return
 	}
 
+	env.Managed = false
+
 	if env.ID != oldEnv.ID {
 				helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
 					"error": "Environment ID in body and URL must be the same",
@@ -93,6 +101,8 @@ func UpdateEnvironment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordRevision(r, env.ProjectID, db.RevisionEnvironment, env.ID)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -119,12 +129,15 @@ func AddEnvironment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	env.Managed = false
 	newEnv, err := helpers.Store(r).CreateEnvironment(env)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	recordRevision(r, project.ID, db.RevisionEnvironment, newEnv.ID)
+
 	user := context.Get(r, "user").(*db.User)
 
 	objType := "environment"
@@ -149,6 +162,11 @@ func AddEnvironment(w http.ResponseWriter, r *http.Request) {
 func RemoveEnvironment(w http.ResponseWriter, r *http.Request) {
 	env := context.Get(r, "environment").(db.Environment)
 
+	if env.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	var err error
 
 	softDeletion := r.URL.Query().Get("setRemoved") == "1"
@@ -90,6 +90,7 @@ func AddInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	inventory.Managed = false
 	newInventory, err := helpers.Store(r).CreateInventory(inventory)
 
 	if err != nil {
@@ -97,6 +98,8 @@ func AddInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordRevision(r, project.ID, db.RevisionInventory, newInventory.ID)
+
 	user := context.Get(r, "user").(*db.User)
 
 	objType := "inventory"
@@ -142,12 +145,19 @@ func IsValidInventoryPath(path string) bool {
 func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	oldInventory := context.Get(r, "inventory").(db.Inventory)
 
+	if oldInventory.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	var inventory db.Inventory
 
 	if !helpers.Bind(w, r, &inventory) {
 		return
 	}
 
+	inventory.Managed = false
+
 	if inventory.ID != oldInventory.ID {
 		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
 			"error": "Inventory ID in body and URL must be the same",
@@ -182,6 +192,8 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordRevision(r, inventory.ProjectID, db.RevisionInventory, inventory.ID)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -190,6 +202,11 @@ func RemoveInventory(w http.ResponseWriter, r *http.Request) {
 	inventory := context.Get(r, "inventory").(db.Inventory)
 	var err error
 
+	if inventory.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	softDeletion := r.URL.Query().Get("setRemoved") == "1"
 
 	if softDeletion {
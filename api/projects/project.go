@@ -4,8 +4,11 @@ import (
 	"github.com/ansible-semaphore/semaphore/api/helpers"
 	"github.com/ansible-semaphore/semaphore/db"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/context"
+	"github.com/gorilla/mux"
 )
 
 // ProjectMiddleware ensures a project exists and loads it to the context
@@ -22,14 +25,39 @@ func ProjectMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// check if user it project's team
-		_, err = helpers.Store(r).GetProjectUser(projectID, user.ID)
+		// check if user is a member of the project directly or by teams
+		role, err := db.GetProjectMemberRole(helpers.Store(r), projectID, user.ID)
 
 		if err != nil {
 			helpers.WriteError(w, err)
 			return
 		}
 
+		teams, err := helpers.Store(r).GetUserTeams(user.ID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		teamIDs := make([]int, len(teams))
+		for i, team := range teams {
+			teamIDs[i] = team.ID
+		}
+
+		if token, ok := context.GetOk(r, "token"); ok {
+			allowed, err := tokenAllowsProjectRequest(r, token.(db.APIToken), projectID)
+			if err != nil {
+				helpers.WriteError(w, err)
+				return
+			}
+
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+
 		project, err := helpers.Store(r).GetProject(projectID)
 
 		if err != nil {
@@ -38,35 +66,118 @@ func ProjectMiddleware(next http.Handler) http.Handler {
 		}
 
 		context.Set(r, "project", project)
+		context.Set(r, "projectRole", role)
+		context.Set(r, "teamIDs", teamIDs)
 		next.ServeHTTP(w, r)
 	})
 }
 
-// MustBeAdmin ensures that the user has administrator rights
-func MustBeAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		project := context.Get(r, "project").(db.Project)
-		user := context.Get(r, "user").(*db.User)
+// isTaskRunRequest returns true for requests which create a task. Whether the template
+// is allowed to run is checked when the task is created.
+func isTaskRunRequest(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if r.Method != "POST" || route == nil {
+		return false
+	}
 
-		projectUser, err := helpers.Store(r).GetProjectUser(project.ID, user.ID)
+	path, err := route.GetPathTemplate()
+	return err == nil && strings.HasSuffix(path, "/project/{project_id}/tasks")
+}
 
-		if err == db.ErrNotFound {
-			w.WriteHeader(http.StatusForbidden)
-			return
+// isExportRequest returns true if the request exports the project, bundles may contain secrets of keys.
+func isExportRequest(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+
+	path, err := route.GetPathTemplate()
+	return err == nil && strings.HasSuffix(path, "/project/{project_id}/export")
+}
+
+// getRequestTemplateID returns the template the request reads: the template itself
+// or a task of it. It returns 0 for other requests.
+func getRequestTemplateID(r *http.Request, projectID int) (int, error) {
+	vars := mux.Vars(r)
+
+	if id, ok := vars["template_id"]; ok {
+		templateID, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, nil
 		}
+		return templateID, nil
+	}
 
+	if id, ok := vars["task_id"]; ok {
+		taskID, err := strconv.Atoi(id)
 		if err != nil {
-			helpers.WriteError(w, err)
-			return
+			return 0, nil
 		}
 
-		if !projectUser.Admin {
-			w.WriteHeader(http.StatusForbidden)
-			return
+		task, err := helpers.Store(r).GetTask(projectID, taskID)
+		if err == db.ErrNotFound {
+			return 0, nil
 		}
+		return task.TemplateID, err
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	return 0, nil
+}
+
+// tokenAllowsProjectRequest checks scopes of the API token: project run scopes allow
+// reading the project and creating tasks, template run scopes allow reading only
+// the templates and their tasks and creating tasks. Other reads require the read
+// scope, any other changes and exports require the admin scope.
+func tokenAllowsProjectRequest(r *http.Request, token db.APIToken, projectID int) (bool, error) {
+	if token.IsAdmin() {
+		return true, nil
+	}
+
+	// secrets are never reachable without the admin scope
+	if isExportRequest(r) {
+		return false, nil
+	}
+
+	safe := r.Method == "GET" || r.Method == "HEAD"
+
+	if !safe && !isTaskRunRequest(r) {
+		return false, nil
+	}
+
+	if safe && token.CanRead() {
+		return true, nil
+	}
+
+	if token.CanRunTemplate(projectID, 0) {
+		return true, nil
+	}
+
+	if safe {
+		templateID, err := getRequestTemplateID(r, projectID)
+		if err != nil || templateID == 0 {
+			return false, err
+		}
+		return token.CanRunTemplate(projectID, templateID), nil
+	}
+
+	// the template of the new task is checked by the handler
+	return len(token.GetRunTemplateIDs(projectID)) > 0, nil
+}
+
+// MustHavePermission ensures that the role of the user in the project allows the action
+func MustHavePermission(permission db.ProjectUserPermission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := context.Get(r, "projectRole").(db.ProjectUserRole)
+
+			if !role.Can(permission) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 //GetProject returns a project details
@@ -0,0 +1,278 @@
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+// errManagedObject is returned if the object to restore is managed by the project sync spec.
+var errManagedObject = errors.New("object is managed by the project sync spec")
+
+type revisionDiff struct {
+	From    int                 `json:"from"`
+	To      int                 `json:"to"`
+	Changes []db.RevisionChange `json:"changes"`
+}
+
+// recordRevision records the current state of the object as the revision made by the user.
+func recordRevision(r *http.Request, projectID int, objectType db.RevisionObjectType, objectID int) {
+	user := context.Get(r, "user").(*db.User)
+
+	if _, err := db.RecordRevision(helpers.Store(r), projectID, objectType, objectID, &user.ID); err != nil {
+		log.Error(err)
+	}
+}
+
+// getRevisionObject returns the type and the ID of the object loaded to the context by the middleware.
+func getRevisionObject(r *http.Request) (db.RevisionObjectType, int) {
+	if template, ok := context.GetOk(r, "template"); ok {
+		return db.RevisionTemplate, template.(db.Template).ID
+	}
+
+	if inventory, ok := context.GetOk(r, "inventory"); ok {
+		return db.RevisionInventory, inventory.(db.Inventory).ID
+	}
+
+	env := context.Get(r, "environment").(db.Environment)
+	return db.RevisionEnvironment, env.ID
+}
+
+// getObjectRevision returns the revision of the object, it returns ErrNotFound
+// if the revision belongs to another object.
+func getObjectRevision(r *http.Request, revisionID int) (revision db.Revision, err error) {
+	project := context.Get(r, "project").(db.Project)
+	objectType, objectID := getRevisionObject(r)
+
+	revision, err = helpers.Store(r).GetRevision(project.ID, revisionID)
+	if err != nil {
+		return
+	}
+
+	if revision.ObjectType != objectType || revision.ObjectID != objectID {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+// GetRevisions returns revisions of the template, the inventory or the environment from the newest to the oldest
+func GetRevisions(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	objectType, objectID := getRevisionObject(r)
+
+	revisions, err := helpers.Store(r).GetRevisions(project.ID, objectType, objectID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, revisions)
+}
+
+// GetRevisionDiff returns fields which differ between two revisions of the object.
+// The last revision is compared if the second revision is not specified.
+func GetRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	objectType, objectID := getRevisionObject(r)
+
+	fromID, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Revision to compare from must be specified",
+		})
+		return
+	}
+
+	from, err := getObjectRevision(r, fromID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	var to db.Revision
+
+	if r.URL.Query().Get("to") == "" {
+		to, err = helpers.Store(r).GetLastRevision(project.ID, objectType, objectID)
+	} else {
+		var toID int
+		if toID, err = strconv.Atoi(r.URL.Query().Get("to")); err != nil {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid revision to compare to",
+			})
+			return
+		}
+		to, err = getObjectRevision(r, toID)
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	changes, err := db.DiffRevisions(from, to)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, revisionDiff{From: from.ID, To: to.ID, Changes: changes})
+}
+
+// RestoreRevision makes the object match the revision, the restored state is recorded as a new revision
+func RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	store := helpers.Store(r)
+
+	revisionID, err := helpers.GetIntParam("revision_id", w, r)
+	if err != nil {
+		return
+	}
+
+	revision, err := getObjectRevision(r, revisionID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	switch revision.ObjectType {
+	case db.RevisionTemplate:
+		err = restoreTemplate(store, context.Get(r, "template").(db.Template), revision)
+	case db.RevisionInventory:
+		err = restoreInventory(store, context.Get(r, "inventory").(db.Inventory), revision)
+	case db.RevisionEnvironment:
+		err = restoreEnvironment(store, context.Get(r, "environment").(db.Environment), revision)
+	}
+
+	switch err {
+	case nil:
+	case errManagedObject:
+		writeManagedObjectError(w)
+		return
+	case db.ErrNotFound:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Objects referenced by the revision do not exist",
+		})
+		return
+	default:
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newRevision, err := db.RecordRevision(store, project.ID, revision.ObjectType, revision.ObjectID, &user.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	objType := string(revision.ObjectType)
+	desc := objType + " ID " + strconv.Itoa(revision.ObjectID) + " restored to revision " + strconv.Itoa(revision.Number)
+	_, err = store.CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &revision.ObjectID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, newRevision)
+}
+
+func checkRevisionKey(store db.Store, projectID int, keyID *int) error {
+	if keyID == nil {
+		return nil
+	}
+	_, err := store.GetAccessKey(projectID, *keyID)
+	return err
+}
+
+func restoreTemplate(store db.Store, current db.Template, revision db.Revision) (err error) {
+	if current.Managed {
+		return errManagedObject
+	}
+
+	var template db.Template
+	if err = json.Unmarshal([]byte(revision.Data), &template); err != nil {
+		return
+	}
+
+	template.ID = current.ID
+	template.ProjectID = current.ProjectID
+	template.Removed = current.Removed
+	template.Managed = false
+
+	if _, err = store.GetInventory(template.ProjectID, template.InventoryID); err != nil {
+		return
+	}
+
+	if _, err = store.GetRepository(template.ProjectID, template.RepositoryID); err != nil {
+		return
+	}
+
+	if template.EnvironmentID != nil {
+		if _, err = store.GetEnvironment(template.ProjectID, *template.EnvironmentID); err != nil {
+			return
+		}
+	}
+
+	if err = checkRevisionKey(store, template.ProjectID, template.VaultPassID); err != nil {
+		return
+	}
+
+	return store.UpdateTemplate(template)
+}
+
+func restoreInventory(store db.Store, current db.Inventory, revision db.Revision) (err error) {
+	if current.Managed {
+		return errManagedObject
+	}
+
+	var inventory db.Inventory
+	if err = json.Unmarshal([]byte(revision.Data), &inventory); err != nil {
+		return
+	}
+
+	inventory.ID = current.ID
+	inventory.ProjectID = current.ProjectID
+	inventory.Removed = current.Removed
+	inventory.Managed = false
+
+	if err = checkRevisionKey(store, inventory.ProjectID, inventory.SSHKeyID); err != nil {
+		return
+	}
+
+	if err = checkRevisionKey(store, inventory.ProjectID, inventory.BecomeKeyID); err != nil {
+		return
+	}
+
+	return store.UpdateInventory(inventory)
+}
+
+func restoreEnvironment(store db.Store, current db.Environment, revision db.Revision) (err error) {
+	if current.Managed {
+		return errManagedObject
+	}
+
+	var env db.Environment
+	if err = json.Unmarshal([]byte(revision.Data), &env); err != nil {
+		return
+	}
+
+	env.ID = current.ID
+	env.ProjectID = current.ProjectID
+	env.Removed = current.Removed
+	env.Managed = false
+
+	return store.UpdateEnvironment(env)
+}
@@ -26,6 +26,28 @@ func SchedulesMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		access, err := helpers.GetTemplateAccess(r, project.ID, schedule.TemplateID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		if access == db.TemplateNoAccess {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// schedules run tasks of the template
+		if r.Method != "GET" && r.Method != "HEAD" && !access.Allows(db.TemplateRun) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.Method != "GET" && r.Method != "HEAD" && !checkTemplateNotManaged(w, r, project.ID, schedule.TemplateID) {
+			return
+		}
+
 		context.Set(r, "schedule", schedule)
 		next.ServeHTTP(w, r)
 	})
@@ -94,8 +116,23 @@ func AddSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, err := helpers.GetTemplateAccess(r, project.ID, schedule.TemplateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !access.Allows(db.TemplateRun) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if !checkTemplateNotManaged(w, r, project.ID, schedule.TemplateID) {
+		return
+	}
+
 	schedule.ProjectID = project.ID
-	schedule, err := helpers.Store(r).CreateSchedule(schedule)
+	schedule, err = helpers.Store(r).CreateSchedule(schedule)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
@@ -149,6 +186,10 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkTemplateNotManaged(w, r, schedule.ProjectID, schedule.TemplateID) {
+		return
+	}
+
 	err := helpers.Store(r).UpdateSchedule(schedule)
 	if err != nil {
 		helpers.WriteError(w, err)
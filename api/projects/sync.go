@@ -0,0 +1,183 @@
+package projects
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/configsync"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+// writeManagedObjectError rejects changes of objects managed by the project sync spec
+func writeManagedObjectError(w http.ResponseWriter) {
+	helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+		"error": "Object is managed by the project sync spec and can be changed only in the repository",
+	})
+}
+
+// checkTemplateNotManaged writes the error and returns false if the template is managed by the project sync spec
+func checkTemplateNotManaged(w http.ResponseWriter, r *http.Request, projectID int, templateID int) bool {
+	template, err := helpers.Store(r).GetTemplate(projectID, templateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	if template.Managed {
+		writeManagedObjectError(w)
+		return false
+	}
+
+	return true
+}
+
+// GetProjectSync returns the sync settings and the status of the last sync
+func GetProjectSync(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	sync, err := helpers.Store(r).GetProjectSync(project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, sync)
+}
+
+// SetProjectSync makes the project follow the spec from the repository
+func SetProjectSync(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	var body db.ProjectSync
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	path, err := configsync.CleanSpecPath(body.Path)
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if _, err = helpers.Store(r).GetRepository(project.ID, body.RepositoryID); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Repository not found",
+		})
+		return
+	}
+
+	// the status of the last sync is kept
+	sync, err := helpers.Store(r).GetProjectSync(project.ID)
+	if err != nil && err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	sync.ProjectID = project.ID
+	sync.RepositoryID = body.RepositoryID
+	sync.Path = path
+	sync.AutoApply = body.AutoApply
+
+	if err = helpers.Store(r).SetProjectSync(sync); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	desc := "Project sync with " + path + " configured"
+	objType := "Project"
+	_, err = helpers.Store(r).CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &project.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, sync)
+}
+
+// RemoveProjectSync stops the sync, objects managed by the spec become editable
+func RemoveProjectSync(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	if err := helpers.Store(r).DeleteProjectSync(project.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err := configsync.Release(helpers.Store(r), project.ID, &user.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectSyncPlan returns changes which would be made by applying the spec
+func GetProjectSyncPlan(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	res, err := configsync.Plan(helpers.Store(r), project.ID)
+
+	if err == db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+// ApplyProjectSync makes the project match the spec. If the commit of the
+// reviewed plan is passed, the spec is applied only if it has not changed.
+func ApplyProjectSync(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	var body struct {
+		Commit string `json:"commit"`
+	}
+
+	if r.ContentLength > 0 && !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	res, err := configsync.Apply(helpers.Store(r), project.ID, body.Commit, &user.ID)
+
+	if len(res.Changes) > 0 {
+		refreshSchedulePool(r)
+	}
+
+	switch {
+	case err == db.ErrNotFound:
+		helpers.WriteError(w, err)
+	case err == configsync.ErrCommitChanged:
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error":  err.Error(),
+			"commit": res.Commit,
+		})
+	case err != nil:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   err.Error(),
+			"changes": res.Changes,
+		})
+	default:
+		helpers.WriteJSON(w, http.StatusOK, res)
+	}
+}
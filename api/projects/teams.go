@@ -0,0 +1,185 @@
+package projects
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+// TeamMiddleware ensures a team is attached to the project and loads it to the context
+func TeamMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		project := context.Get(r, "project").(db.Project)
+		teamID, err := helpers.GetIntParam("team_id", w, r)
+		if err != nil {
+			return
+		}
+
+		projectTeam, err := helpers.Store(r).GetProjectTeam(project.ID, teamID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		team, err := helpers.Store(r).GetTeam(teamID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "projectTeam", db.TeamWithProjectRole{Role: projectTeam.Role, Team: team})
+		next.ServeHTTP(w, r)
+	})
+}
+
+func createTeamEvent(r *http.Request, teamID int, desc string) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	objType := "team"
+
+	_, err := helpers.Store(r).CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &teamID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// GetTeams returns teams attached to the project
+func GetTeams(w http.ResponseWriter, r *http.Request) {
+	if team := context.Get(r, "projectTeam"); team != nil {
+		helpers.WriteJSON(w, http.StatusOK, team.(db.TeamWithProjectRole))
+		return
+	}
+
+	project := context.Get(r, "project").(db.Project)
+
+	teams, err := helpers.Store(r).GetProjectTeams(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if teams == nil {
+		teams = []db.TeamWithProjectRole{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, teams)
+}
+
+// AddTeam attaches a team to the project, all members of the team get the role in the project
+func AddTeam(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	var projectTeam db.ProjectTeam
+	if !helpers.Bind(w, r, &projectTeam) {
+		return
+	}
+
+	if projectTeam.Role == "" {
+		projectTeam.Role = db.ProjectManager
+	}
+
+	if !projectTeam.Role.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	if _, err := helpers.Store(r).GetTeam(projectTeam.TeamID); err != nil {
+		if err == db.ErrNotFound {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Team does not exist",
+			})
+			return
+		}
+		helpers.WriteError(w, err)
+		return
+	}
+
+	_, err := helpers.Store(r).GetProjectTeam(project.ID, projectTeam.TeamID)
+
+	if err == nil {
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error": "Team is already attached to the project",
+		})
+		return
+	}
+
+	if err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	projectTeam.ProjectID = project.ID
+
+	if _, err = helpers.Store(r).CreateProjectTeam(projectTeam); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createTeamEvent(r, projectTeam.TeamID, "Team ID "+strconv.Itoa(projectTeam.TeamID)+
+		" attached to the project as "+string(projectTeam.Role))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateTeam changes the role of the team in the project
+func UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	team := context.Get(r, "projectTeam").(db.TeamWithProjectRole)
+
+	var body struct {
+		Role db.ProjectUserRole `json:"role"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if !body.Role.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	err := helpers.Store(r).UpdateProjectTeam(db.ProjectTeam{ProjectID: project.ID, TeamID: team.ID, Role: body.Role})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createTeamEvent(r, team.ID, "Team ID "+strconv.Itoa(team.ID)+" role changed to "+string(body.Role))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveTeam detaches the team from the project
+func RemoveTeam(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	team := context.Get(r, "projectTeam").(db.TeamWithProjectRole)
+
+	if err := helpers.Store(r).DeleteProjectTeam(project.ID, team.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createTeamEvent(r, team.ID, "Team ID "+strconv.Itoa(team.ID)+" detached from the project")
+
+	w.WriteHeader(http.StatusNoContent)
+}
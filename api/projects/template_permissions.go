@@ -0,0 +1,168 @@
+package projects
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+func createTemplatePermissionEvent(r *http.Request, template db.Template, desc string) {
+	user := context.Get(r, "user").(*db.User)
+	objType := "template"
+
+	_, err := helpers.Store(r).CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &template.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &template.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// getPermissionGrantee returns the description of the user or the team the permission is granted to
+func getPermissionGrantee(permission db.TemplatePermission) string {
+	if permission.TeamID != nil {
+		return "Team ID " + strconv.Itoa(*permission.TeamID)
+	}
+	return "User ID " + strconv.Itoa(*permission.UserID)
+}
+
+// GetTemplatePermissions returns members who are granted access to the template
+func GetTemplatePermissions(w http.ResponseWriter, r *http.Request) {
+	template := context.Get(r, "template").(db.Template)
+
+	permissions, err := helpers.Store(r).GetTemplatePermissions(template.ProjectID, template.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, permissions)
+}
+
+// AddTemplatePermission grants access to the template to a member of the project or to a team.
+// The previous grant of the member or the team is replaced.
+func AddTemplatePermission(w http.ResponseWriter, r *http.Request) {
+	template := context.Get(r, "template").(db.Template)
+
+	var permission db.TemplatePermission
+	if !helpers.Bind(w, r, &permission) {
+		return
+	}
+
+	if !permission.Access.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid access, it must be view, run or edit",
+		})
+		return
+	}
+
+	var err error
+	var notFoundMessage string
+
+	switch {
+	case (permission.UserID == nil) == (permission.TeamID == nil):
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Either user_id or team_id must be specified",
+		})
+		return
+	case permission.UserID != nil:
+		_, err = db.GetProjectMemberRole(helpers.Store(r), template.ProjectID, *permission.UserID)
+		notFoundMessage = "User is not a member of the project"
+	default:
+		_, err = helpers.Store(r).GetTeam(*permission.TeamID)
+		notFoundMessage = "Team does not exist"
+	}
+
+	if err == db.ErrNotFound {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": notFoundMessage,
+		})
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	permissions, err := helpers.Store(r).GetTemplatePermissions(template.ProjectID, template.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	for _, p := range permissions {
+		if getPermissionGrantee(p) != getPermissionGrantee(permission) {
+			continue
+		}
+		if err = helpers.Store(r).DeleteTemplatePermission(template.ProjectID, p.ID); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
+	permission.ProjectID = template.ProjectID
+	permission.TemplateID = template.ID
+
+	permission, err = helpers.Store(r).CreateTemplatePermission(permission)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createTemplatePermissionEvent(r, template, getPermissionGrantee(permission)+
+		" granted "+string(permission.Access)+" access to template ID "+strconv.Itoa(template.ID))
+
+	helpers.WriteJSON(w, http.StatusCreated, permission)
+}
+
+// RemoveTemplatePermission revokes access to the template
+func RemoveTemplatePermission(w http.ResponseWriter, r *http.Request) {
+	template := context.Get(r, "template").(db.Template)
+
+	permissionID, err := helpers.GetIntParam("permission_id", w, r)
+	if err != nil {
+		return
+	}
+
+	permissions, err := helpers.Store(r).GetTemplatePermissions(template.ProjectID, template.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	var permission *db.TemplatePermission
+	for i := range permissions {
+		if permissions[i].ID == permissionID {
+			permission = &permissions[i]
+		}
+	}
+
+	if permission == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err = helpers.Store(r).DeleteTemplatePermission(template.ProjectID, permissionID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createTemplatePermissionEvent(r, template, getPermissionGrantee(*permission)+
+		" access to template ID "+strconv.Itoa(template.ID)+" revoked")
+
+	w.WriteHeader(http.StatusNoContent)
+}
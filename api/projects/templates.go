@@ -25,11 +25,61 @@ func TemplatesMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		access, err := helpers.GetTemplateAccess(r, project.ID, templateID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		// templates which are not granted to the user are hidden
+		if access == db.TemplateNoAccess {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		required := db.TemplateView
+		if r.Method != "GET" && r.Method != "HEAD" {
+			required = db.TemplateEdit
+		}
+
+		if !access.Allows(required) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		context.Set(r, "template", template)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// checkTemplateValidation writes the error and returns false if the validation
+// or the approval settings of the template are invalid
+func checkTemplateValidation(w http.ResponseWriter, template db.Template) bool {
+	if template.LintThreshold != "" && !template.LintThreshold.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Lint threshold must be info, minor, major, critical or blocker",
+		})
+		return false
+	}
+
+	if template.RequiredApprovals < 0 || template.ApprovalExpiry < 0 {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Required approvals and approval expiry can not be negative",
+		})
+		return false
+	}
+
+	if template.RequiredApprovals > 0 && !template.ApproverRole.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Approver role must be owner, manager, task_runner or guest",
+		})
+		return false
+	}
+
+	return true
+}
+
 // GetTemplate returns single template by ID
 func GetTemplate(w http.ResponseWriter, r *http.Request) {
 	template := context.Get(r, "template").(db.Template)
@@ -52,7 +102,21 @@ func GetTemplates(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, templates)
+	getAccess, err := helpers.GetTemplateAccessResolver(r, project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]db.Template, 0, len(templates))
+	for _, template := range templates {
+		if getAccess(template.ID) != db.TemplateNoAccess {
+			res = append(res, template)
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
 }
 
 // AddTemplate adds a template to the database
@@ -65,6 +129,11 @@ func AddTemplate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	template.ProjectID = project.ID
+	template.Managed = false
+
+	if !checkTemplateValidation(w, template) {
+		return
+	}
 	template, err := helpers.Store(r).CreateTemplate(template)
 
 	if err != nil {
@@ -72,6 +141,8 @@ func AddTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordRevision(r, project.ID, db.RevisionTemplate, template.ID)
+
 	user := context.Get(r, "user").(*db.User)
 	objType := "template"
 	desc := "Template ID " + strconv.Itoa(template.ID) + " created"
@@ -95,11 +166,18 @@ func AddTemplate(w http.ResponseWriter, r *http.Request) {
 func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	oldTemplate := context.Get(r, "template").(db.Template)
 
+	if oldTemplate.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	var template db.Template
 	if !helpers.Bind(w, r, &template) {
 		return
 	}
 
+	template.Managed = false
+
 	// project ID and template ID in the body and the path must be the same
 
 	if template.ID != oldTemplate.ID {
@@ -120,12 +198,18 @@ func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		template.Arguments = nil
 	}
 
+	if !checkTemplateValidation(w, template) {
+		return
+	}
+
 	err := helpers.Store(r).UpdateTemplate(template)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	recordRevision(r, template.ProjectID, db.RevisionTemplate, template.ID)
+
 	user := context.Get(r, "user").(*db.User)
 
 	desc := "Template ID " + strconv.Itoa(template.ID) + " updated"
@@ -150,6 +234,11 @@ func UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 func RemoveTemplate(w http.ResponseWriter, r *http.Request) {
 	tpl := context.Get(r, "template").(db.Template)
 
+	if tpl.Managed {
+		writeManagedObjectError(w)
+		return
+	}
+
 	err := helpers.Store(r).DeleteTemplate(tpl.ProjectID, tpl.ID)
 	if err != nil {
 		helpers.WriteError(w, err)
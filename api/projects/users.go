@@ -19,7 +19,7 @@ func UserMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		_, err = helpers.Store(r).GetProjectUser(project.ID, userID)
+		projectUser, err := helpers.Store(r).GetProjectUser(project.ID, userID)
 
 		if err != nil {
 			helpers.WriteError(w, err)
@@ -33,7 +33,7 @@ func UserMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		context.Set(r, "projectUser", user)
+		context.Set(r, "projectUser", db.UserWithProjectRole{Role: projectUser.Role, User: user})
 		next.ServeHTTP(w, r)
 	})
 }
@@ -43,7 +43,7 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	// get single user if user ID specified in the request
 	if user := context.Get(r, "projectUser"); user != nil {
-		helpers.WriteJSON(w, http.StatusOK, user.(db.User))
+		helpers.WriteJSON(w, http.StatusOK, user.(db.UserWithProjectRole))
 		return
 	}
 
@@ -67,15 +67,31 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 func AddUser(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
 	var projectUser struct {
-		UserID int  `json:"user_id" binding:"required"`
-		Admin  bool `json:"admin"`
+		UserID int                `json:"user_id" binding:"required"`
+		Role   db.ProjectUserRole `json:"role"`
+		// Admin is kept for compatibility, it means the owner role
+		Admin bool `json:"admin"`
 	}
 
 	if !helpers.Bind(w, r, &projectUser) {
 		return
 	}
 
-	_, err := helpers.Store(r).CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: projectUser.UserID, Admin: projectUser.Admin})
+	if projectUser.Role == "" {
+		projectUser.Role = db.ProjectManager
+		if projectUser.Admin {
+			projectUser.Role = db.ProjectOwner
+		}
+	}
+
+	if !projectUser.Role.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	_, err := helpers.Store(r).CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: projectUser.UserID, Role: projectUser.Role})
 
 	if err != nil {
 		w.WriteHeader(http.StatusConflict)
@@ -84,7 +100,7 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 
 	user := context.Get(r, "user").(*db.User)
 	objType := "user"
-	desc := "User ID " + strconv.Itoa(projectUser.UserID) + " added to team"
+	desc := "User ID " + strconv.Itoa(projectUser.UserID) + " added to team as " + string(projectUser.Role)
 
 	_, err = helpers.Store(r).CreateEvent(db.Event{
 		UserID:		 &user.ID,
@@ -104,7 +120,7 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 // RemoveUser removes a user from a project team
 func RemoveUser(w http.ResponseWriter, r *http.Request) {
 	project := context.Get(r, "project").(db.Project)
-	projectUser := context.Get(r, "projectUser").(db.User)
+	projectUser := context.Get(r, "projectUser").(db.UserWithProjectRole)
 
 	err := helpers.Store(r).DeleteProjectUser(project.ID, projectUser.ID)
 
@@ -132,23 +148,66 @@ func RemoveUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// MakeUserAdmin writes the admin flag to the users account
-func MakeUserAdmin(w http.ResponseWriter, r *http.Request) {
+// updateUserRole changes the role of the project member
+func updateUserRole(w http.ResponseWriter, r *http.Request, role db.ProjectUserRole) {
 	project := context.Get(r, "project").(db.Project)
-	user := context.Get(r, "projectUser").(db.User)
-	admin := true
-
-	if r.Method == "DELETE" {
-		// strip admin
-		admin = false
-	}
+	projectUser := context.Get(r, "projectUser").(db.UserWithProjectRole)
 
-	err := helpers.Store(r).UpdateProjectUser(db.ProjectUser{UserID: user.ID, ProjectID: project.ID, Admin: admin})
+	err := helpers.Store(r).UpdateProjectUser(db.ProjectUser{UserID: projectUser.ID, ProjectID: project.ID, Role: role})
 
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
+	user := context.Get(r, "user").(*db.User)
+	objType := "user"
+	desc := "User ID " + strconv.Itoa(projectUser.ID) + " role changed to " + string(role)
+
+	_, err = helpers.Store(r).CreateEvent(db.Event{
+		UserID:      &user.ID,
+		ProjectID:   &project.ID,
+		ObjectType:  &objType,
+		ObjectID:    &projectUser.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// UpdateUser changes the role of the project member
+func UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Role db.ProjectUserRole `json:"role" binding:"required"`
+	}
+
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if !body.Role.IsValid() {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid role",
+		})
+		return
+	}
+
+	updateUserRole(w, r, body.Role)
+}
+
+// MakeUserAdmin is kept for compatibility: it makes the member an owner
+// or a manager if the admin flag is removed
+func MakeUserAdmin(w http.ResponseWriter, r *http.Request) {
+	role := db.ProjectOwner
+
+	if r.Method == "DELETE" {
+		// strip admin
+		role = db.ProjectManager
+	}
+
+	updateUserRole(w, r, role)
+}
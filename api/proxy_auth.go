@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+// authenticateProxyUser returns the ID of the user passed by the trusted reverse proxy.
+// The user is created as an external one if it doesn't exist. ok is false
+// if the request is not authenticated by the proxy.
+func authenticateProxyUser(r *http.Request) (userID int, ok bool, err error) {
+	if !util.Config.ProxyAuthEnable {
+		return
+	}
+
+	conf := util.Config.ProxyAuth
+
+	username := strings.ToLower(strings.TrimSpace(r.Header.Get(conf.GetUserHeader())))
+	if username == "" {
+		return
+	}
+
+	if !conf.IsTrustedProxy(getPeerIP(r)) {
+		log.Warn("Proxy header of user " + username + " is ignored, request from untrusted address " + getPeerIP(r))
+		return
+	}
+
+	ok = true
+
+	email := strings.TrimSpace(r.Header.Get(conf.GetEmailHeader()))
+	if email == "" {
+		email = username
+	}
+
+	user, err := helpers.Store(r).GetUserByLoginOrEmail(username, email)
+
+	if err == db.ErrNotFound {
+		name := username
+		if conf.NameHeader != "" && r.Header.Get(conf.NameHeader) != "" {
+			name = r.Header.Get(conf.NameHeader)
+		}
+
+		user, err = helpers.Store(r).CreateUserWithoutPassword(db.User{
+			Username: username,
+			Name:     name,
+			Email:    email,
+			External: true,
+		})
+	}
+
+	if err != nil {
+		return
+	}
+
+	if !user.External {
+		err = errors.New("local user " + user.Username + " can not be authenticated by the proxy")
+		return
+	}
+
+	userID = user.ID
+	return
+}
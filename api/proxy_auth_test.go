@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/handlers"
+)
+
+func TestProxyAuthentication(t *testing.T) {
+	util.Config = &util.ConfigType{
+		ProxyAuthEnable: true,
+		ProxyAuth:       util.ProxyAuth{TrustedProxies: []string{"10.0.0.0/8"}},
+	}
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	_, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := CapturePeerAddress(handlers.ProxyHeaders(router))
+
+	getUser := func(remoteAddr string, headers map[string]string) int {
+		req, _ := http.NewRequest("GET", "/api/user", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	alice := map[string]string{"X-Forwarded-User": "Alice", "X-Forwarded-Email": "alice@example.com"}
+
+	if code := getUser("192.0.2.1:1234", alice); code != http.StatusUnauthorized {
+		t.Fatalf("proxy headers from untrusted address must be ignored: %d", code)
+	}
+
+	spoofed := map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-For": "10.0.0.1"}
+	if code := getUser("192.0.2.1:1234", spoofed); code != http.StatusUnauthorized {
+		t.Fatalf("forwarded address must not make the request trusted: %d", code)
+	}
+
+	if code := getUser("10.0.0.1:1234", alice); code != http.StatusOK {
+		t.Fatalf("user passed by the trusted proxy must be authenticated: %d", code)
+	}
+
+	user, err := store.GetUserByLoginOrEmail("alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.External || user.Email != "alice@example.com" {
+		t.Fatal("user passed by the proxy must be created as external one")
+	}
+
+	if code := getUser("10.0.0.1:1234", alice); code != http.StatusOK {
+		t.Fatalf("existing external user must be authenticated: %d", code)
+	}
+
+	if code := getUser("10.0.0.1:1234", map[string]string{"X-Forwarded-User": "admin"}); code != http.StatusUnauthorized {
+		t.Fatalf("local user must not be authenticated by the proxy: %d", code)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestTemplateRevisions(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, template := createCloneTestProject(t, store)
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+	manager := clients[db.ProjectManager]
+
+	templateURL := "/api/project/" + strconv.Itoa(project.ID) + "/templates/" + strconv.Itoa(template.ID)
+
+	for _, playbook := range []string{"site.yml", "deploy.yml"} {
+		template.Playbook = playbook
+		if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusNoContent {
+			t.Fatalf("Response code should be 204 %d", rr.Code)
+		}
+	}
+
+	rr := clients[db.ProjectGuest].do("GET", templateURL+"/revisions", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var revisions []db.Revision
+	_ = json.Unmarshal(rr.Body.Bytes(), &revisions)
+
+	if len(revisions) != 2 || revisions[0].Number != 2 || revisions[1].Number != 1 {
+		t.Fatalf("revisions must be listed from the newest: %+v", revisions)
+	}
+
+	if revisions[0].UserID == nil || *revisions[0].UserID != userIDs[db.ProjectManager] {
+		t.Fatal("author of the revision must be recorded")
+	}
+
+	rr = manager.do("GET", templateURL+"/revisions/diff?from="+strconv.Itoa(revisions[1].ID), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var diff struct {
+		Changes []db.RevisionChange `json:"changes"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &diff)
+
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "playbook" || diff.Changes[0].Old != "site.yml" {
+		t.Fatalf("unexpected diff: %+v", diff.Changes)
+	}
+
+	restoreURL := templateURL + "/revisions/" + strconv.Itoa(revisions[1].ID) + "/restore"
+
+	if rr = clients[db.ProjectGuest].do("POST", restoreURL, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("guest must not restore revisions: %d", rr.Code)
+	}
+
+	if rr = manager.do("POST", restoreURL, nil); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var restored db.Revision
+	_ = json.Unmarshal(rr.Body.Bytes(), &restored)
+
+	if restored.Number != 3 {
+		t.Fatal("restore must be recorded as a new revision")
+	}
+
+	template, _ = store.GetTemplate(project.ID, template.ID)
+	if template.Playbook != "site.yml" {
+		t.Fatal("template must be restored")
+	}
+
+	other, err := store.GetLastRevision(project.ID, db.RevisionTemplate, template.ID)
+	if err != nil || other.ID != restored.ID {
+		t.Fatal("last revision must be the restored one")
+	}
+
+	inventoryURL := "/api/project/" + strconv.Itoa(project.ID) + "/inventory/" + strconv.Itoa(template.InventoryID)
+	if rr = manager.do("GET", inventoryURL+"/revisions/diff?from="+strconv.Itoa(revisions[1].ID), nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("revisions of other objects must not be found: %d", rr.Code)
+	}
+}
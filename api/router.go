@@ -10,6 +10,7 @@ import (
 	"github.com/ansible-semaphore/semaphore/api/projects"
 	"github.com/ansible-semaphore/semaphore/api/sockets"
 	"github.com/ansible-semaphore/semaphore/api/tasks"
+	"github.com/ansible-semaphore/semaphore/db"
 
 	"github.com/ansible-semaphore/semaphore/util"
 	"github.com/gobuffalo/packr"
@@ -62,16 +63,44 @@ func Route() *mux.Router {
 	}
 
 	r.Use(mux.CORSMethodMiddleware(r))
+	r.Use(metricsMiddleware)
 
 	pingRouter := r.Path(webPath + "api/ping").Subrouter()
 	pingRouter.Use(plainTextMiddleware)
 	pingRouter.Methods("GET", "HEAD").HandlerFunc(pongHandler)
 
+	// metrics without a token are served only on the separate metrics address
+	if util.Config != nil && util.Config.MetricsEnable && util.Config.MetricsListen == "" && util.Config.MetricsToken != "" {
+		r.Path(webPath + "api/metrics").Methods("GET", "HEAD").Handler(MetricsHandler())
+	}
+
 	publicAPIRouter := r.PathPrefix(webPath + "api").Subrouter()
 	publicAPIRouter.Use(JSONMiddleware)
 
 	publicAPIRouter.HandleFunc("/auth/login", login).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/login", getLoginOptions).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/login", oidcLogin).Methods("GET")
+	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/redirect", oidcRedirect).Methods("GET")
 	publicAPIRouter.HandleFunc("/auth/logout", logout).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/verify", verifySession).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/password_reset", requestPasswordReset).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/password_reset/confirm", resetPassword).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/email_verification/confirm", verifyEmail).Methods("POST")
+	publicAPIRouter.HandleFunc("/auth/invitation", getInvitation).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/auth/invitation/accept", acceptInvitation).Methods("POST")
+
+	publicAPIRouter.HandleFunc("/health/live", getLiveness).Methods("GET", "HEAD")
+	publicAPIRouter.HandleFunc("/health/ready", getReadiness).Methods("GET", "HEAD")
+
+	// available to users which must enrol TOTP to finish the login
+	userTotpAPI := r.PathPrefix(webPath + "api").Subrouter()
+	userTotpAPI.Use(JSONMiddleware, totpEnrolmentAuthentication)
+
+	userTotpAPI.Path("/user").HandlerFunc(getUser).Methods("GET", "HEAD")
+	userTotpAPI.Path("/user/totp").HandlerFunc(getUserTotp).Methods("GET", "HEAD")
+	userTotpAPI.Path("/user/totp").HandlerFunc(startUserTotp).Methods("POST")
+	userTotpAPI.Path("/user/totp").HandlerFunc(deleteUserTotp).Methods("DELETE")
+	userTotpAPI.Path("/user/totp/confirm").HandlerFunc(confirmUserTotp).Methods("POST")
 
 	authenticatedAPI := r.PathPrefix(webPath + "api").Subrouter()
 	authenticatedAPI.Use(JSONMiddleware, authentication)
@@ -81,19 +110,49 @@ func Route() *mux.Router {
 
 	authenticatedAPI.Path("/projects").HandlerFunc(projects.GetProjects).Methods("GET", "HEAD")
 	authenticatedAPI.Path("/projects").HandlerFunc(projects.AddProject).Methods("POST")
+	authenticatedAPI.Path("/projects/import").HandlerFunc(projects.ImportProject).Methods("POST")
 	authenticatedAPI.Path("/events").HandlerFunc(getAllEvents).Methods("GET", "HEAD")
 	authenticatedAPI.HandleFunc("/events/last", getLastEvents).Methods("GET", "HEAD")
 
 	authenticatedAPI.Path("/users").HandlerFunc(getUsers).Methods("GET", "HEAD")
 	authenticatedAPI.Path("/users").HandlerFunc(addUser).Methods("POST")
 
-	authenticatedAPI.Path("/user").HandlerFunc(getUser).Methods("GET", "HEAD")
+	authenticatedAPI.Path("/teams").HandlerFunc(getTeams).Methods("GET", "HEAD")
+
+	teamAdminAPI := authenticatedAPI.PathPrefix("/teams").Subrouter()
+	teamAdminAPI.Use(adminMiddleware)
+	teamAdminAPI.Path("").HandlerFunc(addTeam).Methods("POST")
+
+	teamAPI := authenticatedAPI.PathPrefix("/teams/{team_id}").Subrouter()
+	teamAPI.Use(getTeamMiddleware)
+	teamAPI.Path("").HandlerFunc(getTeams).Methods("GET", "HEAD")
+	teamAPI.Path("/users").HandlerFunc(getTeamUsers).Methods("GET", "HEAD")
+
+	teamManagementAPI := authenticatedAPI.PathPrefix("/teams/{team_id}").Subrouter()
+	teamManagementAPI.Use(adminMiddleware, getTeamMiddleware)
+	teamManagementAPI.Path("").HandlerFunc(updateTeam).Methods("PUT")
+	teamManagementAPI.Path("").HandlerFunc(deleteTeam).Methods("DELETE")
+	teamManagementAPI.Path("/users").HandlerFunc(addTeamUser).Methods("POST")
+	teamManagementAPI.HandleFunc("/users/{user_id}", deleteTeamUser).Methods("DELETE")
 
 	tokenAPI := authenticatedAPI.PathPrefix("/user").Subrouter()
 	tokenAPI.Path("/tokens").HandlerFunc(getAPITokens).Methods("GET", "HEAD")
 	tokenAPI.Path("/tokens").HandlerFunc(createAPIToken).Methods("POST")
 	tokenAPI.HandleFunc("/tokens/{token_id}", expireAPIToken).Methods("DELETE")
 
+	tokenAPI.Path("/sessions").HandlerFunc(getSessions).Methods("GET", "HEAD")
+	tokenAPI.Path("/sessions").HandlerFunc(expireOtherSessions).Methods("DELETE")
+	tokenAPI.HandleFunc("/sessions/{session_id}", expireSession).Methods("DELETE")
+
+	tokenAPI.Path("/subscriptions").HandlerFunc(getSubscriptions).Methods("GET", "HEAD")
+	tokenAPI.Path("/subscriptions").HandlerFunc(addSubscription).Methods("POST")
+
+	userSubscriptionAPI := tokenAPI.PathPrefix("/subscriptions").Subrouter()
+	userSubscriptionAPI.Use(getSubscriptionMiddleware)
+	userSubscriptionAPI.HandleFunc("/{subscription_id}", getSubscriptions).Methods("GET", "HEAD")
+	userSubscriptionAPI.HandleFunc("/{subscription_id}", updateSubscription).Methods("PUT")
+	userSubscriptionAPI.HandleFunc("/{subscription_id}", deleteSubscription).Methods("DELETE")
+
 	userAPI := authenticatedAPI.Path("/users/{user_id}").Subrouter()
 	userAPI.Use(getUserMiddleware)
 
@@ -104,11 +163,22 @@ func Route() *mux.Router {
 	userPasswordAPI := authenticatedAPI.PathPrefix("/users/{user_id}").Subrouter()
 	userPasswordAPI.Use(getUserMiddleware)
 	userPasswordAPI.Path("/password").HandlerFunc(updateUserPassword).Methods("POST")
+	userPasswordAPI.Path("/email_verification").HandlerFunc(resendEmailVerification).Methods("POST")
+	userPasswordAPI.Path("/sessions").HandlerFunc(getSessions).Methods("GET", "HEAD")
+	userPasswordAPI.Path("/sessions").HandlerFunc(expireOtherSessions).Methods("DELETE")
+	userPasswordAPI.HandleFunc("/sessions/{session_id}", expireSession).Methods("DELETE")
+
+	serviceAccountAPI := authenticatedAPI.PathPrefix("/users/{user_id}").Subrouter()
+	serviceAccountAPI.Use(getUserMiddleware, serviceAccountMiddleware)
+	serviceAccountAPI.Path("/tokens").HandlerFunc(getAPITokens).Methods("GET", "HEAD")
+	serviceAccountAPI.Path("/tokens").HandlerFunc(createAPIToken).Methods("POST")
+	serviceAccountAPI.HandleFunc("/tokens/{token_id}", expireAPIToken).Methods("DELETE")
 
 	projectGet := authenticatedAPI.Path("/project/{project_id}").Subrouter()
 	projectGet.Use(projects.ProjectMiddleware)
 	projectGet.Methods("GET", "HEAD").HandlerFunc(projects.GetProject)
 
+	// read-only routes available to every member of the project
 	projectUserAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
 	projectUserAPI.Use(projects.ProjectMiddleware)
 
@@ -116,96 +186,169 @@ func Route() *mux.Router {
 	projectUserAPI.HandleFunc("/events/last", getLastEvents).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/users").HandlerFunc(projects.GetUsers).Methods("GET", "HEAD")
-
+	projectUserAPI.Path("/teams").HandlerFunc(projects.GetTeams).Methods("GET", "HEAD")
 	projectUserAPI.Path("/keys").HandlerFunc(projects.GetKeys).Methods("GET", "HEAD")
-	projectUserAPI.Path("/keys").HandlerFunc(projects.AddKey).Methods("POST")
-
 	projectUserAPI.Path("/repositories").HandlerFunc(projects.GetRepositories).Methods("GET", "HEAD")
-	projectUserAPI.Path("/repositories").HandlerFunc(projects.AddRepository).Methods("POST")
-
 	projectUserAPI.Path("/inventory").HandlerFunc(projects.GetInventory).Methods("GET", "HEAD")
-	projectUserAPI.Path("/inventory").HandlerFunc(projects.AddInventory).Methods("POST")
-
 	projectUserAPI.Path("/environment").HandlerFunc(projects.GetEnvironment).Methods("GET", "HEAD")
-	projectUserAPI.Path("/environment").HandlerFunc(projects.AddEnvironment).Methods("POST")
+	projectUserAPI.Path("/sync").HandlerFunc(projects.GetProjectSync).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/tasks").HandlerFunc(tasks.GetAllTasks).Methods("GET", "HEAD")
 	projectUserAPI.HandleFunc("/tasks/last", tasks.GetLastTasks).Methods("GET", "HEAD")
-	projectUserAPI.Path("/tasks").HandlerFunc(tasks.AddTask).Methods("POST")
+	projectUserAPI.HandleFunc("/tasks/stats", tasks.GetTaskStats).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/templates").HandlerFunc(projects.GetTemplates).Methods("GET", "HEAD")
-	projectUserAPI.Path("/templates").HandlerFunc(projects.AddTemplate).Methods("POST")
-
-	projectUserAPI.Path("/schedules").HandlerFunc(projects.AddSchedule).Methods("POST")
-	projectUserAPI.Path("/schedules/validate").HandlerFunc(projects.ValidateScheduleCronFormat).Methods("POST")
 
-	projectAdminAPI := authenticatedAPI.Path("/project/{project_id}").Subrouter()
-	projectAdminAPI.Use(projects.ProjectMiddleware, projects.MustBeAdmin)
-	projectAdminAPI.Methods("PUT").HandlerFunc(projects.UpdateProject)
-	projectAdminAPI.Methods("DELETE").HandlerFunc(projects.DeleteProject)
+	// routes which run tasks
+	projectRunnerAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectRunnerAPI.Use(projects.ProjectMiddleware, projects.MustHavePermission(db.CanRunProjectTasks))
+
+	projectRunnerAPI.Path("/tasks").HandlerFunc(tasks.AddTask).Methods("POST")
+	projectRunnerAPI.Path("/templates/{template_id}/validate").HandlerFunc(tasks.ValidateTemplate).Methods("POST")
+
+	// routes which change keys, repositories, inventories, environments, templates and schedules
+	projectManagerAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectManagerAPI.Use(projects.ProjectMiddleware, projects.MustHavePermission(db.CanManageProjectResources))
+
+	projectManagerAPI.Path("/keys").HandlerFunc(projects.AddKey).Methods("POST")
+	projectManagerAPI.Path("/repositories").HandlerFunc(projects.AddRepository).Methods("POST")
+	projectManagerAPI.Path("/inventory").HandlerFunc(projects.AddInventory).Methods("POST")
+	projectManagerAPI.Path("/environment").HandlerFunc(projects.AddEnvironment).Methods("POST")
+	projectManagerAPI.Path("/templates").HandlerFunc(projects.AddTemplate).Methods("POST")
+	projectManagerAPI.Path("/schedules").HandlerFunc(projects.AddSchedule).Methods("POST")
+	projectManagerAPI.Path("/schedules/validate").HandlerFunc(projects.ValidateScheduleCronFormat).Methods("POST")
+	projectManagerAPI.Path("/sync").HandlerFunc(projects.SetProjectSync).Methods("PUT")
+	projectManagerAPI.Path("/sync").HandlerFunc(projects.RemoveProjectSync).Methods("DELETE")
+	projectManagerAPI.Path("/sync/plan").HandlerFunc(projects.GetProjectSyncPlan).Methods("GET", "HEAD")
+	projectManagerAPI.Path("/sync/apply").HandlerFunc(projects.ApplyProjectSync).Methods("POST")
+
+	projectOwnerAPI := authenticatedAPI.Path("/project/{project_id}").Subrouter()
+	projectOwnerAPI.Use(projects.ProjectMiddleware, projects.MustHavePermission(db.CanUpdateProject))
+	projectOwnerAPI.Methods("PUT").HandlerFunc(projects.UpdateProject)
+	projectOwnerAPI.Methods("DELETE").HandlerFunc(projects.DeleteProject)
+
+	projectCopyAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectCopyAPI.Use(projects.ProjectMiddleware, projects.MustHavePermission(db.CanUpdateProject))
+	projectCopyAPI.Path("/export").HandlerFunc(projects.ExportProject).Methods("GET", "HEAD")
+	projectCopyAPI.Path("/clone").HandlerFunc(projects.CloneProject).Methods("POST")
 
 	projectAdminUsersAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
-	projectAdminUsersAPI.Use(projects.ProjectMiddleware, projects.MustBeAdmin)
+	projectAdminUsersAPI.Use(projects.ProjectMiddleware, projects.MustHavePermission(db.CanManageProjectUsers))
 	projectAdminUsersAPI.Path("/users").HandlerFunc(projects.AddUser).Methods("POST")
+	projectAdminUsersAPI.Path("/invitations").HandlerFunc(addProjectInvitation).Methods("POST")
+
+	projectTmplPermissions := projectAdminUsersAPI.PathPrefix("/templates").Subrouter()
+	projectTmplPermissions.Use(projects.TemplatesMiddleware)
+
+	projectTmplPermissions.HandleFunc("/{template_id}/permissions", projects.GetTemplatePermissions).Methods("GET", "HEAD")
+	projectTmplPermissions.HandleFunc("/{template_id}/permissions", projects.AddTemplatePermission).Methods("POST")
+	projectTmplPermissions.HandleFunc("/{template_id}/permissions/{permission_id}", projects.RemoveTemplatePermission).Methods("DELETE")
+
+	projectAdminUsersAPI.Path("/teams").HandlerFunc(projects.AddTeam).Methods("POST")
+
+	projectTeamManagement := projectAdminUsersAPI.PathPrefix("/teams").Subrouter()
+	projectTeamManagement.Use(projects.TeamMiddleware)
+
+	projectTeamManagement.HandleFunc("/{team_id}", projects.GetTeams).Methods("GET", "HEAD")
+	projectTeamManagement.HandleFunc("/{team_id}", projects.UpdateTeam).Methods("PUT")
+	projectTeamManagement.HandleFunc("/{team_id}", projects.RemoveTeam).Methods("DELETE")
 
 	projectUserManagement := projectAdminUsersAPI.PathPrefix("/users").Subrouter()
 	projectUserManagement.Use(projects.UserMiddleware)
 
 	projectUserManagement.HandleFunc("/{user_id}", projects.GetUsers).Methods("GET", "HEAD")
+	projectUserManagement.HandleFunc("/{user_id}", projects.UpdateUser).Methods("PUT")
 	projectUserManagement.HandleFunc("/{user_id}/admin", projects.MakeUserAdmin).Methods("POST")
 	projectUserManagement.HandleFunc("/{user_id}/admin", projects.MakeUserAdmin).Methods("DELETE")
 	projectUserManagement.HandleFunc("/{user_id}", projects.RemoveUser).Methods("DELETE")
 
-	projectKeyManagement := projectAdminUsersAPI.PathPrefix("/keys").Subrouter()
+	projectKeyManagement := projectManagerAPI.PathPrefix("/keys").Subrouter()
 	projectKeyManagement.Use(projects.KeyMiddleware)
 
 	projectKeyManagement.HandleFunc("/{key_id}", projects.GetKeys).Methods("GET", "HEAD")
 	projectKeyManagement.HandleFunc("/{key_id}", projects.UpdateKey).Methods("PUT")
 	projectKeyManagement.HandleFunc("/{key_id}", projects.RemoveKey).Methods("DELETE")
 
-	projectRepoManagement := projectUserAPI.PathPrefix("/repositories").Subrouter()
+	projectRepoGet := projectUserAPI.PathPrefix("/repositories").Subrouter()
+	projectRepoGet.Use(projects.RepositoryMiddleware)
+	projectRepoGet.HandleFunc("/{repository_id}", projects.GetRepositories).Methods("GET", "HEAD")
+
+	projectRepoManagement := projectManagerAPI.PathPrefix("/repositories").Subrouter()
 	projectRepoManagement.Use(projects.RepositoryMiddleware)
 
-	projectRepoManagement.HandleFunc("/{repository_id}", projects.GetRepositories).Methods("GET", "HEAD")
 	projectRepoManagement.HandleFunc("/{repository_id}", projects.UpdateRepository).Methods("PUT")
 	projectRepoManagement.HandleFunc("/{repository_id}", projects.RemoveRepository).Methods("DELETE")
 
-	projectInventoryManagement := projectUserAPI.PathPrefix("/inventory").Subrouter()
+	projectInventoryGet := projectUserAPI.PathPrefix("/inventory").Subrouter()
+	projectInventoryGet.Use(projects.InventoryMiddleware)
+	projectInventoryGet.HandleFunc("/{inventory_id}", projects.GetInventory).Methods("GET", "HEAD")
+	projectInventoryGet.HandleFunc("/{inventory_id}/revisions", projects.GetRevisions).Methods("GET", "HEAD")
+	projectInventoryGet.HandleFunc("/{inventory_id}/revisions/diff", projects.GetRevisionDiff).Methods("GET", "HEAD")
+
+	projectInventoryManagement := projectManagerAPI.PathPrefix("/inventory").Subrouter()
 	projectInventoryManagement.Use(projects.InventoryMiddleware)
 
-	projectInventoryManagement.HandleFunc("/{inventory_id}", projects.GetInventory).Methods("GET", "HEAD")
 	projectInventoryManagement.HandleFunc("/{inventory_id}", projects.UpdateInventory).Methods("PUT")
 	projectInventoryManagement.HandleFunc("/{inventory_id}", projects.RemoveInventory).Methods("DELETE")
+	projectInventoryManagement.HandleFunc("/{inventory_id}/revisions/{revision_id}/restore", projects.RestoreRevision).Methods("POST")
 
-	projectEnvManagement := projectUserAPI.PathPrefix("/environment").Subrouter()
+	projectEnvGet := projectUserAPI.PathPrefix("/environment").Subrouter()
+	projectEnvGet.Use(projects.EnvironmentMiddleware)
+	projectEnvGet.HandleFunc("/{environment_id}", projects.GetEnvironment).Methods("GET", "HEAD")
+	projectEnvGet.HandleFunc("/{environment_id}/revisions", projects.GetRevisions).Methods("GET", "HEAD")
+	projectEnvGet.HandleFunc("/{environment_id}/revisions/diff", projects.GetRevisionDiff).Methods("GET", "HEAD")
+
+	projectEnvManagement := projectManagerAPI.PathPrefix("/environment").Subrouter()
 	projectEnvManagement.Use(projects.EnvironmentMiddleware)
 
-	projectEnvManagement.HandleFunc("/{environment_id}", projects.GetEnvironment).Methods("GET", "HEAD")
 	projectEnvManagement.HandleFunc("/{environment_id}", projects.UpdateEnvironment).Methods("PUT")
 	projectEnvManagement.HandleFunc("/{environment_id}", projects.RemoveEnvironment).Methods("DELETE")
+	projectEnvManagement.HandleFunc("/{environment_id}/revisions/{revision_id}/restore", projects.RestoreRevision).Methods("POST")
+
+	projectTmplGet := projectUserAPI.PathPrefix("/templates").Subrouter()
+	projectTmplGet.Use(projects.TemplatesMiddleware)
 
-	projectTmplManagement := projectUserAPI.PathPrefix("/templates").Subrouter()
+	projectTmplGet.HandleFunc("/{template_id}", projects.GetTemplate).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/tasks", tasks.GetAllTasks).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/tasks/last", tasks.GetLastTasks).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/tasks/stats", tasks.GetTaskStats).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/schedules", projects.GetTemplateSchedules).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/revisions", projects.GetRevisions).Methods("GET")
+	projectTmplGet.HandleFunc("/{template_id}/revisions/diff", projects.GetRevisionDiff).Methods("GET")
+
+	projectTmplManagement := projectManagerAPI.PathPrefix("/templates").Subrouter()
 	projectTmplManagement.Use(projects.TemplatesMiddleware)
 
 	projectTmplManagement.HandleFunc("/{template_id}", projects.UpdateTemplate).Methods("PUT")
 	projectTmplManagement.HandleFunc("/{template_id}", projects.RemoveTemplate).Methods("DELETE")
-	projectTmplManagement.HandleFunc("/{template_id}", projects.GetTemplate).Methods("GET")
-	projectTmplManagement.HandleFunc("/{template_id}/tasks", tasks.GetAllTasks).Methods("GET")
-	projectTmplManagement.HandleFunc("/{template_id}/tasks/last", tasks.GetLastTasks).Methods("GET")
-	projectTmplManagement.HandleFunc("/{template_id}/schedules", projects.GetTemplateSchedules).Methods("GET")
+	projectTmplManagement.HandleFunc("/{template_id}/clone", projects.CloneTemplate).Methods("POST")
+	projectTmplManagement.HandleFunc("/{template_id}/revisions/{revision_id}/restore", projects.RestoreRevision).Methods("POST")
 
-	projectTaskManagement := projectUserAPI.PathPrefix("/tasks").Subrouter()
-	projectTaskManagement.Use(tasks.GetTaskMiddleware)
+	projectTaskGet := projectUserAPI.PathPrefix("/tasks").Subrouter()
+	projectTaskGet.Use(tasks.GetTaskMiddleware)
 
-	projectTaskManagement.HandleFunc("/{task_id}/output", tasks.GetTaskOutput).Methods("GET", "HEAD")
-	projectTaskManagement.HandleFunc("/{task_id}", tasks.GetTask).Methods("GET", "HEAD")
+	projectTaskGet.HandleFunc("/{task_id}/output", tasks.GetTaskOutput).Methods("GET", "HEAD")
+	projectTaskGet.HandleFunc("/{task_id}/findings", tasks.GetTaskFindings).Methods("GET", "HEAD")
+	projectTaskGet.HandleFunc("/{task_id}/hosts", tasks.GetTaskHostStats).Methods("GET", "HEAD")
+	projectTaskGet.HandleFunc("/{task_id}/approvals", tasks.GetTaskApprovals).Methods("GET", "HEAD")
+	projectTaskGet.HandleFunc("/{task_id}", tasks.GetTask).Methods("GET", "HEAD")
+
+	projectTaskRun := projectRunnerAPI.PathPrefix("/tasks").Subrouter()
+	projectTaskRun.Use(tasks.GetTaskMiddleware)
+	projectTaskRun.HandleFunc("/{task_id}/stop", tasks.StopTask).Methods("POST")
+	projectTaskRun.HandleFunc("/{task_id}/approve", tasks.ApproveTask).Methods("POST")
+	projectTaskRun.HandleFunc("/{task_id}/reject", tasks.RejectTask).Methods("POST")
+
+	projectTaskManagement := projectManagerAPI.PathPrefix("/tasks").Subrouter()
+	projectTaskManagement.Use(tasks.GetTaskMiddleware)
 	projectTaskManagement.HandleFunc("/{task_id}", tasks.RemoveTask).Methods("DELETE")
-	projectTaskManagement.HandleFunc("/{task_id}/stop", tasks.StopTask).Methods("POST")
 
+	projectScheduleGet := projectUserAPI.PathPrefix("/schedules").Subrouter()
+	projectScheduleGet.Use(projects.SchedulesMiddleware)
+	projectScheduleGet.HandleFunc("/{schedule_id}", projects.GetSchedule).Methods("GET", "HEAD")
 
-	projectScheduleManagement := projectUserAPI.PathPrefix("/schedules").Subrouter()
+	projectScheduleManagement := projectManagerAPI.PathPrefix("/schedules").Subrouter()
 	projectScheduleManagement.Use(projects.SchedulesMiddleware)
-	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.GetSchedule).Methods("GET", "HEAD")
 	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.UpdateSchedule).Methods("PUT")
 	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.RemoveSchedule).Methods("DELETE")
 
@@ -4,6 +4,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/ansible-semaphore/semaphore/api/tasks"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/metrics"
 	"github.com/robfig/cron/v3"
 	"sync"
 )
@@ -14,6 +15,8 @@ type ScheduleRunner struct {
 }
 
 func (r ScheduleRunner) Run() {
+	metrics.ObserveScheduleRun(r.Schedule.ProjectID, r.Schedule.TemplateID)
+
 	_, err := tasks.AddTaskToPool(r.Store, db.Task{
 		TemplateID: r.Schedule.TemplateID,
 		ProjectID: r.Schedule.ProjectID,
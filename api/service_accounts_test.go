@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+
+	_, err = store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &testClient{router: router}
+	admin.do("POST", "/api/auth/login", map[string]string{"auth": "admin", "password": "password"})
+
+	rr := admin.do("POST", "/api/users", map[string]interface{}{
+		"username": "ci",
+		"name":     "CI",
+		"email":    "ci@example.com",
+		"service":  true,
+	})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var service db.User
+	_ = json.Unmarshal(rr.Body.Bytes(), &service)
+
+	if !service.Service {
+		t.Fatal("service account must be flagged")
+	}
+
+	login := map[string]string{"auth": "ci", "password": ""}
+	if rr = (&testClient{router: router}).do("POST", "/api/auth/login", login); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("service account must not log in: %d", rr.Code)
+	}
+
+	if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: service.ID, Role: db.ProjectManager}); err != nil {
+		t.Fatal(err)
+	}
+
+	tokensURL := "/api/users/" + strconv.Itoa(service.ID) + "/tokens"
+	scopes := map[string]string{"name": "pipeline", "scopes": "admin"}
+
+	if rr = clients[db.ProjectOwner].do("POST", tokensURL, scopes); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("only admins may create tokens of service accounts: %d", rr.Code)
+	}
+
+	rr = admin.do("POST", tokensURL, scopes)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var token struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &token)
+
+	ci := &testClient{router: router, token: token.Token}
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+
+	if rr = ci.do("POST", projectURL+"/keys", map[string]interface{}{"name": "key", "type": "none", "project_id": project.ID}); rr.Code != http.StatusNoContent {
+		t.Fatalf("service account must act with its project role: %d", rr.Code)
+	}
+
+	var events []db.Event
+	_ = json.Unmarshal(ci.do("GET", projectURL+"/events", nil).Body.Bytes(), &events)
+
+	if len(events) == 0 || events[0].UserID == nil || *events[0].UserID != service.ID {
+		t.Fatal("events must be attributed to the service account")
+	}
+
+	var users []db.User
+	_ = json.Unmarshal(admin.do("GET", "/api/users", nil).Body.Bytes(), &users)
+
+	for _, user := range users {
+		if user.Service != (user.ID == service.ID) {
+			t.Fatalf("only the service account must be flagged, user %s", user.Username)
+		}
+	}
+
+	ownerTokensURL := "/api/users/" + strconv.Itoa(users[0].ID) + "/tokens"
+	if users[0].ID == service.ID {
+		ownerTokensURL = "/api/users/" + strconv.Itoa(users[1].ID) + "/tokens"
+	}
+
+	if rr = admin.do("GET", ownerTokensURL, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("tokens of regular users must not be managed: %d", rr.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+type sessionInfo struct {
+	db.Session
+	Current bool `json:"current"`
+}
+
+// getSessionsUser returns the user whose sessions are managed and the ID of the current session
+// if it belongs to this user. Admins manage sessions of other users via /users/{user_id}/sessions.
+func getSessionsUser(r *http.Request) (user db.User, currentSessionID int) {
+	user = *context.Get(r, "user").(*db.User)
+
+	if u, exists := context.GetOk(r, "_user"); exists {
+		if u.(db.User).ID != user.ID {
+			return u.(db.User), 0
+		}
+	}
+
+	if session, ok := context.GetOk(r, "session"); ok {
+		currentSessionID = session.(db.Session).ID
+	}
+
+	return
+}
+
+func getSessions(w http.ResponseWriter, r *http.Request) {
+	user, currentSessionID := getSessionsUser(r)
+
+	sessions, err := helpers.Store(r).GetSessions(user.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]sessionInfo, len(sessions))
+	for i, session := range sessions {
+		res[i] = sessionInfo{Session: session, Current: session.ID == currentSessionID}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+func expireSession(w http.ResponseWriter, r *http.Request) {
+	user, _ := getSessionsUser(r)
+
+	sessionID, err := helpers.GetIntParam("session_id", w, r)
+	if err != nil {
+		return
+	}
+
+	if err = helpers.Store(r).ExpireSession(user.ID, sessionID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// expireOtherSessions expires all sessions of the user except the current one.
+func expireOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user, currentSessionID := getSessionsUser(r)
+
+	if err := helpers.Store(r).ExpireSessions(user.ID, currentSessionID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestSessionManagement(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	for _, u := range []db.User{
+		{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+		{Username: "user", Name: "User", Email: "user@example.com"},
+	} {
+		if _, err := store.CreateUser(db.UserWithPwd{Pwd: "password", User: u}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	login := func(username string) *testClient {
+		client := &testClient{router: router}
+		client.do("POST", "/api/auth/login", map[string]string{"auth": username, "password": "password"})
+		return client
+	}
+
+	laptop := login("user")
+	phone := login("user")
+
+	rr := laptop.do("GET", "/api/user/sessions", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var sessions []sessionInfo
+	_ = json.Unmarshal(rr.Body.Bytes(), &sessions)
+
+	if len(sessions) != 2 || sessions[0].Current == sessions[1].Current {
+		t.Fatal("both sessions must be listed and one of them marked as current")
+	}
+
+	if rr = laptop.do("DELETE", "/api/user/sessions", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = phone.do("GET", "/api/user", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("other sessions must be revoked: %d", rr.Code)
+	}
+
+	if rr = laptop.do("GET", "/api/user", nil); rr.Code != http.StatusOK {
+		t.Fatalf("current session must be kept: %d", rr.Code)
+	}
+
+	var user db.User
+	_ = json.Unmarshal(rr.Body.Bytes(), &user)
+	sessionsURL := "/api/users/" + strconv.Itoa(user.ID) + "/sessions"
+
+	if rr = phone.do("DELETE", sessionsURL, nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked session must not be usable: %d", rr.Code)
+	}
+
+	admin := login("admin")
+
+	if rr = admin.do("DELETE", sessionsURL, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("admin must be able to revoke sessions of other users: %d", rr.Code)
+	}
+
+	if rr = laptop.do("GET", "/api/user", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("sessions revoked by admin must not be usable: %d", rr.Code)
+	}
+
+	if rr = admin.do("GET", "/api/user", nil); rr.Code != http.StatusOK {
+		t.Fatalf("session of admin must be kept: %d", rr.Code)
+	}
+}
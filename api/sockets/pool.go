@@ -1,5 +1,7 @@
 package sockets
 
+import "github.com/ansible-semaphore/semaphore/metrics"
+
 // hub maintains the set of active connections and broadcasts messages to the
 // connections.
 type hub struct {
@@ -53,6 +55,8 @@ func (h *hub) run() {
 				}
 			}
 		}
+
+		metrics.WebsocketConnections.Set(float64(len(h.connections)))
 	}
 }
 
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+	"net/http"
+)
+
+func getSubscriptionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := context.Get(r, "user").(*db.User)
+
+		subscriptionID, err := helpers.GetIntParam("subscription_id", w, r)
+		if err != nil {
+			return
+		}
+
+		subscription, err := helpers.Store(r).GetSubscription(user.ID, subscriptionID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "subscription", subscription)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateSubscription checks that the user is a member of the subscription's project
+// and the template (if specified) belongs to this project.
+func validateSubscription(w http.ResponseWriter, r *http.Request, subscription db.Subscription) bool {
+	_, err := db.GetProjectMemberRole(helpers.Store(r), subscription.ProjectID, subscription.UserID)
+
+	if err == db.ErrNotFound {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "You are not a member of the project",
+		})
+		return false
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	if subscription.TemplateID == nil {
+		return true
+	}
+
+	_, err = helpers.Store(r).GetTemplate(subscription.ProjectID, *subscription.TemplateID)
+
+	if err == db.ErrNotFound {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Template not found in the project",
+		})
+		return false
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	return true
+}
+
+func getSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if subscription, exists := context.GetOk(r, "subscription"); exists {
+		helpers.WriteJSON(w, http.StatusOK, subscription)
+		return
+	}
+
+	user := context.Get(r, "user").(*db.User)
+
+	subscriptions, err := helpers.Store(r).GetSubscriptions(user.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+func addSubscription(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	var subscription db.Subscription
+	if !helpers.Bind(w, r, &subscription) {
+		return
+	}
+
+	subscription.UserID = user.ID
+
+	if !validateSubscription(w, r, subscription) {
+		return
+	}
+
+	newSubscription, err := helpers.Store(r).CreateSubscription(subscription)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newSubscription)
+}
+
+func updateSubscription(w http.ResponseWriter, r *http.Request) {
+	oldSubscription := context.Get(r, "subscription").(db.Subscription)
+
+	var subscription db.Subscription
+	if !helpers.Bind(w, r, &subscription) {
+		return
+	}
+
+	if subscription.ID != oldSubscription.ID {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Subscription ID in body and URL must be the same",
+		})
+		return
+	}
+
+	subscription.UserID = oldSubscription.UserID
+
+	if !validateSubscription(w, r, subscription) {
+		return
+	}
+
+	if err := helpers.Store(r).UpdateSubscription(subscription); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription := context.Get(r, "subscription").(db.Subscription)
+
+	if err := helpers.Store(r).DeleteSubscription(subscription.UserID, subscription.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
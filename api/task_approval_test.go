@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestTaskApproval(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, template := createCloneTestProject(t, store)
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+	runnerID := userIDs[db.ProjectTaskRunner]
+
+	check, err := store.CreateTask(db.Task{
+		TemplateID:    template.ID,
+		ProjectID:     project.ID,
+		UserID:        &runnerID,
+		Status:        "awaiting_approval",
+		DryRun:        true,
+		Diff:          true,
+		AwaitApproval: true,
+		Created:       time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateTaskHostStat(db.TaskHostStat{TaskID: check.ID, Host: "localhost", Ok: 3, Changed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taskURL := "/api/project/" + strconv.Itoa(project.ID) + "/tasks/" + strconv.Itoa(check.ID)
+
+	if rr := clients[db.ProjectGuest].do("GET", taskURL+"/hosts", nil); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectGuest].do("POST", taskURL+"/reject", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("guest must not reject checks: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("POST", taskURL+"/approve", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("author must not approve the own check: %d", rr.Code)
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("POST", taskURL+"/reject", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	rejected, err := store.GetTask(project.ID, check.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != "rejected" {
+		t.Fatalf("check must be rejected, got %s", rejected.Status)
+	}
+
+	if rr := clients[db.ProjectTaskRunner].do("POST", taskURL+"/approve", nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("rejected check must not be approved: %d", rr.Code)
+	}
+}
+
+func TestTaskApprovalGate(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, template := createCloneTestProject(t, store)
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+	manager := clients[db.ProjectManager]
+	runner := clients[db.ProjectTaskRunner]
+
+	templateURL := "/api/project/" + strconv.Itoa(project.ID) + "/templates/" + strconv.Itoa(template.ID)
+
+	template.RequiredApprovals = 2
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusBadRequest {
+		t.Fatalf("approvals require approver role: %d", rr.Code)
+	}
+
+	template.ApproverRole = db.ProjectManager
+	template.ApprovalExpiry = 60
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	// tasks pending approval are not queued, so creating them does not wait for the task pool
+	rr := runner.do("POST", "/api/project/"+strconv.Itoa(project.ID)+"/tasks", map[string]interface{}{"template_id": template.ID})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var task db.Task
+	_ = json.Unmarshal(rr.Body.Bytes(), &task)
+
+	if task.Status != "pending_approval" {
+		t.Fatalf("task must be pending approval, got %s", task.Status)
+	}
+
+	taskURL := "/api/project/" + strconv.Itoa(project.ID) + "/tasks/" + strconv.Itoa(task.ID)
+
+	if rr = runner.do("POST", taskURL+"/approve", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("task runner must not approve: %d", rr.Code)
+	}
+
+	if rr = manager.do("POST", taskURL+"/approve", nil); rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr = manager.do("POST", taskURL+"/approve", nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("user must approve only once: %d", rr.Code)
+	}
+
+	task, err := store.GetTask(project.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "pending_approval" {
+		t.Fatal("task must wait for the second approval")
+	}
+
+	if rr = clients[db.ProjectOwner].do("POST", taskURL+"/reject", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if task, err = store.GetTask(project.ID, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "rejected" {
+		t.Fatalf("task must be rejected, got %s", task.Status)
+	}
+
+	approvals, err := store.GetTaskApprovals(project.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 2 || !approvals[0].Approved || approvals[0].UserID != userIDs[db.ProjectManager] || approvals[1].Approved {
+		t.Fatalf("approval and rejection must be recorded: %+v", approvals)
+	}
+}
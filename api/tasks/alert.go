@@ -6,15 +6,17 @@ import (
 	"net/http"
 	"strconv"
 
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/db"
 	"github.com/ansible-semaphore/semaphore/util"
 )
 
-const emailTemplate = `Subject: Task '{{ .Alias }}' failed
+const emailTemplate = `Subject: Task '{{ .Alias }}' {{ .Result }}
 
-Task {{ .TaskID }} with template '{{ .Alias }}' has failed!
+Task {{ .TaskID }} with template '{{ .Alias }}' {{ .Result }}!
 Task log: <a href='{{ .TaskURL }}'>{{ .TaskURL }}</a>`
 
-const telegramTemplate = `{"chat_id": "{{ .ChatID }}","text":"<b>Task {{ .TaskID }} with template '{{ .Alias }}' has failed!</b>\nTask log: <a href='{{ .TaskURL }}'>{{ .TaskURL }}</a>","parse_mode":"HTML"}`
+const telegramTemplate = `{"chat_id": "{{ .ChatID }}","text":"<b>Task {{ .TaskID }} with template '{{ .Alias }}' {{ .Result }}!</b>\nTask log: <a href='{{ .TaskURL }}'>{{ .TaskURL }}</a>","parse_mode":"HTML"}`
 
 // Alert represents an alert that will be templated and sent to the appropriate service
 type Alert struct {
@@ -22,10 +24,130 @@ type Alert struct {
 	Alias   string
 	TaskURL string
 	ChatID  string
+	Result  string
 }
 
-func (t *task) sendMailAlert() {
-	if !util.Config.EmailAlert || !t.alert {
+func (t *task) isProjectUser(userID int) bool {
+	for _, user := range t.users {
+		if user == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *task) getProjectChat() string {
+	if t.alertChat != "" {
+		return t.alertChat
+	}
+	return util.Config.TelegramChat
+}
+
+// sendAlerts notifies users subscribed to the outcome of the finished task.
+// Recipients are resolved synchronously, messages are sent in the background.
+func (t *task) sendAlerts() {
+	outcome, failureStreak := t.getOutcome()
+	if outcome == "" {
+		return
+	}
+
+	mailUsers, chats, err := t.getAlertRecipients(outcome, failureStreak)
+	if err != nil {
+		t.log("Can't load alert subscriptions: " + err.Error())
+		log.Error(err)
+		return
+	}
+
+	result := describeOutcome(outcome, failureStreak)
+
+	// slow mail servers and chat APIs must not block the task pool and HTTP handlers
+	go func() {
+		t.sendMailAlert(result, mailUsers)
+		t.sendTelegramAlert(result, chats)
+	}()
+}
+
+// getAlertRecipients returns users to mail and Telegram chats to notify about the outcome.
+// Users without subscriptions in the project fall back to the project alert settings,
+// which cover only new failures, so a failing template does not page them on every run.
+func (t *task) getAlertRecipients(outcome string, failureStreak int) (mailUsers []int, chats []string, err error) {
+	subscriptions, err := t.store.GetProjectSubscriptions(t.projectID)
+	if err != nil {
+		return
+	}
+
+	subscribedUsers := make(map[int]bool)
+
+	addMailUser := func(userID int) {
+		for _, u := range mailUsers {
+			if u == userID {
+				return
+			}
+		}
+		mailUsers = append(mailUsers, userID)
+	}
+
+	addChat := func(chatID string) {
+		if chatID == "" {
+			return
+		}
+		for _, c := range chats {
+			if c == chatID {
+				return
+			}
+		}
+		chats = append(chats, chatID)
+	}
+
+	for _, subscription := range subscriptions {
+		if !t.isProjectUser(subscription.UserID) {
+			continue
+		}
+
+		subscribedUsers[subscription.UserID] = true
+
+		if !subscription.Matches(t.task.TemplateID, outcome, failureStreak) {
+			continue
+		}
+
+		if subscription.Email {
+			addMailUser(subscription.UserID)
+		}
+
+		if subscription.Telegram {
+			if subscription.TelegramChat != "" {
+				addChat(subscription.TelegramChat)
+			} else {
+				addChat(t.getProjectChat())
+			}
+		}
+	}
+
+	if t.alert && outcome == db.TaskOutcomeNewFailure {
+		for _, userID := range t.users {
+			if subscribedUsers[userID] {
+				continue
+			}
+
+			user, userErr := t.store.GetUser(userID)
+			if userErr != nil {
+				log.Error(userErr)
+				continue
+			}
+
+			if user.Alert {
+				addMailUser(userID)
+			}
+		}
+
+		addChat(t.getProjectChat())
+	}
+
+	return
+}
+
+func (t *task) sendMailAlert(result string, userIDs []int) {
+	if !util.Config.EmailAlert || len(userIDs) == 0 {
 		return
 	}
 
@@ -36,55 +158,74 @@ func (t *task) sendMailAlert() {
 		TaskID:  strconv.Itoa(t.task.ID),
 		Alias:   t.template.Alias,
 		TaskURL: util.Config.WebHost + "/project/" + strconv.Itoa(t.template.ProjectID),
+		Result:  result,
 	}
 	tpl := template.New("mail body template")
 	tpl, err := tpl.Parse(emailTemplate)
 	util.LogError(err)
 
-	t.panicOnError(tpl.Execute(&mailBuffer, alert), "Can't generate alert template!")
+	if err = tpl.Execute(&mailBuffer, alert); err != nil {
+		t.log("Can't generate alert template!")
+		log.Error(err)
+		return
+	}
 
-	for _, user := range t.users {
-		userObj, err := t.store.GetUser(user)
+	for _, userID := range userIDs {
+		user, err := t.store.GetUser(userID)
 
-		if !userObj.Alert {
-			return
+		if err != nil {
+			t.log("Can't find user Email!")
+			log.Error(err)
+			continue
 		}
-		t.panicOnError(err,"Can't find user Email!")
 
-		t.log("Sending email to " + userObj.Email + " from " + util.Config.EmailSender)
-		err = util.SendMail(mailHost, util.Config.EmailSender, userObj.Email, mailBuffer)
-		t.panicOnError(err, "Can't send email!")
+		t.log("Sending email to " + user.Email + " from " + util.Config.EmailSender)
+		err = util.SendMail(mailHost, util.Config.EmailSender, user.Email, mailBuffer)
+
+		if err != nil {
+			t.log("Can't send email!")
+			log.Error(err)
+		}
 	}
 }
 
-func (t *task) sendTelegramAlert() {
-	if !util.Config.TelegramAlert || !t.alert {
+func (t *task) sendTelegramAlert(result string, chats []string) {
+	if !util.Config.TelegramAlert {
 		return
 	}
 
-	chatID := util.Config.TelegramChat
-	if t.alertChat != "" {
-		chatID = t.alertChat
-	}
+	for _, chatID := range chats {
+		var telegramBuffer bytes.Buffer
+		alert := Alert{
+			TaskID:  strconv.Itoa(t.task.ID),
+			Alias:   t.template.Alias,
+			TaskURL: util.Config.WebHost + "/project/" + strconv.Itoa(t.template.ProjectID) + "/templates/" + strconv.Itoa(t.template.ID) + "?t=" + strconv.Itoa(t.task.ID),
+			ChatID:  chatID,
+			Result:  result,
+		}
 
-	var telegramBuffer bytes.Buffer
-	alert := Alert{
-		TaskID:  strconv.Itoa(t.task.ID),
-		Alias:   t.template.Alias,
-		TaskURL: util.Config.WebHost + "/project/" + strconv.Itoa(t.template.ProjectID) + "/templates/" + strconv.Itoa(t.template.ID) + "?t=" + strconv.Itoa(t.task.ID),
-		ChatID:  chatID,
-	}
+		tpl := template.New("telegram body template")
+		tpl, err := tpl.Parse(telegramTemplate)
+		util.LogError(err)
 
-	tpl := template.New("telegram body template")
-	tpl, err := tpl.Parse(telegramTemplate)
-	util.LogError(err)
+		if err = tpl.Execute(&telegramBuffer, alert); err != nil {
+			t.log("Can't generate alert template!")
+			log.Error(err)
+			return
+		}
+
+		resp, err := http.Post("https://api.telegram.org/bot"+util.Config.TelegramToken+"/sendMessage", "application/json", &telegramBuffer)
 
-	t.panicOnError(tpl.Execute(&telegramBuffer, alert),"Can't generate alert template!")
+		if err != nil {
+			t.log("Can't send telegram alert!")
+			log.Error(err)
+			continue
+		}
 
-	resp, err := http.Post("https://api.telegram.org/bot"+util.Config.TelegramToken+"/sendMessage", "application/json", &telegramBuffer)
-	t.panicOnError(err, "Can't send telegram alert!")
+		if resp.StatusCode != 200 {
+			t.log("Can't send telegram alert! Response code not 200!")
+		}
 
-	if resp.StatusCode != 200 {
-		t.log("Can't send telegram alert! Response code not 200!")
+		_ = resp.Body.Close()
 	}
 }
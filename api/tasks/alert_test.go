@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+func TestGetAlertRecipients(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil }()
+
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_alerts_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test", Alert: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alerted, err := store.CreateUser(db.UserWithPwd{Pwd: "password", User: db.User{Username: "alerted", Email: "alerted@example.com", Alert: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subscribed, err := store.CreateUser(db.UserWithPwd{Pwd: "password", User: db.User{Username: "subscribed", Email: "subscribed@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateSubscription(db.Subscription{
+		UserID:               subscribed.ID,
+		ProjectID:            project.ID,
+		OnRepeatedFailure:    true,
+		RepeatedFailureEvery: 3,
+		Email:                true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tsk := task{
+		store:     store,
+		projectID: project.ID,
+		alert:     true,
+		users:     []int{alerted.ID, subscribed.ID},
+		task:      db.Task{TemplateID: 1},
+	}
+
+	for _, c := range []struct {
+		outcome string
+		streak  int
+		users   []int
+	}{
+		{db.TaskOutcomeNewFailure, 1, []int{alerted.ID}},
+		{db.TaskOutcomeRepeatedFailure, 2, nil},
+		{db.TaskOutcomeRepeatedFailure, 3, []int{subscribed.ID}},
+		{db.TaskOutcomeSuccess, 0, nil},
+	} {
+		users, _, err := tsk.getAlertRecipients(c.outcome, c.streak)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(users) != len(c.users) || (len(users) > 0 && users[0] != c.users[0]) {
+			t.Fatalf("%s (%d): expected %v, got %v", c.outcome, c.streak, c.users, users)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package tasks
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/context"
+)
+
+// recapLine matches host lines of the PLAY RECAP of ansible-playbook.
+var recapLine = regexp.MustCompile(`^(\S+)\s+:\s+ok=(\d+)\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)` +
+	`(?:\s+skipped=(\d+))?(?:\s+rescued=(\d+))?(?:\s+ignored=(\d+))?`)
+
+// approvalLock prevents approving or rejecting the same check twice.
+var approvalLock sync.Mutex
+
+// parseRecapLine returns the stats of the host if the line is a PLAY RECAP line.
+func parseRecapLine(line string) (stat db.TaskHostStat, ok bool) {
+	m := recapLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return
+	}
+
+	counts := make([]int, len(m)-2)
+	for i, s := range m[2:] {
+		if s != "" {
+			counts[i], _ = strconv.Atoi(s)
+		}
+	}
+
+	return db.TaskHostStat{
+		Host:        m[1],
+		Ok:          counts[0],
+		Changed:     counts[1],
+		Unreachable: counts[2],
+		Failed:      counts[3],
+		Skipped:     counts[4],
+		Rescued:     counts[5],
+		Ignored:     counts[6],
+	}, true
+}
+
+// logRecap logs the output of the playbook and collects the stats of hosts from its recap.
+func (t *task) logRecap(reader *bufio.Reader) (stats []db.TaskHostStat) {
+	line, err := Readln(reader)
+	for err == nil {
+		t.log(line)
+		if stat, ok := parseRecapLine(line); ok {
+			stats = append(stats, stat)
+		}
+		line, err = Readln(reader)
+	}
+
+	if err.Error() != "EOF" {
+		util.LogWarningWithFields(err, log.Fields{"error": "Failed to read task output"})
+	}
+
+	return
+}
+
+// saveHostStats records the stats of hosts and logs the hosts changed by the check.
+func (t *task) saveHostStats(stats []db.TaskHostStat) {
+	for _, stat := range stats {
+		stat.TaskID = t.task.ID
+		if _, err := t.store.CreateTaskHostStat(stat); err != nil {
+			t.log("Failed to save stats of host " + stat.Host + ": " + err.Error())
+		}
+
+		if t.task.AwaitApproval && stat.Changed > 0 {
+			t.log("Host " + stat.Host + " will be changed: " + strconv.Itoa(stat.Changed) + " changes")
+		}
+	}
+}
+
+// getCommitHash returns the commit the repository is checked out at.
+func (t *task) getCommitHash() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD") //nolint: gas
+	cmd.Dir = t.getRepoPath()
+	cmd.Env = t.envVars(util.Config.TmpPath, cmd.Dir, nil)
+
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// checkoutCommit resets the branch to the commit, the next update of the
+// repository fast-forwards it again.
+func (t *task) checkoutCommit(commit string) error {
+	t.log("Checking out commit " + commit)
+
+	cmd := exec.Command("git", "reset", "--hard", commit) //nolint: gas
+	cmd.Dir = t.getRepoPath()
+	cmd.Env = t.envVars(util.Config.TmpPath, cmd.Dir, nil)
+
+	t.logCmd(cmd)
+	return cmd.Run()
+}
+
+// pinCommit checks out the commit of the approved check, other tasks record
+// the commit they run with.
+func (t *task) pinCommit() (err error) {
+	if t.checkTask != nil && t.checkTask.CommitHash != nil {
+		if err = t.checkoutCommit(*t.checkTask.CommitHash); err != nil {
+			return
+		}
+	}
+
+	commit, err := t.getCommitHash()
+	if err != nil {
+		return
+	}
+	t.task.CommitHash = &commit
+
+	return t.store.UpdateTask(t.task)
+}
+
+// loadCheckedRevision replaces the object with the revision the approved check ran with.
+func (t *task) loadCheckedRevision(revisionID *int, object interface{}) error {
+	if revisionID == nil {
+		return nil
+	}
+
+	revision, err := t.store.GetRevision(t.projectID, *revisionID)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(revision.Data), object)
+}
+
+// ApproveTask runs the check awaiting approval for real with the same inputs and commit.
+// Tasks pending approval are queued when they have the approvals required by the template.
+func ApproveTask(w http.ResponseWriter, r *http.Request) {
+	checkTask := context.Get(r, taskTypeID).(db.Task)
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	store := helpers.Store(r)
+
+	if !checkRunAccess(w, r, project.ID, checkTask.TemplateID) {
+		return
+	}
+
+	approvalLock.Lock()
+	defer approvalLock.Unlock()
+
+	// the task in the context may be outdated if the check was approved concurrently
+	checkTask, err := store.GetTask(project.ID, checkTask.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	switch checkTask.Status {
+	case taskAwaitingApprovalStatus:
+		// the author must not sign off the own check
+		if checkTask.UserID != nil && *checkTask.UserID == user.ID {
+			helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Users can not approve their own tasks",
+			})
+			return
+		}
+	case taskPendingApprovalStatus:
+		approveGate(w, r, checkTask, user)
+		return
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Task is not awaiting approval",
+		})
+		return
+	}
+
+	checkTask.Status = taskSuccessStatus
+	if err = store.UpdateTask(checkTask); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createApprovalEvent(store, checkTask, &user.ID, "approved")
+
+	newTask, err := AddTaskToPool(store, db.Task{
+		TemplateID:  checkTask.TemplateID,
+		Debug:       checkTask.Debug,
+		Playbook:    checkTask.Playbook,
+		Environment: checkTask.Environment,
+		Arguments:   checkTask.Arguments,
+		CheckTaskID: &checkTask.ID,
+	}, &user.ID, project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newTask)
+}
+
+// RejectTask closes the check awaiting approval or the task pending approval,
+// the playbook is not run for real.
+func RejectTask(w http.ResponseWriter, r *http.Request) {
+	checkTask := context.Get(r, taskTypeID).(db.Task)
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+	store := helpers.Store(r)
+
+	if !checkRunAccess(w, r, project.ID, checkTask.TemplateID) {
+		return
+	}
+
+	approvalLock.Lock()
+	defer approvalLock.Unlock()
+
+	checkTask, err := store.GetTask(project.ID, checkTask.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	switch checkTask.Status {
+	case taskAwaitingApprovalStatus:
+	case taskPendingApprovalStatus:
+		rejectGate(w, r, checkTask, user)
+		return
+	default:
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Task is not awaiting approval",
+		})
+		return
+	}
+
+	checkTask.Status = taskRejectedStatus
+	if err = store.UpdateTask(checkTask); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createApprovalEvent(store, checkTask, &user.ID, "rejected")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func createApprovalEvent(store db.Store, checkTask db.Task, userID *int, action string) {
+	objType := taskTypeID
+	desc := "Task ID " + strconv.Itoa(checkTask.ID) + " " + action
+
+	_, err := store.CreateEvent(db.Event{
+		UserID:      userID,
+		ProjectID:   &checkTask.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &checkTask.ID,
+		Description: &desc,
+	})
+
+	if err != nil {
+		log.Error(err)
+	}
+}
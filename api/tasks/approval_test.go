@@ -0,0 +1,24 @@
+package tasks
+
+import "testing"
+
+func TestParseRecapLine(t *testing.T) {
+	stat, ok := parseRecapLine("web1.example.com           : ok=5    changed=2    unreachable=0    failed=1    skipped=3    rescued=0    ignored=4   ")
+	if !ok {
+		t.Fatal("recap line must be parsed")
+	}
+
+	if stat.Host != "web1.example.com" || stat.Ok != 5 || stat.Changed != 2 || stat.Failed != 1 || stat.Skipped != 3 || stat.Ignored != 4 {
+		t.Fatalf("unexpected stats %+v", stat)
+	}
+
+	// older versions of ansible do not print skipped, rescued and ignored counts
+	stat, ok = parseRecapLine("localhost : ok=1 changed=1 unreachable=1 failed=0")
+	if !ok || stat.Unreachable != 1 || stat.Skipped != 0 {
+		t.Fatalf("unexpected stats %+v", stat)
+	}
+
+	if _, ok = parseRecapLine("TASK [Gathering Facts] *********************************************************"); ok {
+		t.Fatal("only recap lines must be parsed")
+	}
+}
@@ -0,0 +1,281 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/api/sockets"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+const approvalEmailTemplate = `Subject: Task '{{ .Alias }}' is pending approval
+
+Task {{ .TaskID }} with template '{{ .Alias }}' needs your approval to run.
+Task: <a href='{{ .TaskURL }}'>{{ .TaskURL }}</a>`
+
+// approvalExpiryInterval is how often tasks pending approval are checked for expiry.
+const approvalExpiryInterval = time.Minute
+
+// requiresApproval returns true if the task of the template must be approved before it runs.
+// Validation runs change nothing and approved checks have been approved already.
+func requiresApproval(template db.Template, taskObj db.Task) bool {
+	return template.RequiredApprovals > 0 && !taskObj.ValidateOnly && taskObj.CheckTaskID == nil
+}
+
+// getApprovers returns members of the project who can approve tasks of the template.
+func getApprovers(store db.Store, tpl db.Template) (approvers []int, err error) {
+	members, err := getProjectMembers(store, tpl.ProjectID)
+	if err != nil {
+		return
+	}
+
+	permissions, err := store.GetTemplatePermissions(tpl.ProjectID, tpl.ID)
+	if err != nil {
+		return
+	}
+
+	for _, member := range members {
+		access := db.ResolveTemplateAccess(member.role, member.userID, member.teamIDs, permissions)
+		if member.role.IsAtLeast(tpl.ApproverRole) && access.Allows(db.TemplateRun) {
+			approvers = append(approvers, member.userID)
+		}
+	}
+
+	return
+}
+
+// notifyApprovers tells approvers of the template that the task is waiting for them.
+func notifyApprovers(store db.Store, tpl db.Template, taskObj db.Task) {
+	approvers, err := getApprovers(store, tpl)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	msg, err := json.Marshal(&map[string]interface{}{
+		"type":       "approval",
+		"status":     taskObj.Status,
+		"task_id":    taskObj.ID,
+		"project_id": taskObj.ProjectID,
+	})
+	util.LogPanic(err)
+
+	var mailBuffer bytes.Buffer
+
+	if util.Config.EmailAlert {
+		mailTpl := template.Must(template.New("approval mail body template").Parse(approvalEmailTemplate))
+		err = mailTpl.Execute(&mailBuffer, Alert{
+			TaskID:  strconv.Itoa(taskObj.ID),
+			Alias:   tpl.Alias,
+			TaskURL: util.Config.WebHost + "/project/" + strconv.Itoa(tpl.ProjectID) + "/templates/" + strconv.Itoa(tpl.ID) + "?t=" + strconv.Itoa(taskObj.ID),
+		})
+		if err != nil {
+			log.Error(err)
+			return
+		}
+	}
+
+	mailHost := util.Config.EmailHost + ":" + util.Config.EmailPort
+
+	for _, userID := range approvers {
+		// users do not approve their own tasks
+		if taskObj.UserID != nil && *taskObj.UserID == userID {
+			continue
+		}
+
+		sockets.Message(userID, msg)
+
+		if !util.Config.EmailAlert {
+			continue
+		}
+
+		user, err := store.GetUser(userID)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		if err = util.SendMail(mailHost, util.Config.EmailSender, user.Email, mailBuffer); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// approveGate records the approval of the task pending approval and queues the task
+// when it has the required number of approvals.
+func approveGate(w http.ResponseWriter, r *http.Request, pendingTask db.Task, user *db.User) {
+	store := helpers.Store(r)
+
+	tpl, approvals, ok := checkApprover(w, r, pendingTask, user)
+	if !ok {
+		return
+	}
+
+	// the approval of the author is not a sign-off
+	if pendingTask.UserID != nil && *pendingTask.UserID == user.ID {
+		helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Users can not approve their own tasks",
+		})
+		return
+	}
+
+	for _, approval := range approvals {
+		if approval.UserID == user.ID {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Task is already approved by the user",
+			})
+			return
+		}
+	}
+
+	_, err := store.CreateTaskApproval(db.TaskApproval{
+		TaskID:   pendingTask.ID,
+		UserID:   user.ID,
+		Approved: true,
+		Created:  time.Now(),
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	approved := len(approvals) + 1
+	createApprovalEvent(store, pendingTask, &user.ID,
+		"approved ("+strconv.Itoa(approved)+" of "+strconv.Itoa(tpl.RequiredApprovals)+")")
+
+	if approved >= tpl.RequiredApprovals {
+		pendingTask.Status = taskWaitingStatus
+		if err = store.UpdateTask(pendingTask); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		if err = registerTask(store, pendingTask); err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, pendingTask)
+}
+
+// rejectGate records the rejection of the task pending approval, one rejection closes the task.
+func rejectGate(w http.ResponseWriter, r *http.Request, pendingTask db.Task, user *db.User) {
+	store := helpers.Store(r)
+
+	// authors can withdraw their tasks
+	if pendingTask.UserID == nil || *pendingTask.UserID != user.ID {
+		if _, _, ok := checkApprover(w, r, pendingTask, user); !ok {
+			return
+		}
+	}
+
+	_, err := store.CreateTaskApproval(db.TaskApproval{
+		TaskID:   pendingTask.ID,
+		UserID:   user.ID,
+		Approved: false,
+		Created:  time.Now(),
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	now := time.Now()
+	pendingTask.Status = taskRejectedStatus
+	pendingTask.End = &now
+	if err = store.UpdateTask(pendingTask); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	createApprovalEvent(store, pendingTask, &user.ID, "rejected")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkApprover writes the error and returns false if the user can not approve the task.
+func checkApprover(w http.ResponseWriter, r *http.Request, pendingTask db.Task, user *db.User) (tpl db.Template, approvals []db.TaskApproval, ok bool) {
+	store := helpers.Store(r)
+
+	tpl, err := store.GetTemplate(pendingTask.ProjectID, pendingTask.TemplateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	role, err := db.GetProjectMemberRole(store, pendingTask.ProjectID, user.ID)
+	if err == db.ErrNotFound || (err == nil && !role.IsAtLeast(tpl.ApproverRole)) {
+		helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Tasks of the template are approved by " + string(tpl.ApproverRole) + " members",
+		})
+		return
+	} else if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if approvals, err = store.GetTaskApprovals(pendingTask.ProjectID, pendingTask.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	ok = true
+	return
+}
+
+// expireApprovals cancels tasks which have not been approved within the expiry time of their templates
+// and tasks whose templates have been removed.
+func expireApprovals(store db.Store, now time.Time) {
+	approvalLock.Lock()
+	defer approvalLock.Unlock()
+
+	pendingTasks, err := store.GetTasksByStatus(taskPendingApprovalStatus)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, pendingTask := range pendingTasks {
+		reason := "cancelled, approval expired"
+
+		tpl, err := store.GetTemplate(pendingTask.ProjectID, pendingTask.TemplateID)
+
+		switch {
+		case err == db.ErrNotFound:
+			// the task of the removed template can not be approved anymore
+			reason = "cancelled, template removed"
+		case err != nil:
+			log.Error(err)
+			continue
+		case tpl.ApprovalExpiry <= 0 || now.Before(pendingTask.Created.Add(time.Duration(tpl.ApprovalExpiry)*time.Minute)):
+			continue
+		}
+
+		pendingTask.Status = taskCancelledStatus
+		pendingTask.End = &now
+		if err = store.UpdateTask(pendingTask); err != nil {
+			log.Error(err)
+			continue
+		}
+
+		createApprovalEvent(store, pendingTask, nil, reason)
+	}
+}
+
+// StartApprovalExpiry periodically cancels tasks whose approval has expired.
+func StartApprovalExpiry(store db.Store) {
+	ticker := time.NewTicker(approvalExpiryInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expireApprovals(store, now)
+	}
+}
@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+)
+
+func TestExpireApprovals(t *testing.T) {
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_tasks_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{
+		ProjectID:         project.ID,
+		Alias:             "Deploy",
+		RequiredApprovals: 1,
+		ApproverRole:      db.ProjectManager,
+		ApprovalExpiry:    30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pendingTask, err := store.CreateTask(db.Task{TemplateID: template.ID, ProjectID: project.ID, Status: taskPendingApprovalStatus})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expireApprovals(store, pendingTask.Created.Add(10*time.Minute))
+
+	if pendingTask, err = store.GetTask(project.ID, pendingTask.ID); err != nil {
+		t.Fatal(err)
+	}
+	if pendingTask.Status != taskPendingApprovalStatus {
+		t.Fatal("task must wait until the approval expires")
+	}
+
+	expireApprovals(store, pendingTask.Created.Add(time.Hour))
+
+	if pendingTask, err = store.GetTask(project.ID, pendingTask.ID); err != nil {
+		t.Fatal(err)
+	}
+	if pendingTask.Status != taskCancelledStatus || pendingTask.End == nil {
+		t.Fatalf("expired task must be cancelled, got %s", pendingTask.Status)
+	}
+
+	// tasks of removed templates are cancelled without waiting for the expiry
+	orphanTask, err := store.CreateTask(db.Task{TemplateID: template.ID + 100, ProjectID: project.ID, Status: taskPendingApprovalStatus, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expireApprovals(store, orphanTask.Created)
+
+	if orphanTask, err = store.GetTask(project.ID, orphanTask.ID); err != nil {
+		t.Fatal(err)
+	}
+	if orphanTask.Status != taskCancelledStatus {
+		t.Fatalf("task of the removed template must be cancelled, got %s", orphanTask.Status)
+	}
+}
@@ -13,33 +13,85 @@ import (
 )
 
 func AddTaskToPool(d db.Store, taskObj db.Task, userID *int, projectID int) (db.Task, error) {
+	template, err := d.GetTemplate(projectID, taskObj.TemplateID)
+	if err != nil {
+		return db.Task{}, err
+	}
+
+	// launches of check-first templates preview changes and wait for approval
+	if template.CheckFirst && !taskObj.DryRun && !taskObj.ValidateOnly && taskObj.CheckTaskID == nil {
+		taskObj.DryRun = true
+		taskObj.Diff = true
+		taskObj.AwaitApproval = true
+	}
+
 	taskObj.Created = time.Now()
 	taskObj.Status = taskWaitingStatus
 	taskObj.UserID = userID
 	taskObj.ProjectID = projectID
 
+	if requiresApproval(template, taskObj) {
+		taskObj.Status = taskPendingApprovalStatus
+	}
+
 	newTask, err := d.CreateTask(taskObj)
 	if err != nil {
 		return db.Task{}, err
 	}
 
+	// tasks pending approval are queued when they are approved
+	if newTask.Status == taskPendingApprovalStatus {
+		createApprovalEvent(d, newTask, userID, "is pending approval")
+		// mails are sent in the background not to delay the response
+		go notifyApprovers(d, template, newTask)
+		return newTask, nil
+	}
+
+	return newTask, registerTask(d, newTask)
+}
+
+// registerTask adds the task to the queue of the task pool.
+func registerTask(d db.Store, newTask db.Task) error {
 	pool.register <- &task{
 		store:     d,
 		task:      newTask,
-		projectID: projectID,
+		projectID: newTask.ProjectID,
 	}
 
 	objType := taskTypeID
 	desc := "Task ID " + strconv.Itoa(newTask.ID) + " queued for running"
-	_, err = d.CreateEvent(db.Event{
-		UserID:      userID,
-		ProjectID:   &projectID,
+	_, err := d.CreateEvent(db.Event{
+		UserID:      newTask.UserID,
+		ProjectID:   &newTask.ProjectID,
 		ObjectType:  &objType,
 		ObjectID:    &newTask.ID,
 		Description: &desc,
 	})
 
-	return newTask, err
+	return err
+}
+
+// checkRunAccess writes the error and returns false if the user or the API token can not run the template
+func checkRunAccess(w http.ResponseWriter, r *http.Request, projectID int, templateID int) bool {
+	access, err := helpers.GetTemplateAccess(r, projectID, templateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return false
+	}
+
+	if !access.Allows(db.TemplateRun) {
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+
+	if token, ok := context.GetOk(r, "token"); ok {
+		if !token.(db.APIToken).CanRunTemplate(projectID, templateID) {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+	}
+
+	return true
 }
 
 // AddTask inserts a task into the database and returns a header or returns error
@@ -53,6 +105,15 @@ func AddTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkRunAccess(w, r, project.ID, taskObj.TemplateID) {
+		return
+	}
+
+	// approved runs are created only by approving checks
+	taskObj.AwaitApproval = false
+	taskObj.CheckTaskID = nil
+	taskObj.CommitHash = nil
+
 	newTask, err := AddTaskToPool(helpers.Store(r), taskObj, &user.ID, project.ID)
 
 	//taskObj.Created = time.Now()
@@ -92,6 +153,35 @@ func AddTask(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusCreated, newTask)
 }
 
+// ValidateTemplate queues the task which runs only the pre-flight validation of the template.
+// Findings of the validation are available when the task is finished.
+func ValidateTemplate(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+	user := context.Get(r, "user").(*db.User)
+
+	templateID, err := helpers.GetIntParam("template_id", w, r)
+	if err != nil {
+		return
+	}
+
+	if !checkRunAccess(w, r, project.ID, templateID) {
+		return
+	}
+
+	if _, err = helpers.Store(r).GetTemplate(project.ID, templateID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	newTask, err := AddTaskToPool(helpers.Store(r), db.Task{TemplateID: templateID, ValidateOnly: true}, &user.ID, project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newTask)
+}
+
 // GetTasksList returns a list of tasks for the current project in desc order to limit or error
 func GetTasksList(w http.ResponseWriter, r *http.Request, limit uint64) {
 	project := context.Get(r, "project").(db.Project)
@@ -116,7 +206,20 @@ func GetTasksList(w http.ResponseWriter, r *http.Request, limit uint64) {
 		return
 	}
 
-	helpers.WriteJSON(w, http.StatusOK, tasks)
+	getAccess, err := helpers.GetTemplateAccessResolver(r, project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	res := make([]db.TaskWithTpl, 0, len(tasks))
+	for _, t := range tasks {
+		if getAccess(t.TemplateID) != db.TemplateNoAccess {
+			res = append(res, t)
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
 }
 
 // GetAllTasks returns all tasks for the current project
@@ -154,6 +257,23 @@ func GetTaskMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		access, err := helpers.GetTemplateAccess(r, project.ID, task.TemplateID)
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		// tasks of templates which are not granted to the user are hidden
+		if access == db.TemplateNoAccess {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method != "GET" && r.Method != "HEAD" && !access.Allows(db.TemplateRun) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		context.Set(r, taskTypeID, task)
 		next.ServeHTTP(w, r)
 	})
@@ -176,6 +296,60 @@ func GetTaskOutput(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, output)
 }
 
+// GetTaskFindings returns problems found by the pre-flight validation of the task
+func GetTaskFindings(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, taskTypeID).(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	findings, err := helpers.Store(r).GetTaskFindings(project.ID, task.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if findings == nil {
+		findings = []db.TaskFinding{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, findings)
+}
+
+// GetTaskHostStats returns the play recap of hosts of the task
+func GetTaskHostStats(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, taskTypeID).(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	stats, err := helpers.Store(r).GetTaskHostStats(project.ID, task.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if stats == nil {
+		stats = []db.TaskHostStat{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, stats)
+}
+
+// GetTaskApprovals returns approvals and rejections of the task
+func GetTaskApprovals(w http.ResponseWriter, r *http.Request) {
+	task := context.Get(r, taskTypeID).(db.Task)
+	project := context.Get(r, "project").(db.Project)
+
+	approvals, err := helpers.Store(r).GetTaskApprovals(project.ID, task.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if approvals == nil {
+		approvals = []db.TaskApproval{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, approvals)
+}
+
 func StopTask(w http.ResponseWriter, r *http.Request) {
 	targetTask := context.Get(r, "task").(db.Task)
 	project := context.Get(r, "project").(db.Project)
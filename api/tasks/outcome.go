@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"strconv"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+// outcomeHistoryLength limits number of previous tasks loaded to classify outcome of a task
+const outcomeHistoryLength = 100
+
+// classifyOutcome returns the outcome class of a finished task and the number of
+// failures in a row including this task. prevStatuses are statuses of the earlier
+// tasks of the same template, most recent first. Stopped tasks neither break nor
+// extend a failure streak.
+func classifyOutcome(status string, prevStatuses []string) (outcome string, failureStreak int) {
+	prevFailures := 0
+	prevFinished := ""
+
+	for _, prevStatus := range prevStatuses {
+		if prevStatus == taskFailStatus {
+			prevFailures++
+			prevFinished = prevStatus
+			continue
+		}
+
+		if prevStatus == taskSuccessStatus {
+			if prevFinished == "" {
+				prevFinished = prevStatus
+			}
+			break
+		}
+	}
+
+	switch status {
+	case taskFailStatus:
+		failureStreak = prevFailures + 1
+		if prevFailures > 0 {
+			outcome = db.TaskOutcomeRepeatedFailure
+		} else {
+			outcome = db.TaskOutcomeNewFailure
+		}
+	case taskSuccessStatus:
+		if prevFinished == taskFailStatus {
+			outcome = db.TaskOutcomeRecovered
+		} else {
+			outcome = db.TaskOutcomeSuccess
+		}
+	case taskStoppedStatus:
+		outcome = db.TaskOutcomeStopped
+	}
+
+	return
+}
+
+// describeOutcome returns the human readable result used in alert messages.
+func describeOutcome(outcome string, failureStreak int) string {
+	switch outcome {
+	case db.TaskOutcomeNewFailure:
+		return "has failed"
+	case db.TaskOutcomeRepeatedFailure:
+		return "is still failing (" + strconv.Itoa(failureStreak) + " failures in a row)"
+	case db.TaskOutcomeSuccess:
+		return "has succeeded"
+	case db.TaskOutcomeRecovered:
+		return "has recovered"
+	case db.TaskOutcomeStopped:
+		return "was stopped"
+	default:
+		return "has finished"
+	}
+}
+
+// getPreviousStatuses returns statuses of the tasks of the same template which
+// were created before the current one, most recent first. Validation-only tasks
+// do not run the playbook and are skipped.
+func (t *task) getPreviousStatuses() ([]string, error) {
+	tasks, err := t.store.GetTemplateTasks(t.projectID, t.task.TemplateID, db.RetrieveQueryParams{
+		Count: outcomeHistoryLength,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]string, 0)
+
+	for _, tsk := range tasks {
+		if tsk.ID == t.task.ID || tsk.ValidateOnly || !tsk.Created.Before(t.task.Created) {
+			continue
+		}
+		statuses = append(statuses, tsk.Status)
+	}
+
+	return statuses, nil
+}
+
+// getOutcome classifies the finished task for alerting.
+// It returns empty outcome if the task is not finished.
+func (t *task) getOutcome() (outcome string, failureStreak int) {
+	switch t.task.Status {
+	case taskSuccessStatus, taskFailStatus, taskStoppedStatus:
+	default:
+		return
+	}
+
+	prevStatuses, err := t.getPreviousStatuses()
+	if err != nil {
+		t.log("Can't load previous tasks of the template: " + err.Error())
+	}
+
+	return classifyOutcome(t.task.Status, prevStatuses)
+}
@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+)
+
+func TestClassifyOutcome(t *testing.T) {
+	cases := []struct {
+		status  string
+		prev    []string
+		outcome string
+		streak  int
+	}{
+		{taskFailStatus, nil, db.TaskOutcomeNewFailure, 1},
+		{taskFailStatus, []string{taskSuccessStatus, taskFailStatus}, db.TaskOutcomeNewFailure, 1},
+		{taskFailStatus, []string{taskFailStatus, taskStoppedStatus, taskFailStatus, taskSuccessStatus}, db.TaskOutcomeRepeatedFailure, 3},
+		{taskSuccessStatus, []string{taskFailStatus, taskFailStatus}, db.TaskOutcomeRecovered, 0},
+		{taskSuccessStatus, []string{taskStoppedStatus, taskFailStatus}, db.TaskOutcomeRecovered, 0},
+		{taskSuccessStatus, []string{taskSuccessStatus, taskFailStatus}, db.TaskOutcomeSuccess, 0},
+		{taskSuccessStatus, nil, db.TaskOutcomeSuccess, 0},
+		{taskStoppedStatus, []string{taskFailStatus}, db.TaskOutcomeStopped, 0},
+	}
+
+	for i, c := range cases {
+		outcome, streak := classifyOutcome(c.status, c.prev)
+		if outcome != c.outcome || streak != c.streak {
+			t.Fatalf("case %d: expected %s/%d, got %s/%d", i, c.outcome, c.streak, outcome, streak)
+		}
+	}
+}
+
+func TestGetPreviousStatuses(t *testing.T) {
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_outcome_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Alias: "Deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Now().Add(-time.Hour)
+
+	for _, tsk := range []db.Task{
+		{Status: taskFailStatus},
+		{Status: taskSuccessStatus, ValidateOnly: true},
+	} {
+		created = created.Add(time.Minute)
+		tsk.ProjectID = project.ID
+		tsk.TemplateID = template.ID
+		tsk.Created = created
+
+		if _, err = store.CreateTask(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	current, err := store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: template.ID, Status: taskFailStatus, Created: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tsk := task{store: store, projectID: project.ID, task: current}
+
+	outcome, streak := tsk.getOutcome()
+	if outcome != db.TaskOutcomeRepeatedFailure || streak != 2 {
+		t.Fatalf("validation must not break the failure streak, got %s (%d)", outcome, streak)
+	}
+}
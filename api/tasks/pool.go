@@ -2,7 +2,9 @@ package tasks
 
 import (
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/metrics"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -42,6 +44,12 @@ var pool = taskPool{
 
 var resourceLocker = make(chan *resourceLock)
 
+// runnerHeartbeat is the Unix time (in nanoseconds) of the last
+// tick processed by the task pool.
+var runnerHeartbeat int64
+
+const runnerTickInterval = 5 * time.Second
+
 func (p *taskPool) getTask(id int) (task *task){
 
 	for _, t := range p.queue {
@@ -65,7 +73,8 @@ func (p *taskPool) getTask(id int) (task *task){
 
 //nolint: gocyclo
 func (p *taskPool) run() {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(runnerTickInterval)
+	atomic.StoreInt64(&runnerHeartbeat, time.Now().UnixNano())
 
 	defer func() {
 		close(resourceLocker)
@@ -90,6 +99,7 @@ func (p *taskPool) run() {
 
 				p.running++
 				p.runningTasks[t.task.ID] = t
+				metrics.RunningTasks.Set(float64(p.running))
 				continue
 			}
 
@@ -103,10 +113,13 @@ func (p *taskPool) run() {
 
 			p.running--
 			delete(p.runningTasks, t.task.ID)
+			metrics.RunningTasks.Set(float64(p.running))
 		}
 	}(resourceLocker)
 
 	for {
+		metrics.TaskQueueLength.Set(float64(len(p.queue)))
+
 		select {
 		case record := <-p.logger:
 			_, err := record.task.store.CreateTaskOutput(db.TaskOutput{
@@ -126,6 +139,8 @@ func (p *taskPool) run() {
 			log.Info(msg)
 
 		case <-ticker.C:
+			atomic.StoreInt64(&runnerHeartbeat, time.Now().UnixNano())
+
 			if len(p.queue) == 0 {
 				continue
 			}
@@ -177,6 +192,15 @@ func (p *taskPool) blocks(t *task) bool {
 	}
 }
 
+// IsRunnerAlive reports whether the task pool has processed its ticks recently.
+func IsRunnerAlive() bool {
+	heartbeat := atomic.LoadInt64(&runnerHeartbeat)
+	if heartbeat == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, heartbeat)) < 3*runnerTickInterval
+}
+
 // StartRunner begins the task pool, used as a goroutine
 func StartRunner() {
 	pool.run()
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"github.com/ansible-semaphore/semaphore/api/helpers"
 	"github.com/ansible-semaphore/semaphore/api/sockets"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/metrics"
 	"github.com/ansible-semaphore/semaphore/util"
 	"io/ioutil"
 	"os"
@@ -26,6 +28,14 @@ const (
 	taskSuccessStatus  = "success"
 	taskFailStatus     = "error"
 	taskTypeID         = "task"
+
+	// checks of check-first templates wait for approval before the real run
+	taskAwaitingApprovalStatus = "awaiting_approval"
+	taskRejectedStatus         = "rejected"
+
+	// tasks of templates which require approvals wait outside the queue
+	taskPendingApprovalStatus = "pending_approval"
+	taskCancelledStatus       = "cancelled"
 )
 
 type task struct {
@@ -42,6 +52,9 @@ type task struct {
 	alert       bool
 	prepared    bool
 	process     *os.Process
+
+	// checkTask is the approved check the task applies
+	checkTask *db.Task
 }
 
 func (t *task) getRepoName() string {
@@ -64,6 +77,23 @@ func (t *task) setStatus(status string) {
 	}
 	t.task.Status = status
 	t.updateStatus()
+
+	switch status {
+	case taskSuccessStatus, taskFailStatus, taskStoppedStatus:
+		t.observeMetrics()
+		// validation-only tasks are not runs of the template, their outcome is not alerted
+		if !t.task.ValidateOnly {
+			t.sendAlerts()
+		}
+	}
+}
+
+func (t *task) observeMetrics() {
+	var duration time.Duration
+	if t.task.Start != nil {
+		duration = time.Since(*t.task.Start)
+	}
+	metrics.ObserveTask(t.projectID, t.task.TemplateID, t.task.Status, duration)
 }
 
 func (t *task) updateStatus() {
@@ -89,8 +119,6 @@ func (t *task) updateStatus() {
 
 func (t *task) fail() {
 	t.setStatus(taskFailStatus)
-	t.sendMailAlert()
-	t.sendTelegramAlert()
 }
 
 func (t *task) destroyKeys() {
@@ -147,6 +175,12 @@ func (t *task) prepareRun() {
 		return
 	}
 
+	if err := t.recordRevisions(); err != nil {
+		t.log("Failed to record revisions: " + err.Error())
+		t.fail()
+		return
+	}
+
 	objType := taskTypeID
 	desc := "Task ID " + strconv.Itoa(t.task.ID) + " (" + t.template.Alias + ")" + " is preparing"
 	_, err = t.store.CreateEvent(db.Event{
@@ -176,6 +210,12 @@ func (t *task) prepareRun() {
 		return
 	}
 
+	if err := t.pinCommit(); err != nil {
+		t.log("Failed to check out commit: " + err.Error())
+		t.fail()
+		return
+	}
+
 	if err := t.installInventory(); err != nil {
 		t.log("Failed to install inventory: " + err.Error())
 		t.fail()
@@ -196,6 +236,19 @@ func (t *task) prepareRun() {
 
 	// todo: write environment
 
+	if t.template.Validate || t.task.ValidateOnly {
+		if err := t.validate(); err != nil {
+			t.log("Pre-flight validation failed: " + err.Error())
+			t.fail()
+			return
+		}
+	}
+
+	if t.task.ValidateOnly {
+		t.prepared = true
+		return
+	}
+
 	if stderr, err := t.listPlaybookHosts(); err != nil {
 		t.log("Listing playbook hosts failed: " + err.Error() + "\n" + stderr)
 		t.fail()
@@ -226,9 +279,16 @@ func (t *task) run() {
 	{
 		now := time.Now()
 		t.task.Start = &now
-		t.setStatus(taskRunningStatus)
 	}
 
+	// the validation has passed while preparing the task
+	if t.task.ValidateOnly {
+		t.setStatus(taskSuccessStatus)
+		return
+	}
+
+	t.setStatus(taskRunningStatus)
+
 	objType := taskTypeID
 	desc := "Task ID " + strconv.Itoa(t.task.ID) + " (" + t.template.Alias + ")" + " is running"
 
@@ -259,6 +319,12 @@ func (t *task) run() {
 		return
 	}
 
+	if t.task.AwaitApproval {
+		t.log("Check finished, the task is awaiting approval")
+		t.setStatus(taskAwaitingApprovalStatus)
+		return
+	}
+
 	t.setStatus(taskSuccessStatus)
 }
 
@@ -286,6 +352,24 @@ func (t *task) populateDetails() error {
 		return t.prepareError(err, "Template not found!")
 	}
 
+	// the approved run uses the inputs of the check
+	if t.task.CheckTaskID != nil {
+		var checkTask db.Task
+		checkTask, err = t.store.GetTask(t.projectID, *t.task.CheckTaskID)
+		if err != nil {
+			return t.prepareError(err, "Check task not found!")
+		}
+		t.checkTask = &checkTask
+
+		if err = t.loadCheckedRevision(checkTask.TemplateRevisionID, &t.template); err != nil {
+			return err
+		}
+
+		if err = db.FillTemplate(t.store, &t.template); err != nil {
+			return err
+		}
+	}
+
 	// get project alert setting
 	project, err := t.store.GetProject(t.template.ProjectID)
 	if err != nil {
@@ -295,15 +379,22 @@ func (t *task) populateDetails() error {
 	t.alert = project.Alert
 	t.alertChat = project.AlertChat
 
-	// get project users
-	users, err := t.store.GetProjectUsers(t.template.ProjectID, db.RetrieveQueryParams{})
+	members, err := getProjectMembers(t.store, t.template.ProjectID)
 	if err != nil {
 		return t.prepareError(err, "Users not found!")
 	}
 
+	permissions, err := t.store.GetTemplatePermissions(t.template.ProjectID, t.template.ID)
+	if err != nil {
+		return t.prepareError(err, "Template permissions not found!")
+	}
+
+	// output and status of the task are sent only to members who can see the template
 	t.users = []int{}
-	for _, user := range users {
-		t.users = append(t.users, user.ID)
+	for _, member := range members {
+		if db.ResolveTemplateAccess(member.role, member.userID, member.teamIDs, permissions) != db.TemplateNoAccess {
+			t.users = append(t.users, member.userID)
+		}
 	}
 
 	// get inventory
@@ -312,6 +403,16 @@ func (t *task) populateDetails() error {
 		return t.prepareError(err, "Template Inventory not found!")
 	}
 
+	if t.checkTask != nil {
+		if err = t.loadCheckedRevision(t.checkTask.InventoryRevisionID, &t.inventory); err != nil {
+			return err
+		}
+
+		if err = db.FillInventory(t.store, &t.inventory); err != nil {
+			return err
+		}
+	}
+
 	// get repository
 	t.repository, err = t.store.GetRepository(t.template.ProjectID, t.template.RepositoryID)
 
@@ -334,9 +435,98 @@ func (t *task) populateDetails() error {
 		t.environment.JSON = t.task.Environment
 	}
 
+	if t.checkTask != nil && len(t.task.Environment) == 0 {
+		if err = t.loadCheckedRevision(t.checkTask.EnvironmentRevisionID, &t.environment); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// projectMember is a member of the project directly or through teams.
+type projectMember struct {
+	userID  int
+	role    db.ProjectUserRole
+	teamIDs []int
+}
+
+// getProjectMembers returns members of the project with the most privileged
+// of their own role and the roles of their teams.
+func getProjectMembers(store db.Store, projectID int) (members []projectMember, err error) {
+	users, err := store.GetProjectUsers(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	index := make(map[int]int)
+	for _, user := range users {
+		index[user.ID] = len(members)
+		members = append(members, projectMember{userID: user.ID, role: user.Role})
+	}
+
+	// members of teams attached to the project
+	teams, err := store.GetProjectTeams(projectID)
+	if err != nil {
+		return
+	}
+
+	for _, team := range teams {
+		var teamUsers []db.User
+		if teamUsers, err = store.GetTeamUsers(team.ID); err != nil {
+			return
+		}
+
+		for _, user := range teamUsers {
+			i, ok := index[user.ID]
+			if !ok {
+				i = len(members)
+				index[user.ID] = i
+				members = append(members, projectMember{userID: user.ID, role: team.Role})
+			}
+			members[i].role = db.MaxProjectUserRole(members[i].role, team.Role)
+			members[i].teamIDs = append(members[i].teamIDs, team.ID)
+		}
+	}
+
+	return
+}
+
+// recordRevisions saves the revisions of the template, the inventory and
+// the environment the task runs with.
+func (t *task) recordRevisions() error {
+	// the approved run uses the revisions of the check
+	if t.checkTask != nil {
+		t.task.TemplateRevisionID = t.checkTask.TemplateRevisionID
+		t.task.InventoryRevisionID = t.checkTask.InventoryRevisionID
+		t.task.EnvironmentRevisionID = t.checkTask.EnvironmentRevisionID
+		return t.store.UpdateTask(t.task)
+	}
+
+	revision, err := db.CreateObjectRevision(t.store, t.projectID, db.RevisionTemplate, t.template.ID, t.template, nil)
+	if err != nil {
+		return err
+	}
+	t.task.TemplateRevisionID = &revision.ID
+
+	revision, err = db.CreateObjectRevision(t.store, t.projectID, db.RevisionInventory, t.inventory.ID, t.inventory, nil)
+	if err != nil {
+		return err
+	}
+	t.task.InventoryRevisionID = &revision.ID
+
+	// the environment of the task overrides the environment of the template
+	if len(t.task.Environment) == 0 && t.template.EnvironmentID != nil {
+		revision, err = db.CreateObjectRevision(t.store, t.projectID, db.RevisionEnvironment, t.environment.ID, t.environment, nil)
+		if err != nil {
+			return err
+		}
+		t.task.EnvironmentRevisionID = &revision.ID
+	}
+
+	return t.store.UpdateTask(t.task)
+}
+
 func (t *task) destroyKey(key db.AccessKey) error {
 	path := key.GetPath()
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -488,13 +678,19 @@ func (t *task) runPlaybook() (err error) {
 	cmd.Dir = t.getRepoPath()
 	cmd.Env = t.envVars(util.Config.TmpPath, cmd.Dir, nil)
 
-	t.logCmd(cmd)
+	stderr, _ := cmd.StderrPipe()
+	stdout, _ := cmd.StdoutPipe()
 	cmd.Stdin = strings.NewReader("")
 	err = cmd.Start()
 	if err != nil {
 		return
 	}
 	t.process = cmd.Process
+
+	go t.logPipe(bufio.NewReader(stderr))
+	// the output must be read before waiting for the command
+	t.saveHostStats(t.logRecap(bufio.NewReader(stdout)))
+
 	err = cmd.Wait()
 	return
 }
@@ -533,12 +729,17 @@ func (t *task) getExtraVars() (string, error) {
 	return string(ev), nil
 }
 
+// getPlaybookName returns the playbook of the task, it overrides the playbook of the template.
+func (t *task) getPlaybookName() string {
+	if len(t.task.Playbook) > 0 {
+		return t.task.Playbook
+	}
+	return t.template.Playbook
+}
+
 //nolint: gocyclo
 func (t *task) getPlaybookArgs() ([]string, error) {
-	playbookName := t.task.Playbook
-	if len(playbookName) == 0 {
-		playbookName = t.template.Playbook
-	}
+	playbookName := t.getPlaybookName()
 
 	var inventory string
 	switch t.inventory.Type {
@@ -564,6 +765,10 @@ func (t *task) getPlaybookArgs() ([]string, error) {
 		args = append(args, "--check")
 	}
 
+	if t.task.Diff {
+		args = append(args, "--diff")
+	}
+
 	if t.template.VaultPassID != nil {
 		args = append(args, "--vault-password-file", t.template.VaultPass.GetPath())
 	}
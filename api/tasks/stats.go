@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+const (
+	defaultStatsWindow = 30 * 24 * time.Hour
+	maxStatsWindow     = 366 * 24 * time.Hour
+	busiestUsersCount  = 5
+)
+
+// parseStatsTime parses the time given as a date (2006-01-02) or in RFC 3339 format.
+func parseStatsTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// getStatsWindow reads the window from the "from" and "to" query parameters.
+// By default it is the last 30 days.
+func getStatsWindow(r *http.Request) (from time.Time, to time.Time, err error) {
+	to = time.Now()
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = parseStatsTime(value); err != nil {
+			return
+		}
+	}
+
+	from = to.Add(-defaultStatsWindow)
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = parseStatsTime(value); err != nil {
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		err = errors.New("from must be before to")
+		return
+	}
+
+	if to.Sub(from) > maxStatsWindow {
+		err = errors.New("time window must not exceed 366 days")
+	}
+
+	return
+}
+
+// GetTaskStats returns statistics of tasks of the current project or template
+func GetTaskStats(w http.ResponseWriter, r *http.Request) {
+	project := context.Get(r, "project").(db.Project)
+
+	from, to, err := getStatsWindow(r)
+	if err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter := db.TaskStatsFilter{From: from, To: to}
+
+	if tpl := context.Get(r, "template"); tpl != nil {
+		templateID := tpl.(db.Template).ID
+		filter.TemplateID = &templateID
+	}
+
+	store := helpers.Store(r)
+
+	records, err := store.GetTaskStatRecords(project.ID, filter)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	getAccess, err := helpers.GetTemplateAccessResolver(r, project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	// tasks of templates which are not granted to the user are not counted
+	visible := records[:0]
+	for _, record := range records {
+		if getAccess(record.TemplateID) != db.TemplateNoAccess {
+			visible = append(visible, record)
+		}
+	}
+	records = visible
+
+	stats := db.CalculateTaskStats(records, from, to, busiestUsersCount)
+
+	for i := range stats.BusiestUsers {
+		user, err := store.GetUser(stats.BusiestUsers[i].UserID)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		stats.BusiestUsers[i].Name = user.Name
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,174 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+// lintIssue is an issue of the codeclimate output of ansible-lint.
+type lintIssue struct {
+	CheckName   string `json:"check_name"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+		Positions struct {
+			Begin struct {
+				Line int `json:"line"`
+			} `json:"begin"`
+		} `json:"positions"`
+	} `json:"location"`
+}
+
+// parseLintFindings converts the codeclimate output of ansible-lint to findings.
+func parseLintFindings(output []byte) ([]db.TaskFinding, error) {
+	var issues []lintIssue
+
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(output, &issues); err != nil {
+		return nil, err
+	}
+
+	findings := make([]db.TaskFinding, 0, len(issues))
+
+	for _, issue := range issues {
+		severity := db.FindingSeverity(issue.Severity)
+		if !severity.IsValid() {
+			severity = db.FindingMajor
+		}
+
+		line := issue.Location.Lines.Begin
+		if line == 0 {
+			line = issue.Location.Positions.Begin.Line
+		}
+
+		findings = append(findings, db.TaskFinding{
+			Tool:     db.FindingToolLint,
+			Rule:     issue.CheckName,
+			Severity: severity,
+			Message:  issue.Description,
+			Path:     issue.Location.Path,
+			Line:     line,
+		})
+	}
+
+	return findings, nil
+}
+
+func (t *task) syntaxCheck() ([]db.TaskFinding, error) {
+	args, err := t.getPlaybookArgs()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--syntax-check")
+
+	cmd := exec.Command("ansible-playbook", args...) //nolint: gas
+	cmd.Dir = t.getRepoPath()
+	cmd.Env = t.envVars(util.Config.TmpPath, cmd.Dir, nil)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+
+	if _, ok := err.(*exec.ExitError); !ok && err != nil {
+		return nil, err
+	}
+
+	t.log(output.String())
+
+	if err != nil {
+		return []db.TaskFinding{{
+			Tool:     db.FindingToolSyntaxCheck,
+			Severity: db.FindingBlocker,
+			Message:  strings.TrimSpace(output.String()),
+			Path:     t.getPlaybookName(),
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+func (t *task) lint() ([]db.TaskFinding, error) {
+	cmd := exec.Command("ansible-lint", "--nocolor", "-q", "-f", "codeclimate", "--profile", t.template.LintProfile, t.getPlaybookName()) //nolint: gas
+	cmd.Dir = t.getRepoPath()
+	cmd.Env = t.envVars(util.Config.TmpPath, cmd.Dir, nil)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// ansible-lint exits with an error if it finds violations
+	err := cmd.Run()
+
+	if _, ok := err.(*exec.ExitError); !ok && err != nil {
+		return nil, err
+	}
+
+	findings, parseErr := parseLintFindings(stdout.Bytes())
+
+	if parseErr != nil || (err != nil && len(findings) == 0) {
+		return nil, errors.New("ansible-lint failed: " + strings.TrimSpace(stderr.String()))
+	}
+
+	return findings, nil
+}
+
+// validate runs the pre-flight validation of the playbook and records its findings.
+// It returns an error if the playbook is broken or lint findings reach the threshold of the template.
+func (t *task) validate() error {
+	t.log("Validating playbook " + t.getPlaybookName())
+
+	findings, err := t.syntaxCheck()
+	if err != nil {
+		return err
+	}
+
+	// ansible-lint reports syntax errors too, so it runs only for valid playbooks
+	if len(findings) == 0 && t.template.LintProfile != "" {
+		if findings, err = t.lint(); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+
+	for _, finding := range findings {
+		finding.TaskID = t.task.ID
+
+		if _, err = t.store.CreateTaskFinding(finding); err != nil {
+			return err
+		}
+
+		location := finding.Path
+		if finding.Line > 0 {
+			location += fmt.Sprintf(":%d", finding.Line)
+		}
+		t.log(fmt.Sprintf("[%s] %s %s %s", finding.Severity, location, finding.Rule, finding.Message))
+
+		if finding.Tool == db.FindingToolSyntaxCheck || finding.Severity.Reaches(t.template.LintThreshold) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d findings fail the task", failed, len(findings))
+	}
+
+	t.log(fmt.Sprintf("Validation passed with %d findings", len(findings)))
+	return nil
+}
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func TestParseLintFindings(t *testing.T) {
+	output := []byte(`[
+  {
+    "type": "issue",
+    "check_name": "yaml[truthy]",
+    "severity": "minor",
+    "description": "Truthy value should be one of [false, true]",
+    "location": {"path": "site.yml", "lines": {"begin": 4}}
+  },
+  {
+    "type": "issue",
+    "check_name": "no-changed-when",
+    "severity": "unknown",
+    "description": "Commands should not change things if nothing needs doing.",
+    "location": {"path": "roles/app/tasks/main.yml", "positions": {"begin": {"line": 12, "column": 3}}}
+  }
+]`)
+
+	findings, err := parseLintFindings(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+
+	if findings[0].Tool != db.FindingToolLint || findings[0].Rule != "yaml[truthy]" ||
+		findings[0].Severity != db.FindingMinor || findings[0].Path != "site.yml" || findings[0].Line != 4 {
+		t.Fatalf("unexpected finding: %+v", findings[0])
+	}
+
+	if findings[1].Severity != db.FindingMajor || findings[1].Line != 12 {
+		t.Fatalf("unknown severity must be major and line must be read from positions: %+v", findings[1])
+	}
+
+	if findings, err = parseLintFindings([]byte("\n")); err != nil || len(findings) != 0 {
+		t.Fatal("empty output must have no findings")
+	}
+
+	if _, err = parseLintFindings([]byte("ERROR: invalid profile")); err == nil {
+		t.Fatal("output which is not codeclimate must be rejected")
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/gorilla/context"
+)
+
+// adminMiddleware allows requests only of system administrators
+func adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		editor := context.Get(r, "user").(*db.User)
+
+		if !editor.Admin {
+			log.Warn(editor.Username + " is not permitted to manage teams")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getTeamMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		teamID, err := helpers.GetIntParam("team_id", w, r)
+
+		if err != nil {
+			return
+		}
+
+		team, err := helpers.Store(r).GetTeam(teamID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		context.Set(r, "team", team)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getTeams(w http.ResponseWriter, r *http.Request) {
+	if team, exists := context.GetOk(r, "team"); exists {
+		helpers.WriteJSON(w, http.StatusOK, team.(db.Team))
+		return
+	}
+
+	teams, err := helpers.Store(r).GetTeams(db.RetrieveQueryParams{
+		SortInverted: r.URL.Query().Get("order") == "desc",
+	})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, teams)
+}
+
+func addTeam(w http.ResponseWriter, r *http.Request) {
+	var team db.Team
+	if !helpers.Bind(w, r, &team) {
+		return
+	}
+
+	if team.Name == "" {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Team name can not be empty",
+		})
+		return
+	}
+
+	newTeam, err := helpers.Store(r).CreateTeam(team)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newTeam)
+}
+
+func updateTeam(w http.ResponseWriter, r *http.Request) {
+	oldTeam := context.Get(r, "team").(db.Team)
+
+	var team db.Team
+	if !helpers.Bind(w, r, &team) {
+		return
+	}
+
+	if team.Name == "" {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Team name can not be empty",
+		})
+		return
+	}
+
+	oldTeam.Name = team.Name
+
+	if err := helpers.Store(r).UpdateTeam(oldTeam); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteTeam(w http.ResponseWriter, r *http.Request) {
+	team := context.Get(r, "team").(db.Team)
+
+	if err := helpers.Store(r).DeleteTeam(team.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getTeamUsers(w http.ResponseWriter, r *http.Request) {
+	team := context.Get(r, "team").(db.Team)
+
+	users, err := helpers.Store(r).GetTeamUsers(team.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if users == nil {
+		users = []db.User{}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, users)
+}
+
+func addTeamUser(w http.ResponseWriter, r *http.Request) {
+	team := context.Get(r, "team").(db.Team)
+
+	var teamUser db.TeamUser
+	if !helpers.Bind(w, r, &teamUser) {
+		return
+	}
+
+	if _, err := helpers.Store(r).GetUser(teamUser.UserID); err != nil {
+		if err == db.ErrNotFound {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "User does not exist",
+			})
+			return
+		}
+		helpers.WriteError(w, err)
+		return
+	}
+
+	members, err := helpers.Store(r).GetTeamUsers(team.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	for _, member := range members {
+		if member.ID == teamUser.UserID {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	teamUser.TeamID = team.ID
+
+	if _, err = helpers.Store(r).CreateTeamUser(teamUser); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteTeamUser(w http.ResponseWriter, r *http.Request) {
+	team := context.Get(r, "team").(db.Team)
+
+	userID, err := helpers.GetIntParam("user_id", w, r)
+	if err != nil {
+		return
+	}
+
+	if err = helpers.Store(r).DeleteTeamUser(team.ID, userID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestTeams(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Alias: "Production", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+
+	admin, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adminClient := &testClient{router: router}
+	adminClient.do("POST", "/api/auth/login", map[string]string{"auth": admin.Username, "password": "password"})
+
+	outsider, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "outsider", Name: "outsider", Email: "outsider@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outsiderClient := &testClient{router: router}
+	outsiderClient.do("POST", "/api/auth/login", map[string]string{"auth": outsider.Username, "password": "password"})
+
+	teamBody := map[string]string{"name": "Developers"}
+
+	if rr := clients[db.ProjectOwner].do("POST", "/api/teams", teamBody); rr.Code != http.StatusForbidden {
+		t.Fatalf("only admins may create teams: %d", rr.Code)
+	}
+
+	rr := adminClient.do("POST", "/api/teams", teamBody)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var team db.Team
+	_ = json.Unmarshal(rr.Body.Bytes(), &team)
+
+	teamURL := "/api/teams/" + strconv.Itoa(team.ID)
+
+	for _, userID := range []int{outsider.ID, userIDs[db.ProjectGuest]} {
+		if rr = adminClient.do("POST", teamURL+"/users", map[string]int{"user_id": userID}); rr.Code != http.StatusNoContent {
+			t.Fatalf("Response code should be 204 %d", rr.Code)
+		}
+	}
+
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+
+	if rr = outsiderClient.do("GET", projectURL+"/keys", nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("team must not give access before it is attached: %d", rr.Code)
+	}
+
+	attach := map[string]interface{}{"team_id": team.ID, "role": db.ProjectManager}
+
+	if rr = clients[db.ProjectManager].do("POST", projectURL+"/teams", attach); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not attach teams: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectOwner].do("POST", projectURL+"/teams", attach); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectOwner].do("POST", projectURL+"/teams", attach); rr.Code != http.StatusConflict {
+		t.Fatalf("team must not be attached twice: %d", rr.Code)
+	}
+
+	key := map[string]interface{}{"name": "key", "type": "none", "project_id": project.ID}
+
+	if rr = outsiderClient.do("POST", projectURL+"/keys", key); rr.Code != http.StatusNoContent {
+		t.Fatalf("team members must get the role of the team: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectGuest].do("POST", projectURL+"/keys", key); rr.Code != http.StatusNoContent {
+		t.Fatalf("role of the team must extend the direct role: %d", rr.Code)
+	}
+
+	grant := map[string]interface{}{"team_id": team.ID, "access": db.TemplateView}
+	templateURL := projectURL + "/templates/" + strconv.Itoa(template.ID)
+
+	if rr = clients[db.ProjectOwner].do("POST", templateURL+"/permissions", grant); rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	if rr = outsiderClient.do("GET", templateURL, nil); rr.Code != http.StatusOK {
+		t.Fatalf("team grant must allow viewing the template: %d", rr.Code)
+	}
+
+	if rr = outsiderClient.do("PUT", templateURL, template); rr.Code != http.StatusForbidden {
+		t.Fatalf("team view grant must not allow editing the template: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectManager].do("GET", templateURL, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("members outside of the team must not see the restricted template: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectOwner].do("DELETE", projectURL+"/teams/"+strconv.Itoa(team.ID), nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = outsiderClient.do("GET", projectURL+"/keys", nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("detached team must not give access: %d", rr.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestTemplatePermissions(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{ProjectID: project.ID, Alias: "Production", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients, userIDs := createProjectMembers(t, store, router, project.ID)
+
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+	templateURL := projectURL + "/templates/" + strconv.Itoa(template.ID)
+	task := map[string]int{"template_id": template.ID}
+
+	countTemplates := func(client *testClient) int {
+		var templates []db.Template
+		_ = json.Unmarshal(client.do("GET", projectURL+"/templates", nil).Body.Bytes(), &templates)
+		return len(templates)
+	}
+
+	grant := map[string]interface{}{"user_id": userIDs[db.ProjectManager], "access": db.TemplateView}
+
+	if rr := clients[db.ProjectManager].do("POST", templateURL+"/permissions", grant); rr.Code != http.StatusForbidden {
+		t.Fatalf("manager must not grant permissions: %d", rr.Code)
+	}
+
+	rr := clients[db.ProjectOwner].do("POST", templateURL+"/permissions", grant)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var permission db.TemplatePermission
+	_ = json.Unmarshal(rr.Body.Bytes(), &permission)
+
+	if countTemplates(clients[db.ProjectGuest]) != 0 {
+		t.Fatal("restricted template must be hidden from members without permissions")
+	}
+
+	if rr = clients[db.ProjectGuest].do("GET", templateURL, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("restricted template must not be found: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectTaskRunner].do("POST", projectURL+"/tasks", task); rr.Code != http.StatusForbidden {
+		t.Fatalf("member without permission must not run the template: %d", rr.Code)
+	}
+
+	if countTemplates(clients[db.ProjectManager]) != 1 {
+		t.Fatal("granted template must be listed")
+	}
+
+	if rr = clients[db.ProjectManager].do("PUT", templateURL, template); rr.Code != http.StatusForbidden {
+		t.Fatalf("view permission must not allow editing the template: %d", rr.Code)
+	}
+
+	if rr = clients[db.ProjectManager].do("POST", projectURL+"/tasks", task); rr.Code != http.StatusForbidden {
+		t.Fatalf("view permission must not allow running the template: %d", rr.Code)
+	}
+
+	if countTemplates(clients[db.ProjectOwner]) != 1 {
+		t.Fatal("owner must not be restricted")
+	}
+
+	rr = clients[db.ProjectOwner].do("DELETE", templateURL+"/permissions/"+strconv.Itoa(permission.ID), nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if countTemplates(clients[db.ProjectGuest]) != 1 {
+		t.Fatal("template without permissions must be available to all members")
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestTemplateValidationSettings(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	project, template := createCloneTestProject(t, store)
+	clients, _ := createProjectMembers(t, store, router, project.ID)
+	manager := clients[db.ProjectManager]
+
+	templateURL := "/api/project/" + strconv.Itoa(project.ID) + "/templates/" + strconv.Itoa(template.ID)
+
+	template.Validate = true
+	template.LintProfile = "production"
+	template.LintThreshold = "fatal"
+
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusBadRequest {
+		t.Fatalf("unknown lint threshold must be rejected: %d", rr.Code)
+	}
+
+	template.LintThreshold = db.FindingMajor
+
+	if rr := manager.do("PUT", templateURL, template); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	updated, err := store.GetTemplate(project.ID, template.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !updated.Validate || updated.LintProfile != "production" || updated.LintThreshold != db.FindingMajor {
+		t.Fatal("validation settings must be saved")
+	}
+
+	if rr := clients[db.ProjectGuest].do("POST", templateURL+"/validate", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("guest must not validate templates: %d", rr.Code)
+	}
+}
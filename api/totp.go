@@ -0,0 +1,301 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/api/helpers"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/context"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer         = "Semaphore"
+	recoveryCodesCount = 10
+)
+
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(b)
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+
+	return codes, nil
+}
+
+func isTotpRequired(user *db.User) bool {
+	return util.Config.TotpRequired && !user.External
+}
+
+// getUserTotp returns the two-factor authentication status of the current user.
+func getUserTotp(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	totp, err := helpers.Store(r).GetUserTotp(user.ID)
+	if err != nil && err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":  err == nil && totp.Confirmed,
+		"required": isTotpRequired(user),
+	})
+}
+
+// startUserTotp generates a new TOTP secret for the current user.
+// It must be confirmed with a valid code to be enabled.
+func startUserTotp(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	if user.External {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Two-factor authentication is available for local users only",
+		})
+		return
+	}
+
+	totp, err := helpers.Store(r).GetUserTotp(user.ID)
+	if err == nil && totp.Confirmed {
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	if err != nil && err != db.ErrNotFound {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	secret, err := util.GenerateTotpSecret()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if _, err = helpers.Store(r).CreateUserTotp(db.UserTotp{
+		UserID: user.ID,
+		Secret: secret,
+	}); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, map[string]string{
+		"secret": secret,
+		"uri":    util.GetTotpURI(totpIssuer, user.Username, secret),
+	})
+}
+
+// confirmUserTotp enables the TOTP of the current user and returns recovery codes,
+// which are not shown again.
+func confirmUserTotp(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	var body struct {
+		Passcode string `json:"passcode" binding:"required"`
+	}
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	totp, err := store.GetUserTotp(user.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if totp.Confirmed {
+		helpers.WriteJSON(w, http.StatusConflict, map[string]string{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	if !totp.UseCode(body.Passcode, time.Now()) {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid passcode",
+		})
+		return
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	totp.Confirmed = true
+	totp.SetRecoveryCodes(codes)
+
+	if err = store.UpdateUserTotp(totp); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if session, ok := context.GetOk(r, "session"); ok && !session.(db.Session).IsVerified() {
+		if err = store.VerifySession(user.ID, session.(db.Session).ID); err != nil {
+			log.Error(err)
+		}
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// deleteUserTotp disables two-factor authentication of the current user
+// unless it is required by the policy. An enabled TOTP is disabled only with
+// a valid code or with a recovery code and the password, so a stolen session
+// is not enough to remove the second factor.
+func deleteUserTotp(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*db.User)
+
+	if isTotpRequired(user) {
+		helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Two-factor authentication is required",
+		})
+		return
+	}
+
+	store := helpers.Store(r)
+
+	totp, err := store.GetUserTotp(user.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if totp.Confirmed {
+		var body struct {
+			Passcode     string `json:"passcode"`
+			RecoveryCode string `json:"recovery_code"`
+			Password     string `json:"password"`
+		}
+		if !helpers.Bind(w, r, &body) {
+			return
+		}
+
+		// failed codes are throttled together with failed passwords of the account
+		if !checkLoginThrottle(w, r, user.Username) {
+			return
+		}
+
+		ok := false
+
+		switch {
+		case body.Passcode != "":
+			ok = totp.UseCode(body.Passcode, time.Now())
+		case body.RecoveryCode != "" && body.Password != "":
+			ok = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)) == nil &&
+				totp.UseRecoveryCode(body.RecoveryCode)
+		}
+
+		if !ok {
+			recordLoginFailure(store, r, user.Username, user)
+			helpers.WriteJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Valid passcode, or recovery code and password are required",
+			})
+			return
+		}
+	}
+
+	if err = store.DeleteUserTotp(user.ID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifySession is the second step of the login: it verifies the session
+// by the TOTP code or by one of the recovery codes.
+func verifySession(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Passcode     string `json:"passcode"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	userID, sessionID, ok := readSessionCookie(r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	store := helpers.Store(r)
+
+	session, err := store.GetSession(userID, sessionID)
+	if err != nil || session.VerificationMethod != db.SessionVerificationTotp {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if session.Verified {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	user, err := store.GetUser(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// failed codes are throttled together with failed passwords of the account
+	if !checkLoginThrottle(w, r, user.Username) {
+		return
+	}
+
+	totp, err := store.GetUserTotp(userID)
+	if err != nil || !totp.Confirmed {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case body.Passcode != "":
+		ok = totp.UseCode(body.Passcode, time.Now())
+	case body.RecoveryCode != "":
+		ok = totp.UseRecoveryCode(body.RecoveryCode)
+	default:
+		ok = false
+	}
+
+	if !ok {
+		recordLoginFailure(store, r, user.Username, &user)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// used codes must not be accepted again
+	if err = store.UpdateUserTotp(totp); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if err = store.VerifySession(userID, sessionID); err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	resetLoginFailures(user.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
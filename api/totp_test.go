@@ -0,0 +1,291 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
+)
+
+type testClient struct {
+	router  *mux.Router
+	cookies []*http.Cookie
+	token   string
+}
+
+func (c *testClient) do(method string, url string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+
+	req, _ := http.NewRequest(method, url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+
+	rr := httptest.NewRecorder()
+	c.router.ServeHTTP(rr, req)
+
+	if cookies := rr.Result().Cookies(); len(cookies) > 0 {
+		c.cookies = cookies
+	}
+
+	return rr
+}
+
+func TestTotpLogin(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	defer func() {
+		util.Config = nil
+		loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	}()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	_, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+	credentials := map[string]string{"auth": "admin", "password": "password"}
+
+	if rr := client.do("POST", "/api/auth/login", credentials); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	// enrol
+	rr := client.do("POST", "/api/user/totp", nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var enrolment struct {
+		Secret string `json:"secret"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &enrolment)
+
+	if rr = client.do("POST", "/api/user/totp/confirm", map[string]string{"passcode": "000000x"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("Response code should be 400 %d", rr.Code)
+	}
+
+	code, _ := util.GetTotpCode(enrolment.Secret, time.Now())
+
+	rr = client.do("POST", "/api/user/totp/confirm", map[string]string{"passcode": code})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	var recovery struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &recovery)
+
+	if len(recovery.RecoveryCodes) != recoveryCodesCount {
+		t.Fatal("recovery codes must be returned")
+	}
+
+	// two-step login
+	client.cookies = nil
+
+	rr = client.do("POST", "/api/auth/login", credentials)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr = client.do("GET", "/api/user", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("unverified session must be rejected: %d", rr.Code)
+	}
+
+	if rr = client.do("POST", "/api/auth/verify", map[string]string{"recovery_code": recovery.RecoveryCodes[0]}); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	if rr = client.do("GET", "/api/user", nil); rr.Code != http.StatusOK {
+		t.Fatalf("verified session must be accepted: %d", rr.Code)
+	}
+
+	// recovery codes are single-use
+	client.cookies = nil
+	client.do("POST", "/api/auth/login", credentials)
+
+	if rr = client.do("POST", "/api/auth/verify", map[string]string{"recovery_code": recovery.RecoveryCodes[0]}); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("used recovery code must be rejected: %d", rr.Code)
+	}
+
+	// passcodes are single-use too, the code of the next period is accepted within the skew
+	code, _ = util.GetTotpCode(enrolment.Secret, time.Now().Add(30*time.Second))
+
+	client.cookies = nil
+	client.do("POST", "/api/auth/login", credentials)
+
+	if rr = client.do("POST", "/api/auth/verify", map[string]string{"passcode": code}); rr.Code != http.StatusNoContent {
+		t.Fatalf("Response code should be 204 %d", rr.Code)
+	}
+
+	client.cookies = nil
+	client.do("POST", "/api/auth/login", credentials)
+
+	if rr = client.do("POST", "/api/auth/verify", map[string]string{"passcode": code}); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("used passcode must be rejected: %d", rr.Code)
+	}
+}
+
+func TestTotpRequired(t *testing.T) {
+	util.Config = &util.ConfigType{TotpRequired: true}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	_, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "user", Name: "User", Email: "user@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+
+	rr := client.do("POST", "/api/auth/login", map[string]string{"auth": "user", "password": "password"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Response code should be 200 %d", rr.Code)
+	}
+
+	if rr = client.do("GET", "/api/projects", nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("session must not be usable before enrolment: %d", rr.Code)
+	}
+
+	if rr = client.do("POST", "/api/user/totp", nil); rr.Code != http.StatusCreated {
+		t.Fatalf("enrolment must be allowed: %d", rr.Code)
+	}
+
+	if rr = client.do("DELETE", "/api/user/totp", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("required TOTP must not be disabled: %d", rr.Code)
+	}
+}
+
+func TestTotpDisable(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	defer func() {
+		util.Config = nil
+		loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	}()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "user", Name: "User", Email: "user@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+	client.do("POST", "/api/auth/login", map[string]string{"auth": "user", "password": "password"})
+
+	rr := client.do("POST", "/api/user/totp", nil)
+	var enrolment struct {
+		Secret string `json:"secret"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &enrolment)
+
+	code, _ := util.GetTotpCode(enrolment.Secret, time.Now())
+	rr = client.do("POST", "/api/user/totp/confirm", map[string]string{"passcode": code})
+
+	var recovery struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &recovery)
+
+	// the session alone is not enough to disable the second factor
+	for _, body := range []map[string]string{
+		{},
+		{"passcode": "000000"},
+		{"recovery_code": recovery.RecoveryCodes[0]},
+	} {
+		if rr = client.do("DELETE", "/api/user/totp", body); rr.Code != http.StatusForbidden {
+			t.Fatalf("TOTP must not be disabled with %v: %d", body, rr.Code)
+		}
+	}
+
+	rr = client.do("DELETE", "/api/user/totp", map[string]string{"recovery_code": recovery.RecoveryCodes[0], "password": "password"})
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("TOTP must be disabled with the recovery code and the password: %d", rr.Code)
+	}
+
+	if _, err = store.GetUserTotp(user.ID); err != db.ErrNotFound {
+		t.Fatal("TOTP must be removed")
+	}
+}
+
+func TestTotpFailuresSurvivePasswordLogin(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	defer func() {
+		util.Config = nil
+		loginFailures = &loginThrottle{attempts: make(map[string]*loginAttempts)}
+	}()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "user", Name: "User", Email: "user@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, _ := util.GenerateTotpSecret()
+	if _, err = store.CreateUserTotp(db.UserTotp{UserID: user.ID, Secret: secret, Confirmed: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+	credentials := map[string]string{"auth": "user", "password": "password"}
+
+	// the password is known, codes are guessed between logins
+	for i := 0; i < 2; i++ {
+		client.cookies = nil
+		if rr := client.do("POST", "/api/auth/login", credentials); rr.Code != http.StatusOK {
+			t.Fatalf("Response code should be 200 %d", rr.Code)
+		}
+
+		for j := 0; j < 2; j++ {
+			if rr := client.do("POST", "/api/auth/verify", map[string]string{"passcode": "000000"}); rr.Code != http.StatusUnauthorized {
+				t.Fatalf("Response code should be 401 %d", rr.Code)
+			}
+		}
+	}
+
+	if loginFailures.retryAfter(time.Now(), loginAccountKey("user")) <= 0 {
+		t.Fatal("password logins must not reset failed TOTP codes of the account")
+	}
+}
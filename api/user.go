@@ -3,6 +3,7 @@ package api
 import (
 	"crypto/rand"
 	"encoding/base64"
+	log "github.com/Sirupsen/logrus"
 	"github.com/ansible-semaphore/semaphore/api/helpers"
 	"github.com/ansible-semaphore/semaphore/db"
 	"github.com/gorilla/context"
@@ -10,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func getUser(w http.ResponseWriter, r *http.Request) {
@@ -21,8 +23,41 @@ func getUser(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, context.Get(r, "user"))
 }
 
+// getTokenOwner returns the user whose tokens are managed:
+// the service account from the path or the current user.
+func getTokenOwner(r *http.Request) *db.User {
+	if u, exists := context.GetOk(r, "_user"); exists {
+		user := u.(db.User)
+		return &user
+	}
+	return context.Get(r, "user").(*db.User)
+}
+
+// serviceAccountMiddleware allows only admins to manage the service account from the path.
+func serviceAccountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := context.Get(r, "_user").(db.User)
+		editor := context.Get(r, "user").(*db.User)
+
+		if !user.Service {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "User is not a service account",
+			})
+			return
+		}
+
+		if !editor.Admin {
+			log.Warn(editor.Username + " is not permitted to manage service accounts")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func getAPITokens(w http.ResponseWriter, r *http.Request) {
-	user := context.Get(r, "user").(*db.User)
+	user := getTokenOwner(r)
 
 	tokens, err := helpers.Store(r).GetAPITokens(user.ID)
 	if err != nil {
@@ -33,15 +68,45 @@ func getAPITokens(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, tokens)
 }
 
+// createAPIToken creates a scoped token of the current user or the service account.
+// Only a hash of the token is stored, the token itself is returned once in the response.
 func createAPIToken(w http.ResponseWriter, r *http.Request) {
-	user := context.Get(r, "user").(*db.User)
-	tokenID := make([]byte, 32)
-	if _, err := io.ReadFull(rand.Reader, tokenID); err != nil {
+	user := getTokenOwner(r)
+
+	var body struct {
+		Name    string     `json:"name"`
+		Expires *time.Time `json:"expires"`
+		Scopes  string     `json:"scopes"`
+	}
+	if !helpers.Bind(w, r, &body) {
+		return
+	}
+
+	if _, err := db.ParseAPITokenScopes(body.Scopes); err != nil {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if body.Expires != nil && !body.Expires.After(time.Now()) {
+		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Expiry date must be in the future",
+		})
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
 		panic(err)
 	}
+	tokenString := base64.RawURLEncoding.EncodeToString(secret)
 
 	token, err := helpers.Store(r).CreateAPIToken(db.APIToken{
-		ID:      strings.ToLower(base64.URLEncoding.EncodeToString(tokenID)),
+		ID:      db.HashAPIToken(tokenString),
+		Name:    body.Name,
+		Expires: body.Expires,
+		Scopes:  strings.Join(strings.Fields(body.Scopes), " "),
 		UserID:  user.ID,
 		Expired: false,
 	})
@@ -49,11 +114,14 @@ func createAPIToken(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	helpers.WriteJSON(w, http.StatusCreated, token)
+	helpers.WriteJSON(w, http.StatusCreated, struct {
+		db.APIToken
+		Token string `json:"token"`
+	}{token, tokenString})
 }
 
 func expireAPIToken(w http.ResponseWriter, r *http.Request) {
-	user := context.Get(r, "user").(*db.User)
+	user := getTokenOwner(r)
 
 	tokenID := mux.Vars(r)["token_id"]
 
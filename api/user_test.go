@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
+	"github.com/gorilla/securecookie"
+)
+
+func TestAPITokenScopes(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+	defer func() { util.Config = nil }()
+
+	store, router := createTestRouter(t)
+	defer store.Close()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "password",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &testClient{router: router}
+	client.do("POST", "/api/auth/login", map[string]string{"auth": "admin", "password": "password"})
+
+	if rr := client.do("POST", "/api/user/tokens", map[string]string{"scopes": "write"}); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid scope must be rejected: %d", rr.Code)
+	}
+
+	rr := client.do("POST", "/api/user/tokens", map[string]string{"name": "CI", "scopes": "read"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Response code should be 201 %d", rr.Code)
+	}
+
+	var created struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+
+	if created.ID != db.HashAPIToken(created.Token) {
+		t.Fatal("only the hash of the token must be stored")
+	}
+
+	request := func(method string, url string, auth string) int {
+		req, _ := http.NewRequest(method, url, nil)
+		req.Header.Set("Authorization", auth)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := request("GET", "/api/user", "Bearer "+created.Token); code != http.StatusOK {
+		t.Fatalf("read-only token must be accepted: %d", code)
+	}
+
+	if code := request("GET", "/api/user", "bearer "+created.Token); code != http.StatusOK {
+		t.Fatalf("authorization scheme must be case-insensitive: %d", code)
+	}
+
+	if code := request("POST", "/api/user/tokens", "Bearer "+created.Token); code != http.StatusForbidden {
+		t.Fatalf("read-only token must not create tokens: %d", code)
+	}
+
+	if code := request("GET", "/api/user", "Bearer "+created.ID); code != http.StatusUnauthorized {
+		t.Fatalf("hash must not be accepted as a token: %d", code)
+	}
+
+	tokens, err := store.GetAPITokens(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 1 || tokens[0].LastUsed == nil || tokens[0].Name != "CI" {
+		t.Fatal("last usage of the token must be saved")
+	}
+
+	// run scopes allow reading the project, but not changing it
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: user.ID, Role: db.ProjectOwner}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = client.do("POST", "/api/user/tokens", map[string]string{"scopes": "run:project:" + strconv.Itoa(project.ID)})
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+
+	projectURL := "/api/project/" + strconv.Itoa(project.ID)
+
+	if code := request("GET", projectURL, "Bearer "+created.Token); code != http.StatusOK {
+		t.Fatalf("run scope must allow reading the project: %d", code)
+	}
+
+	if code := request("DELETE", projectURL, "Bearer "+created.Token); code != http.StatusForbidden {
+		t.Fatalf("run scope must not allow changing the project: %d", code)
+	}
+
+	if code := request("GET", "/api/projects", "Bearer "+created.Token); code != http.StatusForbidden {
+		t.Fatalf("run scope must not allow requests outside of the project: %d", code)
+	}
+
+	// template run scopes allow reading only the template and its tasks
+	templates := make([]db.Template, 2)
+	taskIDs := make([]string, 2)
+	for i := range templates {
+		templates[i], err = store.CreateTemplate(db.Template{ProjectID: project.ID, Alias: "Deploy " + strconv.Itoa(i), Playbook: "deploy.yml"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		task, err := store.CreateTask(db.Task{ProjectID: project.ID, TemplateID: templates[i].ID, Status: "success"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		taskIDs[i] = strconv.Itoa(task.ID)
+	}
+
+	rr = client.do("POST", "/api/user/tokens", map[string]string{"scopes": "run:template:" + strconv.Itoa(project.ID) + ":" + strconv.Itoa(templates[0].ID)})
+	_ = json.Unmarshal(rr.Body.Bytes(), &created)
+
+	allowed := []string{
+		"/templates/" + strconv.Itoa(templates[0].ID),
+		"/templates/" + strconv.Itoa(templates[0].ID) + "/tasks",
+		"/tasks/" + taskIDs[0],
+		"/tasks/" + taskIDs[0] + "/output",
+	}
+
+	for _, url := range allowed {
+		if code := request("GET", projectURL+url, "Bearer "+created.Token); code != http.StatusOK {
+			t.Fatalf("template run scope must allow reading %s: %d", url, code)
+		}
+	}
+
+	forbidden := []string{
+		"",
+		"/keys",
+		"/environment",
+		"/inventory",
+		"/tasks",
+		"/templates",
+		"/templates/" + strconv.Itoa(templates[1].ID),
+		"/tasks/" + taskIDs[1],
+	}
+
+	for _, url := range forbidden {
+		if code := request("GET", projectURL+url, "Bearer "+created.Token); code != http.StatusForbidden {
+			t.Fatalf("template run scope must not allow reading %s: %d", url, code)
+		}
+	}
+
+	// template IDs are unique only within the project, the scope must not match other projects
+	otherProject, err := store.CreateProject(db.Project{Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.CreateProjectUser(db.ProjectUser{ProjectID: otherProject.ID, UserID: user.ID, Role: db.ProjectOwner}); err != nil {
+		t.Fatal(err)
+	}
+
+	otherTemplate, err := store.CreateTemplate(db.Template{ProjectID: otherProject.ID, Alias: "Deploy", Playbook: "deploy.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if otherTemplate.ID != templates[0].ID {
+		t.Fatalf("templates of both projects must share the ID: %d, %d", otherTemplate.ID, templates[0].ID)
+	}
+
+	otherProjectURL := "/api/project/" + strconv.Itoa(otherProject.ID)
+
+	if code := request("GET", otherProjectURL+"/templates/"+strconv.Itoa(otherTemplate.ID), "Bearer "+created.Token); code != http.StatusForbidden {
+		t.Fatalf("template run scope must not allow reading the template of another project: %d", code)
+	}
+
+	tokenClient := &testClient{router: router, token: created.Token}
+	rr = tokenClient.do("POST", otherProjectURL+"/tasks", map[string]interface{}{"template_id": otherTemplate.ID})
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("template run scope must not allow running the template of another project: %d", rr.Code)
+	}
+}
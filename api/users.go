@@ -33,16 +33,51 @@ func addUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newUser, err := helpers.Store(r).CreateUser(user)
+	var newUser db.User
+	var err error
+
+	if user.Service {
+		// service accounts have no password and don't receive emails to verify
+		user.External = false
+		user.EmailUnverified = false
+		newUser, err = helpers.Store(r).CreateUserWithoutPassword(user.User)
+	} else {
+		user.EmailUnverified = util.Config.EmailVerification && !user.External
+		newUser, err = helpers.Store(r).CreateUser(user)
+	}
+
+	if writePasswordPolicyError(w, err) {
+		return
+	}
 
 	if err != nil {
 		log.Warn(editor.Username + " is not created: " + err.Error())
 		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if newUser.EmailUnverified && util.Config.IsEmailConfigured() {
+		if err = sendEmailVerification(helpers.Store(r), newUser); err != nil {
+			log.Error(err)
+		}
 	}
 
 	helpers.WriteJSON(w, http.StatusCreated, newUser)
 }
 
+// writePasswordPolicyError responds with the reason if the password does not satisfy the policy.
+func writePasswordPolicyError(w http.ResponseWriter, err error) bool {
+	policyErr, ok := err.(*util.PasswordPolicyError)
+	if !ok {
+		return false
+	}
+
+	helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+		"error": "Password " + policyErr.Reason,
+	})
+	return true
+}
+
 func getUserMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID, err := helpers.GetIntParam("user_id", w, r)
@@ -99,7 +134,12 @@ func updateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user.ID = oldUser.ID
+	user.EmailUnverified = oldUser.EmailUnverified
+	user.Service = oldUser.Service
 	if err := helpers.Store(r).UpdateUser(user); err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		log.Error(err.Error())
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -128,11 +168,20 @@ func updateUserPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Service {
+		log.Warn("Password is not editable for service accounts")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	if !helpers.Bind(w, r, &pwd) {
 		return
 	}
 
 	if err := helpers.Store(r).SetUserPassword(user.ID, pwd.Pwd); err != nil {
+		if writePasswordPolicyError(w, err) {
+			return
+		}
 		util.LogWarning(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type projectArgs struct {
+	id         int
+	name       string
+	file       string
+	format     string
+	passphrase string
+	owner      string
+}
+
+var targetProjectArgs projectArgs
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+}
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db/bundle"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+)
+
+func init() {
+	projectExportCmd.PersistentFlags().IntVar(&targetProjectArgs.id, "id", 0, "Project ID")
+	projectExportCmd.PersistentFlags().StringVar(&targetProjectArgs.file, "file", "", "Bundle file, standard output if not specified")
+	projectExportCmd.PersistentFlags().StringVar(&targetProjectArgs.format, "format", bundle.FormatYAML, "Bundle format: yaml or json")
+	projectExportCmd.PersistentFlags().StringVar(&targetProjectArgs.passphrase, "passphrase", "", "Passphrase to encrypt secrets, secrets are omitted if not specified")
+	projectCmd.AddCommand(projectExportCmd)
+}
+
+var projectExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export project to bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		if targetProjectArgs.id == 0 {
+			fmt.Println("Argument --id required")
+			fmt.Println("Use command `semaphore project export --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore()
+		defer store.Close()
+
+		exported, err := bundle.Export(store, targetProjectArgs.id, targetProjectArgs.passphrase)
+		if err != nil {
+			panic(err)
+		}
+
+		data, err := bundle.Marshal(exported, targetProjectArgs.format)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		if targetProjectArgs.file == "" {
+			_, _ = os.Stdout.Write(data)
+			return
+		}
+
+		if err = ioutil.WriteFile(targetProjectArgs.file, data, 0600); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Project %s exported to %s\n", exported.Project.Name, targetProjectArgs.file)
+	},
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bundle"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+)
+
+func init() {
+	projectImportCmd.PersistentFlags().StringVar(&targetProjectArgs.file, "file", "", "Bundle file")
+	projectImportCmd.PersistentFlags().StringVar(&targetProjectArgs.name, "name", "", "Project name, the name from the bundle if not specified")
+	projectImportCmd.PersistentFlags().StringVar(&targetProjectArgs.passphrase, "passphrase", "", "Passphrase to decrypt secrets")
+	projectImportCmd.PersistentFlags().StringVar(&targetProjectArgs.owner, "owner", "", "Login or email of the user who becomes project owner")
+	projectCmd.AddCommand(projectImportCmd)
+}
+
+var projectImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create project from bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		if targetProjectArgs.file == "" {
+			fmt.Println("Argument --file required")
+			fmt.Println("Use command `semaphore project import --help` for details.")
+			os.Exit(1)
+		}
+
+		data, err := ioutil.ReadFile(targetProjectArgs.file)
+		if err != nil {
+			panic(err)
+		}
+
+		imported, err := bundle.Unmarshal(data)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		store := createStore()
+		defer store.Close()
+
+		var owner db.User
+		if targetProjectArgs.owner != "" {
+			owner, err = store.GetUserByLoginOrEmail(targetProjectArgs.owner, targetProjectArgs.owner)
+			if err != nil {
+				fmt.Printf("User %s not found\n", targetProjectArgs.owner)
+				os.Exit(1)
+			}
+		}
+
+		project, err := bundle.Import(store, imported, targetProjectArgs.passphrase, targetProjectArgs.name)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		if targetProjectArgs.owner != "" {
+			_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: project.ID, UserID: owner.ID, Role: db.ProjectOwner})
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		fmt.Printf("Project %s imported with ID %d\n", project.Name, project.ID)
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/ansible-semaphore/semaphore/api"
+	"github.com/ansible-semaphore/semaphore/api/configsync"
 	"github.com/ansible-semaphore/semaphore/api/schedules"
 	"github.com/ansible-semaphore/semaphore/api/sockets"
 	"github.com/ansible-semaphore/semaphore/api/tasks"
@@ -79,6 +80,10 @@ func runService() {
 	go sockets.StartWS()
 	go tasks.StartRunner()
 	go schedulePool.Run()
+	runMetricsServer()
+	go api.StartLDAPSync(store)
+	go configsync.StartSync(store, schedulePool)
+	go tasks.StartApprovalExpiry(store)
 
 	route := api.Route()
 
@@ -93,6 +98,8 @@ func runService() {
 	var router http.Handler = route
 
 	router = handlers.ProxyHeaders(router)
+	// the peer address must be captured before proxy headers replace it
+	router = api.CapturePeerAddress(router)
 	http.Handle("/", router)
 
 	fmt.Println("Server is running")
@@ -104,6 +111,31 @@ func runService() {
 	}
 }
 
+// runMetricsServer starts serving metrics on the separate address if it is configured.
+func runMetricsServer() {
+	if !util.Config.MetricsEnable {
+		return
+	}
+
+	if util.Config.MetricsListen == "" {
+		if util.Config.MetricsToken == "" {
+			log.Warn("Metrics are not served: set metrics_token or metrics_listen")
+		}
+		return
+	}
+
+	fmt.Printf("Metrics %v\n", util.Config.MetricsListen)
+
+	router := http.NewServeMux()
+	router.Handle("/api/metrics", api.MetricsHandler())
+
+	go func() {
+		if err := http.ListenAndServe(util.Config.MetricsListen, router); err != nil {
+			log.Panic(err)
+		}
+	}()
+}
+
 func createStore() db.Store {
 	util.ConfigInit(configPath)
 
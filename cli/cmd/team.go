@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type teamArgs struct {
+	name      string
+	login     string
+	projectID int
+	role      string
+}
+
+var targetTeamArgs teamArgs
+
+func init() {
+	rootCmd.AddCommand(teamCmd)
+}
+
+var teamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "Manage teams",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
+
+// requireTeamName exits if the --name argument is not specified.
+func requireTeamName(command string) {
+	if targetTeamArgs.name == "" {
+		fmt.Println("Argument --name required")
+		fmt.Println("Use command `semaphore team " + command + " --help` for details.")
+		os.Exit(1)
+	}
+}
+
+// getTeamByName returns the team with the name or exits if it does not exist.
+func getTeamByName(store db.Store, name string) db.Team {
+	teams, err := store.GetTeams(db.RetrieveQueryParams{})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, team := range teams {
+		if team.Name == name {
+			return team
+		}
+	}
+
+	fmt.Printf("Team %s not found\n", name)
+	os.Exit(1)
+	return db.Team{}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	teamAddCmd.PersistentFlags().StringVar(&targetTeamArgs.name, "name", "", "New team name")
+	teamCmd.AddCommand(teamAddCmd)
+}
+
+var teamAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add new team",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("add")
+
+		store := createStore()
+		defer store.Close()
+
+		if _, err := store.CreateTeam(db.Team{Name: targetTeamArgs.name}); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Team %s added!\n", targetTeamArgs.name)
+	},
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	teamDeleteCmd.PersistentFlags().StringVar(&targetTeamArgs.name, "name", "", "Name of the team you want to delete")
+	teamCmd.AddCommand(teamDeleteCmd)
+}
+
+var teamDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Remove existing team",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("delete")
+
+		store := createStore()
+		defer store.Close()
+
+		team := getTeamByName(store, targetTeamArgs.name)
+
+		if err := store.DeleteTeam(team.ID); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Team %s deleted!\n", team.Name)
+	},
+}
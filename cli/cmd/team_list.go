@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	teamListCmd.PersistentFlags().StringVar(&targetTeamArgs.name, "name", "", "Print members of the team instead of teams")
+	teamCmd.AddCommand(teamListCmd)
+}
+
+var teamListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print all teams or members of the team",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := createStore()
+		defer store.Close()
+
+		if targetTeamArgs.name != "" {
+			team := getTeamByName(store, targetTeamArgs.name)
+
+			users, err := store.GetTeamUsers(team.ID)
+			if err != nil {
+				panic(err)
+			}
+
+			for _, user := range users {
+				fmt.Println(user.Username)
+			}
+			return
+		}
+
+		teams, err := store.GetTeams(db.RetrieveQueryParams{})
+		if err != nil {
+			panic(err)
+		}
+
+		for _, team := range teams {
+			fmt.Println(team.Name)
+		}
+	},
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() {
+	for _, c := range []*cobra.Command{teamAddUserCmd, teamRemoveUserCmd} {
+		c.PersistentFlags().StringVar(&targetTeamArgs.name, "name", "", "Team name")
+		c.PersistentFlags().StringVar(&targetTeamArgs.login, "login", "", "Login or email of the user")
+		teamCmd.AddCommand(c)
+	}
+}
+
+// getTeamMemberArgs returns the team and the user given by arguments or exits if they do not exist.
+func getTeamMemberArgs(store db.Store, command string) (db.Team, db.User) {
+	if targetTeamArgs.login == "" {
+		fmt.Println("Argument --login required")
+		fmt.Println("Use command `semaphore team " + command + " --help` for details.")
+		os.Exit(1)
+	}
+
+	team := getTeamByName(store, targetTeamArgs.name)
+
+	user, err := store.GetUserByLoginOrEmail(targetTeamArgs.login, targetTeamArgs.login)
+	if err == db.ErrNotFound {
+		fmt.Printf("User %s not found\n", targetTeamArgs.login)
+		os.Exit(1)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	return team, user
+}
+
+var teamAddUserCmd = &cobra.Command{
+	Use:   "add-user",
+	Short: "Add user to the team",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("add-user")
+
+		store := createStore()
+		defer store.Close()
+
+		team, user := getTeamMemberArgs(store, "add-user")
+
+		members, err := store.GetTeamUsers(team.ID)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, member := range members {
+			if member.ID == user.ID {
+				fmt.Printf("User %s is already a member of team %s\n", user.Username, team.Name)
+				return
+			}
+		}
+
+		if _, err = store.CreateTeamUser(db.TeamUser{TeamID: team.ID, UserID: user.ID}); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("User %s added to team %s!\n", user.Username, team.Name)
+	},
+}
+
+var teamRemoveUserCmd = &cobra.Command{
+	Use:   "remove-user",
+	Short: "Remove user from the team",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("remove-user")
+
+		store := createStore()
+		defer store.Close()
+
+		team, user := getTeamMemberArgs(store, "remove-user")
+
+		if err := store.DeleteTeamUser(team.ID, user.ID); err != nil && err != db.ErrNotFound {
+			panic(err)
+		}
+
+		fmt.Printf("User %s removed from team %s!\n", user.Username, team.Name)
+	},
+}
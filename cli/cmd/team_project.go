@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() {
+	teamAttachCmd.PersistentFlags().StringVar(&targetTeamArgs.role, "role", string(db.ProjectManager),
+		"Role of team members in the project: owner, manager, task_runner or guest")
+
+	for _, c := range []*cobra.Command{teamAttachCmd, teamDetachCmd} {
+		c.PersistentFlags().StringVar(&targetTeamArgs.name, "name", "", "Team name")
+		c.PersistentFlags().IntVar(&targetTeamArgs.projectID, "project", 0, "Project ID")
+		teamCmd.AddCommand(c)
+	}
+}
+
+// getTeamProjectArgs returns the team and the project given by arguments or exits if they do not exist.
+func getTeamProjectArgs(store db.Store, command string) (db.Team, db.Project) {
+	if targetTeamArgs.projectID == 0 {
+		fmt.Println("Argument --project required")
+		fmt.Println("Use command `semaphore team " + command + " --help` for details.")
+		os.Exit(1)
+	}
+
+	team := getTeamByName(store, targetTeamArgs.name)
+
+	project, err := store.GetProject(targetTeamArgs.projectID)
+	if err == db.ErrNotFound {
+		fmt.Printf("Project %d not found\n", targetTeamArgs.projectID)
+		os.Exit(1)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	return team, project
+}
+
+var teamAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Give members of the team a role in the project",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("attach")
+
+		role := db.ProjectUserRole(targetTeamArgs.role)
+		if !role.IsValid() {
+			fmt.Printf("Invalid role %s\n", targetTeamArgs.role)
+			os.Exit(1)
+		}
+
+		store := createStore()
+		defer store.Close()
+
+		team, project := getTeamProjectArgs(store, "attach")
+		projectTeam := db.ProjectTeam{ProjectID: project.ID, TeamID: team.ID, Role: role}
+
+		_, err := store.GetProjectTeam(project.ID, team.ID)
+
+		switch err {
+		case nil:
+			err = store.UpdateProjectTeam(projectTeam)
+		case db.ErrNotFound:
+			_, err = store.CreateProjectTeam(projectTeam)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Team %s attached to project %s as %s!\n", team.Name, project.Name, role)
+	},
+}
+
+var teamDetachCmd = &cobra.Command{
+	Use:   "detach",
+	Short: "Remove the team from the project",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireTeamName("detach")
+
+		store := createStore()
+		defer store.Close()
+
+		team, project := getTeamProjectArgs(store, "detach")
+
+		if err := store.DeleteProjectTeam(project.ID, team.ID); err != nil && err != db.ErrNotFound {
+			panic(err)
+		}
+
+		fmt.Printf("Team %s detached from project %s!\n", team.Name, project.Name)
+	},
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func init() {
+	user2faResetCmd.PersistentFlags().StringVar(&targetUserArgs.login, "login", "", "Login of the user")
+	user2faResetCmd.PersistentFlags().StringVar(&targetUserArgs.email, "email", "", "Email of the user")
+	user2faCmd.AddCommand(user2faResetCmd)
+	userCmd.AddCommand(user2faCmd)
+}
+
+var user2faCmd = &cobra.Command{
+	Use:   "2fa",
+	Short: "Manage two-factor authentication of users",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
+
+var user2faResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Disable two-factor authentication of the user, e.g. if the user lost the device",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if targetUserArgs.login == "" && targetUserArgs.email == "" {
+			fmt.Println("Argument --email or --login required")
+			fmt.Println("Use command `semaphore user 2fa reset --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore()
+		defer store.Close()
+
+		user, err := store.GetUserByLoginOrEmail(targetUserArgs.login, targetUserArgs.email)
+		if err != nil {
+			panic(err)
+		}
+
+		err = store.DeleteUserTotp(user.ID)
+
+		if err == db.ErrNotFound {
+			fmt.Printf("User %s <%s> has no two-factor authentication\n", user.Username, user.Email)
+			return
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("Two-factor authentication of user %s <%s> reset!\n", user.Username, user.Email)
+	},
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
 	"github.com/spf13/cobra"
 	"os"
 )
@@ -58,6 +59,10 @@ var userAddCmd = &cobra.Command{
 				Admin:    targetUserArgs.admin,
 			},
 		}); err != nil {
+			if _, ok := err.(*util.PasswordPolicyError); ok {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
 			panic(err)
 		}
 
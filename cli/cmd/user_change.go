@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/util"
 	"github.com/spf13/cobra"
 	"os"
 )
@@ -43,6 +44,10 @@ func applyChangeUserArgsForUser(user db.User, store db.Store) {
 		User: user,
 		Pwd: targetUserArgs.password,
 	}); err != nil {
+		if _, ok := err.(*util.PasswordPolicyError); ok {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
 		panic(err)
 	}
 
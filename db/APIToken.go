@@ -1,11 +1,165 @@
 package db
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
-// APIToken is given to a user to allow API access
+const (
+	// APITokenScopeAdmin gives the token all rights of the user.
+	APITokenScopeAdmin = "admin"
+	// APITokenScopeRead allows read-only requests.
+	APITokenScopeRead = "read"
+	// APITokenScopeRun allows reading a project and running tasks of it. It must be restricted
+	// to a project or a template: run:project:<project id> or run:template:<project id>:<template id>.
+	// Template IDs are unique only within the project, so template scopes include the project.
+	APITokenScopeRun = "run"
+)
+
+// APITokenScope is a parsed scope of the API token. Template scopes have both IDs set.
+type APITokenScope struct {
+	Access     string
+	ProjectID  int
+	TemplateID int
+}
+
+// APIToken is given to a user to allow API access.
+// ID is a hash of the token, the token itself is shown only once when it is created.
 type APIToken struct {
-	ID      string    `db:"id" json:"id"`
-	Created time.Time `db:"created" json:"created"`
-	Expired bool      `db:"expired" json:"expired"`
-	UserID  int       `db:"user_id" json:"user_id"`
+	ID       string     `db:"id" json:"id"`
+	Name     string     `db:"name" json:"name"`
+	Created  time.Time  `db:"created" json:"created"`
+	Expired  bool       `db:"expired" json:"expired"`
+	Expires  *time.Time `db:"expires" json:"expires"`
+	LastUsed *time.Time `db:"last_used" json:"last_used"`
+	LastIP   string     `db:"last_ip" json:"last_ip"`
+	UserID   int        `db:"user_id" json:"user_id"`
+
+	// Scopes is a space separated list of scopes: admin, read,
+	// run:project:<project id> and run:template:<project id>:<template id>.
+	Scopes string `db:"scopes" json:"scopes"`
+}
+
+// HashAPIToken returns the hash which is stored as ID of the token.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+func parseAPITokenScope(s string) (APITokenScope, error) {
+	parts := strings.Split(s, ":")
+
+	switch {
+	case len(parts) == 1 && (parts[0] == APITokenScopeAdmin || parts[0] == APITokenScopeRead):
+		return APITokenScope{Access: parts[0]}, nil
+	case len(parts) == 3 && parts[0] == APITokenScopeRun && parts[1] == "project":
+		projectID, err := strconv.Atoi(parts[2])
+		if err != nil || projectID <= 0 {
+			break
+		}
+		return APITokenScope{Access: APITokenScopeRun, ProjectID: projectID}, nil
+	case len(parts) == 4 && parts[0] == APITokenScopeRun && parts[1] == "template":
+		projectID, err := strconv.Atoi(parts[2])
+		if err != nil || projectID <= 0 {
+			break
+		}
+		templateID, err := strconv.Atoi(parts[3])
+		if err != nil || templateID <= 0 {
+			break
+		}
+		return APITokenScope{Access: APITokenScopeRun, ProjectID: projectID, TemplateID: templateID}, nil
+	}
+
+	return APITokenScope{}, fmt.Errorf("invalid token scope %s", s)
+}
+
+// ParseAPITokenScopes parses the space separated list of scopes.
+func ParseAPITokenScopes(scopes string) ([]APITokenScope, error) {
+	fields := strings.Fields(scopes)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("token must have at least one scope")
+	}
+
+	res := make([]APITokenScope, len(fields))
+	for i, field := range fields {
+		scope, err := parseAPITokenScope(field)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = scope
+	}
+
+	return res, nil
+}
+
+// GetScopes returns valid scopes of the token.
+func (token APIToken) GetScopes() []APITokenScope {
+	var res []APITokenScope
+	for _, field := range strings.Fields(token.Scopes) {
+		if scope, err := parseAPITokenScope(field); err == nil {
+			res = append(res, scope)
+		}
+	}
+	return res
+}
+
+// IsActive returns false if the token is revoked, expired or has no scopes.
+func (token APIToken) IsActive(now time.Time) bool {
+	if token.Expired || len(token.GetScopes()) == 0 {
+		return false
+	}
+	return token.Expires == nil || now.Before(*token.Expires)
+}
+
+func (token APIToken) hasAccess(access string) bool {
+	for _, scope := range token.GetScopes() {
+		if scope.Access == access {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin returns true if the token has all rights of the user.
+func (token APIToken) IsAdmin() bool {
+	return token.hasAccess(APITokenScopeAdmin)
+}
+
+// CanRead returns true if the token allows read-only requests to any object of the user.
+func (token APIToken) CanRead() bool {
+	return token.IsAdmin() || token.hasAccess(APITokenScopeRead)
+}
+
+// GetRunTemplateIDs returns templates of the project which are allowed to run by template scopes.
+func (token APIToken) GetRunTemplateIDs(projectID int) []int {
+	var res []int
+	for _, scope := range token.GetScopes() {
+		if scope.Access == APITokenScopeRun && scope.ProjectID == projectID && scope.TemplateID != 0 {
+			res = append(res, scope.TemplateID)
+		}
+	}
+	return res
+}
+
+// CanRunTemplate returns true if the token allows to run tasks of the template.
+// Zero templateID checks if the token allows to run tasks of any template of the project.
+func (token APIToken) CanRunTemplate(projectID int, templateID int) bool {
+	if token.IsAdmin() {
+		return true
+	}
+
+	for _, scope := range token.GetScopes() {
+		if scope.Access != APITokenScopeRun {
+			continue
+		}
+		if scope.ProjectID == projectID && (scope.TemplateID == 0 || scope.TemplateID == templateID) {
+			return true
+		}
+	}
+
+	return false
 }
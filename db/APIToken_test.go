@@ -0,0 +1,62 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAPITokenScopes(t *testing.T) {
+	if _, err := ParseAPITokenScopes("read run:project:1 run:template:1:2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scopes := range []string{"", "write", "run", "run:project:x", "run:inventory:1", "run:template:2", "run:template:0:2", "run:project:1:2"} {
+		if _, err := ParseAPITokenScopes(scopes); err == nil {
+			t.Fatalf("scopes %q must be rejected", scopes)
+		}
+	}
+}
+
+func TestAPIToken_Scopes(t *testing.T) {
+	token := APIToken{Scopes: "run:project:1 run:template:2:5"}
+
+	if token.IsAdmin() || token.CanRead() {
+		t.Fatal("run scopes must not give read or admin access")
+	}
+
+	if !token.CanRunTemplate(1, 3) || !token.CanRunTemplate(2, 5) || token.CanRunTemplate(2, 6) {
+		t.Fatal("run scopes must be limited to the project and the template")
+	}
+
+	if token.CanRunTemplate(3, 5) || token.CanRunTemplate(2, 0) {
+		t.Fatal("template scopes must be limited to the project of the template")
+	}
+
+	if ids := token.GetRunTemplateIDs(2); len(ids) != 1 || ids[0] != 5 || len(token.GetRunTemplateIDs(3)) != 0 {
+		t.Fatal("template scopes must be listed only for their project")
+	}
+
+	token.Scopes = "admin"
+	if !token.CanRead() || !token.CanRunTemplate(2, 6) {
+		t.Fatal("admin scope must give all rights")
+	}
+}
+
+func TestAPIToken_IsActive(t *testing.T) {
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	token := APIToken{Scopes: "read", Expires: &expires}
+
+	if !token.IsActive(now) {
+		t.Fatal("token must be active before expiry")
+	}
+
+	if token.IsActive(expires) {
+		t.Fatal("token must expire")
+	}
+
+	if (APIToken{}).IsActive(now) {
+		t.Fatal("token without scopes must be rejected")
+	}
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"time"
+)
+
+// kinds of account tokens
+const (
+	AccountTokenPasswordReset     = "password_reset"
+	AccountTokenEmailVerification = "email_verification"
+	AccountTokenInvitation        = "invitation"
+)
+
+// AccountToken is a single-use token which is sent to the user by email to reset the password,
+// verify the email or accept an invitation to a project. Invitations have no user, they are
+// issued for the email. ID is a hash of the token, the token itself is only sent to the user.
+type AccountToken struct {
+	ID        string          `db:"id" json:"-"`
+	Kind      string          `db:"kind" json:"kind"`
+	UserID    *int            `db:"user_id" json:"user_id"`
+	Email     string          `db:"email" json:"email"`
+	ProjectID *int            `db:"project_id" json:"project_id"`
+	Role      ProjectUserRole `db:"role" json:"role"`
+	CreatedBy *int            `db:"created_by" json:"created_by"`
+	Created   time.Time       `db:"created" json:"created"`
+	Expires   time.Time       `db:"expires" json:"expires"`
+	Used      bool            `db:"used" json:"used"`
+}
+
+// HashAccountToken returns the hash which is stored as ID of the token.
+func HashAccountToken(token string) string {
+	return HashAPIToken(token)
+}
+
+// IsActive returns true if the token is of the kind, is not used and is not expired.
+func (t AccountToken) IsActive(kind string, now time.Time) bool {
+	return t.Kind == kind && !t.Used && now.Before(t.Expires)
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountTokenIsActive(t *testing.T) {
+	now := time.Now()
+	token := AccountToken{Kind: AccountTokenPasswordReset, Expires: now.Add(time.Hour)}
+
+	if !token.IsActive(AccountTokenPasswordReset, now) {
+		t.Fatal("token must be active")
+	}
+
+	if token.IsActive(AccountTokenInvitation, now) {
+		t.Fatal("token must be active only for its kind")
+	}
+
+	if token.IsActive(AccountTokenPasswordReset, now.Add(2*time.Hour)) {
+		t.Fatal("expired token must not be active")
+	}
+
+	token.Used = true
+	if token.IsActive(AccountTokenPasswordReset, now) {
+		t.Fatal("used token must not be active")
+	}
+}
@@ -8,4 +8,6 @@ type Environment struct {
 	Password  *string `db:"password" json:"password"`
 	JSON      string  `db:"json" json:"json" binding:"required"`
 	Removed   bool    `db:"removed" json:"removed"`
+	// Managed environments are created from the project sync spec and are read-only.
+	Managed bool `db:"managed" json:"managed"`
 }
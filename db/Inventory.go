@@ -18,4 +18,7 @@ type Inventory struct {
 	Type string `db:"type" json:"type"`
 
 	Removed bool `db:"removed" json:"removed"`
+
+	// Managed inventories are created from the project sync spec and are read-only.
+	Managed bool `db:"managed" json:"managed"`
 }
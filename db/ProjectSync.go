@@ -0,0 +1,19 @@
+package db
+
+import "time"
+
+// ProjectSync makes the project follow the declarative spec stored in the repository.
+// Templates, inventories and environments created from the spec are marked as managed
+// and can be changed only by the spec.
+type ProjectSync struct {
+	ProjectID    int `db:"project_id" json:"project_id"`
+	RepositoryID int `db:"repository_id" json:"repository_id" binding:"required"`
+	// Path to the spec file relative to the root of the repository.
+	Path string `db:"path" json:"path" binding:"required"`
+	// AutoApply enables periodic reconciliation, otherwise the spec is applied only on demand.
+	AutoApply bool `db:"auto_apply" json:"auto_apply"`
+
+	LastCommit string     `db:"last_commit" json:"last_commit"`
+	LastSynced *time.Time `db:"last_synced" json:"last_synced"`
+	LastError  string     `db:"last_error" json:"last_error"`
+}
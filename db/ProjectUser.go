@@ -1,8 +1,107 @@
 package db
 
+import "encoding/json"
+
+// ProjectUserRole defines what a member of the project is allowed to do.
+type ProjectUserRole string
+
+const (
+	ProjectOwner      ProjectUserRole = "owner"
+	ProjectManager    ProjectUserRole = "manager"
+	ProjectTaskRunner ProjectUserRole = "task_runner"
+	ProjectGuest      ProjectUserRole = "guest"
+)
+
+// ProjectUserPermission is a set of actions allowed in the project.
+// Every member can view the project.
+type ProjectUserPermission int
+
+const (
+	// CanRunProjectTasks allows to run and stop tasks
+	CanRunProjectTasks ProjectUserPermission = 1 << iota
+	// CanManageProjectResources allows to edit keys, repositories, inventories,
+	// environments, templates and schedules
+	CanManageProjectResources
+	// CanManageProjectUsers allows to add and remove members and change their roles
+	CanManageProjectUsers
+	// CanUpdateProject allows to edit and delete the project
+	CanUpdateProject
+)
+
+// projectRoles lists roles from the least to the most privileged.
+var projectRoles = []ProjectUserRole{ProjectGuest, ProjectTaskRunner, ProjectManager, ProjectOwner}
+
+var rolePermissions = map[ProjectUserRole]ProjectUserPermission{
+	ProjectOwner:      CanRunProjectTasks | CanManageProjectResources | CanManageProjectUsers | CanUpdateProject,
+	ProjectManager:    CanRunProjectTasks | CanManageProjectResources,
+	ProjectTaskRunner: CanRunProjectTasks,
+	ProjectGuest:      0,
+}
+
+// IsValid returns false for unknown roles.
+func (r ProjectUserRole) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// GetPermissions returns the set of actions allowed for the role.
+func (r ProjectUserRole) GetPermissions() ProjectUserPermission {
+	return rolePermissions[r]
+}
+
+// Can returns true if the role allows the action.
+func (r ProjectUserRole) Can(permission ProjectUserPermission) bool {
+	return r.GetPermissions()&permission == permission
+}
+
+// IsAtLeast returns true if the role is the same as or more privileged than the other role.
+func (r ProjectUserRole) IsAtLeast(role ProjectUserRole) bool {
+	for _, projectRole := range projectRoles {
+		if projectRole == role {
+			return true
+		}
+		if projectRole == r {
+			return false
+		}
+	}
+	return false
+}
+
+// MaxProjectUserRole returns the most privileged of the roles.
+func MaxProjectUserRole(a ProjectUserRole, b ProjectUserRole) ProjectUserRole {
+	for i := len(projectRoles) - 1; i >= 0; i-- {
+		if projectRoles[i] == a || projectRoles[i] == b {
+			return projectRoles[i]
+		}
+	}
+	return ProjectGuest
+}
+
 type ProjectUser struct {
-	ID        int  `db:"id" json:"-"`
-	ProjectID int  `db:"project_id" json:"project_id"`
-	UserID    int  `db:"user_id" json:"user_id"`
-	Admin     bool `db:"admin" json:"admin"`
+	ID        int             `db:"id" json:"-"`
+	ProjectID int             `db:"project_id" json:"project_id"`
+	UserID    int             `db:"user_id" json:"user_id"`
+	Role      ProjectUserRole `db:"role" json:"role"`
+}
+
+// UserWithProjectRole is a member of the project.
+type UserWithProjectRole struct {
+	Role ProjectUserRole `db:"role" json:"role"`
+	User
+}
+
+// MarshalJSON keeps the admin field of the project member meaning the owner role,
+// as it did before roles were introduced, instead of the global admin flag of the user.
+func (u UserWithProjectRole) MarshalJSON() ([]byte, error) {
+	type user User
+
+	return json.Marshal(struct {
+		user
+		Role  ProjectUserRole `json:"role"`
+		Admin bool            `json:"admin"`
+	}{
+		user:  user(u.User),
+		Role:  u.Role,
+		Admin: u.Role == ProjectOwner,
+	})
 }
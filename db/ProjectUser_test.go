@@ -0,0 +1,76 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProjectUserRole_Can(t *testing.T) {
+	if !ProjectOwner.Can(CanUpdateProject | CanManageProjectUsers) {
+		t.Fatal("owner must be able to do everything")
+	}
+
+	if ProjectManager.Can(CanManageProjectUsers) || !ProjectManager.Can(CanManageProjectResources) {
+		t.Fatal("manager must manage resources, but not members")
+	}
+
+	if ProjectTaskRunner.Can(CanManageProjectResources) || !ProjectTaskRunner.Can(CanRunProjectTasks) {
+		t.Fatal("task runner must only run tasks")
+	}
+
+	if ProjectGuest.Can(CanRunProjectTasks) {
+		t.Fatal("guest must not run tasks")
+	}
+
+	if ProjectUserRole("root").IsValid() || ProjectUserRole("root").Can(CanRunProjectTasks) {
+		t.Fatal("unknown role must not have permissions")
+	}
+}
+
+func TestMaxProjectUserRole(t *testing.T) {
+	if MaxProjectUserRole(ProjectGuest, ProjectManager) != ProjectManager {
+		t.Fatal("manager is more privileged than guest")
+	}
+
+	if MaxProjectUserRole(ProjectOwner, ProjectTaskRunner) != ProjectOwner {
+		t.Fatal("owner is the most privileged role")
+	}
+}
+
+func TestProjectUserRole_IsAtLeast(t *testing.T) {
+	if !ProjectOwner.IsAtLeast(ProjectManager) || !ProjectManager.IsAtLeast(ProjectManager) {
+		t.Fatal("owner and manager are at least managers")
+	}
+
+	if ProjectTaskRunner.IsAtLeast(ProjectManager) {
+		t.Fatal("task runner is less privileged than manager")
+	}
+
+	if ProjectOwner.IsAtLeast("root") {
+		t.Fatal("unknown role must not be reached")
+	}
+}
+
+func TestUserWithProjectRole_MarshalJSON(t *testing.T) {
+	for role, admin := range map[ProjectUserRole]bool{
+		ProjectOwner:   true,
+		ProjectManager: false,
+	} {
+		bytes, err := json.Marshal(UserWithProjectRole{
+			Role: role,
+			User: User{ID: 1, Username: "user", Admin: !admin},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res map[string]interface{}
+		if err = json.Unmarshal(bytes, &res); err != nil {
+			t.Fatal(err)
+		}
+
+		if res["admin"] != admin || res["role"] != string(role) || res["username"] != "user" {
+			t.Fatalf("unexpected project member %s", bytes)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// RevisionObjectType is the type of objects whose changes are recorded as revisions.
+type RevisionObjectType string
+
+const (
+	RevisionTemplate    RevisionObjectType = "template"
+	RevisionInventory   RevisionObjectType = "inventory"
+	RevisionEnvironment RevisionObjectType = "environment"
+)
+
+// Revision is an immutable snapshot of a template, an inventory or an environment.
+// A revision is recorded every time the object changes.
+type Revision struct {
+	ID         int                `db:"id" json:"id"`
+	ProjectID  int                `db:"project_id" json:"project_id"`
+	ObjectType RevisionObjectType `db:"object_type" json:"object_type"`
+	ObjectID   int                `db:"object_id" json:"object_id"`
+	// Number is the sequence number of the revision of the object starting from 1.
+	Number int `db:"number" json:"number"`
+	// UserID is the author of the change, it is empty if the change was not made by a user.
+	UserID  *int      `db:"user_id" json:"user_id"`
+	Created time.Time `db:"created" json:"created"`
+	// Data is the object in JSON.
+	Data string `db:"data" json:"data"`
+}
+
+// RevisionChange is a field which differs between two revisions.
+type RevisionChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// CreateObjectRevision records the current state of the object as a new revision.
+// If the object has not changed since the last revision, the last revision is
+// returned and nothing is recorded.
+func CreateObjectRevision(d Store, projectID int, objectType RevisionObjectType, objectID int, object interface{}, userID *int) (revision Revision, err error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+
+	last, err := d.GetLastRevision(projectID, objectType, objectID)
+
+	switch err {
+	case nil:
+		if last.Data == string(data) {
+			return last, nil
+		}
+	case ErrNotFound:
+	default:
+		return
+	}
+
+	return d.CreateRevision(Revision{
+		ProjectID:  projectID,
+		ObjectType: objectType,
+		ObjectID:   objectID,
+		Number:     last.Number + 1,
+		UserID:     userID,
+		Created:    time.Now(),
+		Data:       string(data),
+	})
+}
+
+// RecordRevision records the object as it is stored now. It is called after
+// every change of the object.
+func RecordRevision(d Store, projectID int, objectType RevisionObjectType, objectID int, userID *int) (revision Revision, err error) {
+	var object interface{}
+
+	switch objectType {
+	case RevisionTemplate:
+		object, err = d.GetTemplate(projectID, objectID)
+	case RevisionInventory:
+		object, err = d.GetInventory(projectID, objectID)
+	case RevisionEnvironment:
+		object, err = d.GetEnvironment(projectID, objectID)
+	default:
+		err = ErrInvalidOperation
+	}
+
+	if err != nil {
+		return
+	}
+
+	return CreateObjectRevision(d, projectID, objectType, objectID, object, userID)
+}
+
+// DiffRevisions returns fields of the object which differ between the revisions.
+func DiffRevisions(from Revision, to Revision) (changes []RevisionChange, err error) {
+	var oldFields, newFields map[string]interface{}
+
+	if err = json.Unmarshal([]byte(from.Data), &oldFields); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal([]byte(to.Data), &newFields); err != nil {
+		return
+	}
+
+	fields := make([]string, 0, len(newFields))
+	for field := range newFields {
+		fields = append(fields, field)
+	}
+	for field := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes = []RevisionChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(oldFields[field], newFields[field]) {
+			changes = append(changes, RevisionChange{Field: field, Old: oldFields[field], New: newFields[field]})
+		}
+	}
+
+	return
+}
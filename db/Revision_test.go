@@ -0,0 +1,34 @@
+package db
+
+import "testing"
+
+func TestDiffRevisions(t *testing.T) {
+	from := Revision{Data: `{"playbook":"site.yml","arguments":null,"inventory_id":1}`}
+	to := Revision{Data: `{"playbook":"deploy.yml","arguments":"[\"-v\"]","inventory_id":1,"description":"Deploy"}`}
+
+	changes, err := DiffRevisions(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+
+	// changes are sorted by field names
+	if changes[0].Field != "arguments" || changes[0].Old != nil || changes[0].New != `["-v"]` {
+		t.Fatalf("unexpected change: %+v", changes[0])
+	}
+
+	if changes[1].Field != "description" || changes[1].Old != nil || changes[1].New != "Deploy" {
+		t.Fatalf("unexpected change: %+v", changes[1])
+	}
+
+	if changes[2].Field != "playbook" || changes[2].Old != "site.yml" || changes[2].New != "deploy.yml" {
+		t.Fatalf("unexpected change: %+v", changes[2])
+	}
+
+	if changes, _ = DiffRevisions(from, from); len(changes) != 0 {
+		t.Fatal("same revisions must not differ")
+	}
+}
@@ -2,6 +2,13 @@ package db
 
 import "time"
 
+const (
+	// SessionVerificationTotp requires the TOTP code to verify the session.
+	SessionVerificationTotp = "totp"
+	// SessionVerificationTotpEnrolment requires to enrol TOTP to verify the session.
+	SessionVerificationTotpEnrolment = "totp_enrolment"
+)
+
 // Session is a connection to the API
 type Session struct {
 	ID         int       `db:"id" json:"id"`
@@ -11,4 +18,22 @@ type Session struct {
 	IP         string    `db:"ip" json:"ip"`
 	UserAgent  string    `db:"user_agent" json:"user_agent"`
 	Expired    bool      `db:"expired" json:"expired"`
+
+	// VerificationMethod is the second step of the login required to verify the session.
+	VerificationMethod string `db:"verification_method" json:"verification_method"`
+	Verified           bool   `db:"verified" json:"verified"`
+}
+
+// IsVerified returns false if the session waits for the second step of the login.
+func (s Session) IsVerified() bool {
+	return s.VerificationMethod == "" || s.Verified
+}
+
+// IsActive returns false if the session is expired, has been inactive longer than idleTimeout
+// or is older than lifetime. Zero lifetime means that the session age is not limited.
+func (s Session) IsActive(now time.Time, idleTimeout time.Duration, lifetime time.Duration) bool {
+	if s.Expired || now.Sub(s.LastActive) > idleTimeout {
+		return false
+	}
+	return lifetime == 0 || now.Sub(s.Created) <= lifetime
 }
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_IsActive(t *testing.T) {
+	now := time.Now()
+	session := Session{Created: now.Add(-48 * time.Hour), LastActive: now.Add(-time.Hour)}
+
+	if !session.IsActive(now, 2*time.Hour, 0) {
+		t.Fatal("session age must not be limited by default")
+	}
+
+	if session.IsActive(now, 30*time.Minute, 0) {
+		t.Fatal("session must expire after the idle timeout")
+	}
+
+	if session.IsActive(now, 2*time.Hour, 24*time.Hour) {
+		t.Fatal("session must expire after its lifetime")
+	}
+}
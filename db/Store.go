@@ -3,6 +3,7 @@ package db
 import (
 	"errors"
 	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/util"
 	"time"
 )
 
@@ -43,6 +44,14 @@ func ValidateUsername(login string) error {
 	return nil
 }
 
+// ValidatePassword checks the password against the configured password policy.
+func ValidatePassword(password string) error {
+	if util.Config == nil {
+		return nil
+	}
+	return util.Config.PasswordPolicy.Validate(password)
+}
+
 type Transaction interface {}
 
 type Store interface {
@@ -50,6 +59,9 @@ type Store interface {
 	Close() error
 	Migrate() error
 
+	// Ping checks that the store is reachable.
+	Ping() error
+
 	GetEnvironment(projectID int, environmentID int) (Environment, error)
 	GetEnvironments(projectID int, params RetrieveQueryParams) ([]Environment, error)
 	UpdateEnvironment(env Environment) error
@@ -96,6 +108,10 @@ type Store interface {
 	SetUserPassword(userID int, password string) error
 	GetUser(userID int) (User, error)
 	GetUserByLoginOrEmail(login string, email string) (User, error)
+	// GetUserByOidcIdentity returns the user bound to the account of the OpenID Connect provider.
+	GetUserByOidcIdentity(issuer string, subject string) (User, error)
+	SetUserOidcIdentity(userID int, issuer string, subject string) error
+	VerifyUserEmail(userID int) error
 
 	GetProject(projectID int) (Project, error)
 	GetProjects(userID int) ([]Project, error)
@@ -116,12 +132,51 @@ type Store interface {
 	GetSchedule(projectID int, scheduleID int) (Schedule, error)
 	DeleteSchedule(projectID int, scheduleID int) error
 
-	GetProjectUsers(projectID int, params RetrieveQueryParams) ([]User, error)
+	GetTemplatePermissions(projectID int, templateID int) ([]TemplatePermission, error)
+	GetProjectTemplatePermissions(projectID int) ([]TemplatePermission, error)
+	CreateTemplatePermission(permission TemplatePermission) (TemplatePermission, error)
+	DeleteTemplatePermission(projectID int, permissionID int) error
+
+	GetProjectUsers(projectID int, params RetrieveQueryParams) ([]UserWithProjectRole, error)
 	CreateProjectUser(projectUser ProjectUser) (ProjectUser, error)
 	DeleteProjectUser(projectID int, userID int) error
 	GetProjectUser(projectID int, userID int) (ProjectUser, error)
 	UpdateProjectUser(projectUser ProjectUser) error
 
+	GetTeams(params RetrieveQueryParams) ([]Team, error)
+	GetTeam(teamID int) (Team, error)
+	CreateTeam(team Team) (Team, error)
+	UpdateTeam(team Team) error
+	DeleteTeam(teamID int) error
+	// GetUserTeams returns teams the user is a member of.
+	GetUserTeams(userID int) ([]Team, error)
+
+	GetTeamUsers(teamID int) ([]User, error)
+	CreateTeamUser(teamUser TeamUser) (TeamUser, error)
+	DeleteTeamUser(teamID int, userID int) error
+
+	GetProjectTeams(projectID int) ([]TeamWithProjectRole, error)
+	GetProjectTeam(projectID int, teamID int) (ProjectTeam, error)
+	CreateProjectTeam(projectTeam ProjectTeam) (ProjectTeam, error)
+	UpdateProjectTeam(projectTeam ProjectTeam) error
+	DeleteProjectTeam(projectID int, teamID int) error
+	// GetProjectTeamRoles returns roles given to the user in the project by teams.
+	GetProjectTeamRoles(projectID int, userID int) ([]ProjectUserRole, error)
+
+	GetProjectSync(projectID int) (ProjectSync, error)
+	// GetProjectSyncs returns syncs of all projects.
+	GetProjectSyncs() ([]ProjectSync, error)
+	// SetProjectSync creates or replaces the sync of the project.
+	SetProjectSync(sync ProjectSync) error
+	DeleteProjectSync(projectID int) error
+
+	CreateRevision(revision Revision) (Revision, error)
+	GetRevision(projectID int, revisionID int) (Revision, error)
+	// GetRevisions returns revisions of the object from the newest to the oldest.
+	GetRevisions(projectID int, objectType RevisionObjectType, objectID int) ([]Revision, error)
+	// GetLastRevision returns the newest revision of the object or ErrNotFound if the object has no revisions.
+	GetLastRevision(projectID int, objectType RevisionObjectType, objectID int) (Revision, error)
+
 	CreateEvent(event Event) (Event, error)
 	GetUserEvents(userID int, params RetrieveQueryParams) ([]Event, error)
 	GetEvents(projectID int, params RetrieveQueryParams) ([]Event, error)
@@ -130,21 +185,91 @@ type Store interface {
 	CreateAPIToken(token APIToken) (APIToken, error)
 	GetAPIToken(tokenID string) (APIToken, error)
 	ExpireAPIToken(userID int, tokenID string) error
+	TouchAPIToken(userID int, tokenID string, ip string) error
+
+	GetSubscriptions(userID int) ([]Subscription, error)
+	GetProjectSubscriptions(projectID int) ([]Subscription, error)
+	GetSubscription(userID int, subscriptionID int) (Subscription, error)
+	CreateSubscription(subscription Subscription) (Subscription, error)
+	UpdateSubscription(subscription Subscription) error
+	DeleteSubscription(userID int, subscriptionID int) error
 
 	GetSession(userID int, sessionID int) (Session, error)
 	CreateSession(session Session) (Session, error)
 	ExpireSession(userID int, sessionID int) error
+	// GetSessions returns not expired sessions of the user.
+	GetSessions(userID int) ([]Session, error)
+	// ExpireSessions expires all sessions of the user except the given one, 0 expires all sessions.
+	ExpireSessions(userID int, exceptSessionID int) error
 	TouchSession(userID int, sessionID int) error
+	VerifySession(userID int, sessionID int) error
+
+	CreateAccountToken(token AccountToken) (AccountToken, error)
+	GetAccountToken(tokenID string) (AccountToken, error)
+	// UseAccountToken marks the token as used, it returns ErrNotFound if the token is already used.
+	UseAccountToken(tokenID string) error
+
+	GetUserTotp(userID int) (UserTotp, error)
+	// CreateUserTotp replaces the existing TOTP of the user.
+	CreateUserTotp(totp UserTotp) (UserTotp, error)
+	UpdateUserTotp(totp UserTotp) error
+	DeleteUserTotp(userID int) error
 
 	CreateTask(task Task) (Task, error)
 	UpdateTask(task Task) error
 
 	GetTemplateTasks(projectID int, templateID int, params RetrieveQueryParams) ([]TaskWithTpl, error)
 	GetProjectTasks(projectID int, params RetrieveQueryParams) ([]TaskWithTpl, error)
+	// GetTaskStatRecords returns tasks matching the filter sorted by creation time.
+	GetTaskStatRecords(projectID int, filter TaskStatsFilter) ([]TaskStatRecord, error)
 	GetTask(projectID int, taskID int) (Task, error)
 	DeleteTaskWithOutputs(projectID int, taskID int) error
 	GetTaskOutputs(projectID int, taskID int) ([]TaskOutput, error)
 	CreateTaskOutput(output TaskOutput) (TaskOutput, error)
+	GetTaskFindings(projectID int, taskID int) ([]TaskFinding, error)
+	CreateTaskFinding(finding TaskFinding) (TaskFinding, error)
+	GetTaskHostStats(projectID int, taskID int) ([]TaskHostStat, error)
+	CreateTaskHostStat(stat TaskHostStat) (TaskHostStat, error)
+	// GetTasksByStatus returns tasks of all projects with the status.
+	GetTasksByStatus(status string) ([]Task, error)
+	GetTaskApprovals(projectID int, taskID int) ([]TaskApproval, error)
+	CreateTaskApproval(approval TaskApproval) (TaskApproval, error)
+}
+
+// GetProjectMemberRole returns the effective role of the user in the project: the union of
+// the role of the user and the roles of teams of the user. Permissions of roles are nested,
+// so the union is the most privileged of the roles. It returns ErrNotFound if the user
+// is not a member of the project.
+func GetProjectMemberRole(d Store, projectID int, userID int) (role ProjectUserRole, err error) {
+	isMember := false
+
+	projectUser, err := d.GetProjectUser(projectID, userID)
+
+	switch err {
+	case nil:
+		isMember = true
+		role = projectUser.Role
+	case ErrNotFound:
+		role = ProjectGuest
+	default:
+		return
+	}
+
+	teamRoles, err := d.GetProjectTeamRoles(projectID, userID)
+	if err != nil {
+		return
+	}
+
+	for _, teamRole := range teamRoles {
+		isMember = true
+		role = MaxProjectUserRole(role, teamRole)
+	}
+
+	if !isMember {
+		err = ErrNotFound
+	}
+
+	return
 }
 
 func FillTemplate(d Store, template *Template) (err error) {
@@ -288,6 +413,39 @@ var ScheduleProps = ObjectProperties{
 	PrimaryColumnName: "id",
 }
 
+var TemplatePermissionProps = ObjectProperties{
+	TableName:         "project__template_permission",
+	PrimaryColumnName: "id",
+}
+
+var TeamProps = ObjectProperties{
+	TableName:         "team",
+	IsGlobal:          true,
+	SortableColumns:   []string{"name"},
+	PrimaryColumnName: "id",
+}
+
+var TeamUserProps = ObjectProperties{
+	TableName:         "team__user",
+	PrimaryColumnName: "user_id",
+}
+
+var ProjectTeamProps = ObjectProperties{
+	TableName:         "project__team",
+	PrimaryColumnName: "team_id",
+}
+
+var ProjectSyncProps = ObjectProperties{
+	TableName:         "project__sync",
+	IsGlobal:          true,
+	PrimaryColumnName: "project_id",
+}
+
+var RevisionProps = ObjectProperties{
+	TableName:         "project__revision",
+	PrimaryColumnName: "id",
+}
+
 var ProjectUserProps = ObjectProperties{
 	TableName:         "project__user",
 	PrimaryColumnName: "user_id",
@@ -315,6 +473,22 @@ var TokenProps = ObjectProperties{
 	PrimaryColumnName: "id",
 }
 
+var AccountTokenProps = ObjectProperties{
+	TableName:         "account_token",
+	IsGlobal:          true,
+	PrimaryColumnName: "id",
+}
+
+var UserTotpProps = ObjectProperties{
+	TableName:         "user__totp",
+	PrimaryColumnName: "id",
+}
+
+var SubscriptionProps = ObjectProperties{
+	TableName:         "user__subscription",
+	PrimaryColumnName: "id",
+}
+
 var TaskProps = ObjectProperties{
 	TableName:         "task",
 	IsGlobal:          true,
@@ -325,3 +499,15 @@ var TaskProps = ObjectProperties{
 var TaskOutputProps = ObjectProperties{
 	TableName:         "task__output",
 }
+
+var TaskFindingProps = ObjectProperties{
+	TableName: "task__finding",
+}
+
+var TaskHostStatProps = ObjectProperties{
+	TableName: "task__host_stat",
+}
+
+var TaskApprovalProps = ObjectProperties{
+	TableName: "task__approval",
+}
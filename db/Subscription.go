@@ -0,0 +1,65 @@
+package db
+
+// Task outcome classes used to select alerts. A failure following another failure
+// of the same template is a repeated failure, a success following a failure is a recovery.
+const (
+	TaskOutcomeNewFailure      = "new_failure"
+	TaskOutcomeRepeatedFailure = "repeated_failure"
+	TaskOutcomeSuccess         = "success"
+	TaskOutcomeRecovered       = "recovered"
+	TaskOutcomeStopped         = "stopped"
+)
+
+// Subscription describes which task outcomes of a project (or of a single template)
+// a user wants to be alerted about and through which channels.
+type Subscription struct {
+	ID        int `db:"id" json:"id"`
+	UserID    int `db:"user_id" json:"user_id"`
+	ProjectID int `db:"project_id" json:"project_id" binding:"required"`
+	// TemplateID limits the subscription to one template, nil means all templates of the project
+	TemplateID *int `db:"template_id" json:"template_id"`
+
+	// OnFailure selects the first failure after a success
+	OnFailure bool `db:"on_failure" json:"on_failure"`
+	// OnRepeatedFailure selects failures following another failure
+	OnRepeatedFailure bool `db:"on_repeated_failure" json:"on_repeated_failure"`
+	OnSuccess         bool `db:"on_success" json:"on_success"`
+	OnRecovery        bool `db:"on_recovery" json:"on_recovery"`
+	OnStopped         bool `db:"on_stopped" json:"on_stopped"`
+
+	// RepeatedFailureEvery throttles repeated failure alerts: only every Nth failure
+	// in a row is reported. Zero or one reports every failure.
+	RepeatedFailureEvery int `db:"repeated_failure_every" json:"repeated_failure_every"`
+
+	Email    bool `db:"email" json:"email"`
+	Telegram bool `db:"telegram" json:"telegram"`
+	// TelegramChat overrides the chat of the project for this subscription
+	TelegramChat string `db:"telegram_chat" json:"telegram_chat"`
+}
+
+// Matches returns true if the subscription covers the given template and outcome.
+// failureStreak is the number of failures in a row including the current task.
+func (s Subscription) Matches(templateID int, outcome string, failureStreak int) bool {
+	if s.TemplateID != nil && *s.TemplateID != templateID {
+		return false
+	}
+
+	switch outcome {
+	case TaskOutcomeNewFailure:
+		return s.OnFailure
+	case TaskOutcomeRepeatedFailure:
+		if !s.OnRepeatedFailure {
+			return false
+		}
+		return s.RepeatedFailureEvery <= 1 || failureStreak%s.RepeatedFailureEvery == 0
+	case TaskOutcomeSuccess:
+		return s.OnSuccess
+	case TaskOutcomeRecovered:
+		// recovery is a success too
+		return s.OnRecovery || s.OnSuccess
+	case TaskOutcomeStopped:
+		return s.OnStopped
+	default:
+		return false
+	}
+}
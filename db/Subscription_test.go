@@ -0,0 +1,52 @@
+package db
+
+import "testing"
+
+func TestSubscription_Matches(t *testing.T) {
+	templateID := 3
+
+	sub := Subscription{
+		TemplateID: &templateID,
+		OnFailure:  true,
+		OnSuccess:  true,
+	}
+
+	if !sub.Matches(3, TaskOutcomeNewFailure, 1) {
+		t.Fatal("subscription must match failures of its template")
+	}
+
+	if sub.Matches(4, TaskOutcomeNewFailure, 1) {
+		t.Fatal("subscription must not match other templates")
+	}
+
+	if !sub.Matches(3, TaskOutcomeRecovered, 0) {
+		t.Fatal("recovery must match success subscriptions")
+	}
+
+	if sub.Matches(3, TaskOutcomeStopped, 0) {
+		t.Fatal("subscription must not match stopped tasks")
+	}
+
+	if sub.Matches(3, TaskOutcomeRepeatedFailure, 2) {
+		t.Fatal("subscription must not match repeated failures")
+	}
+
+	sub.TemplateID = nil
+
+	if !sub.Matches(4, TaskOutcomeNewFailure, 1) {
+		t.Fatal("project-wide subscription must match all templates")
+	}
+}
+
+func TestSubscription_MatchesThrottledRepeatedFailure(t *testing.T) {
+	sub := Subscription{
+		OnRepeatedFailure:    true,
+		RepeatedFailureEvery: 3,
+	}
+
+	for streak, expected := range map[int]bool{2: false, 3: true, 4: false, 5: false, 6: true} {
+		if sub.Matches(1, TaskOutcomeRepeatedFailure, streak) != expected {
+			t.Fatalf("unexpected result for failure streak %d", streak)
+		}
+	}
+}
@@ -12,6 +12,19 @@ type Task struct {
 	Debug  bool   `db:"debug" json:"debug"`
 
 	DryRun bool `db:"dry_run" json:"dry_run"`
+	// Diff shows changes made by the playbook, it is used with DryRun to preview them.
+	Diff bool `db:"diff" json:"diff"`
+
+	// AwaitApproval tasks are checks which pause in the awaiting_approval status when succeeded.
+	// Approving the check runs the same inputs and commit for real.
+	AwaitApproval bool `db:"await_approval" json:"await_approval"`
+	// CheckTaskID is the approved check this task applies.
+	CheckTaskID *int `db:"check_task_id" json:"check_task_id"`
+	// CommitHash is the commit of the repository the task ran with.
+	CommitHash *string `db:"commit_hash" json:"commit_hash"`
+
+	// ValidateOnly tasks run only the pre-flight validation of the playbook.
+	ValidateOnly bool `db:"validate_only" json:"validate_only"`
 
 	// override variables
 	Playbook    string `db:"playbook" json:"playbook"`
@@ -21,6 +34,11 @@ type Task struct {
 
 	UserID *int `db:"user_id" json:"user_id"`
 
+	// revisions of the template, the inventory and the environment the task ran with
+	TemplateRevisionID    *int `db:"template_revision_id" json:"template_revision_id"`
+	InventoryRevisionID   *int `db:"inventory_revision_id" json:"inventory_revision_id"`
+	EnvironmentRevisionID *int `db:"environment_revision_id" json:"environment_revision_id"`
+
 	Created time.Time  `db:"created" json:"created"`
 	Start   *time.Time `db:"start" json:"start"`
 	End     *time.Time `db:"end" json:"end"`
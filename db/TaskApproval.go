@@ -0,0 +1,11 @@
+package db
+
+import "time"
+
+// TaskApproval is a decision of the approver on the task of the template which requires approvals.
+type TaskApproval struct {
+	TaskID   int       `db:"task_id" json:"task_id"`
+	UserID   int       `db:"user_id" json:"user_id"`
+	Approved bool      `db:"approved" json:"approved"`
+	Created  time.Time `db:"created" json:"created"`
+}
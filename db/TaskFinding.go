@@ -0,0 +1,57 @@
+package db
+
+// FindingSeverity is the severity of a problem found by the pre-flight validation.
+// Severities are the ones of the codeclimate format of ansible-lint.
+type FindingSeverity string
+
+const (
+	FindingInfo     FindingSeverity = "info"
+	FindingMinor    FindingSeverity = "minor"
+	FindingMajor    FindingSeverity = "major"
+	FindingCritical FindingSeverity = "critical"
+	FindingBlocker  FindingSeverity = "blocker"
+)
+
+// findingSeverityLevels lists severities from the lowest to the highest.
+var findingSeverityLevels = []FindingSeverity{FindingInfo, FindingMinor, FindingMajor, FindingCritical, FindingBlocker}
+
+func (s FindingSeverity) level() int {
+	for i, level := range findingSeverityLevels {
+		if level == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsValid returns false for unknown severities.
+func (s FindingSeverity) IsValid() bool {
+	return s.level() >= 0
+}
+
+// Reaches returns true if the severity is the same as or higher than the threshold.
+// An empty threshold is never reached.
+func (s FindingSeverity) Reaches(threshold FindingSeverity) bool {
+	if !threshold.IsValid() {
+		return false
+	}
+	return s.level() >= threshold.level()
+}
+
+const (
+	// FindingToolSyntaxCheck reports errors of ansible-playbook --syntax-check.
+	FindingToolSyntaxCheck = "syntax-check"
+	// FindingToolLint reports violations of ansible-lint rules.
+	FindingToolLint = "ansible-lint"
+)
+
+// TaskFinding is a problem found by the pre-flight validation of the task.
+type TaskFinding struct {
+	TaskID   int             `db:"task_id" json:"task_id"`
+	Tool     string          `db:"tool" json:"tool"`
+	Rule     string          `db:"rule" json:"rule"`
+	Severity FindingSeverity `db:"severity" json:"severity"`
+	Message  string          `db:"message" json:"message"`
+	Path     string          `db:"path" json:"path"`
+	Line     int             `db:"line" json:"line"`
+}
@@ -0,0 +1,21 @@
+package db
+
+import "testing"
+
+func TestFindingSeverityReaches(t *testing.T) {
+	if !FindingMajor.Reaches(FindingMajor) || !FindingBlocker.Reaches(FindingMinor) {
+		t.Fatal("severities from the threshold must reach it")
+	}
+
+	if FindingMinor.Reaches(FindingMajor) {
+		t.Fatal("lower severities must not reach the threshold")
+	}
+
+	if FindingBlocker.Reaches("") {
+		t.Fatal("empty threshold must never be reached")
+	}
+
+	if FindingSeverity("fatal").IsValid() || !FindingInfo.IsValid() {
+		t.Fatal("unexpected validity of severities")
+	}
+}
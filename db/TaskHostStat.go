@@ -0,0 +1,14 @@
+package db
+
+// TaskHostStat is the play recap of a host of the task.
+type TaskHostStat struct {
+	TaskID      int    `db:"task_id" json:"task_id"`
+	Host        string `db:"host" json:"host"`
+	Ok          int    `db:"ok" json:"ok"`
+	Changed     int    `db:"changed" json:"changed"`
+	Unreachable int    `db:"unreachable" json:"unreachable"`
+	Failed      int    `db:"failed" json:"failed"`
+	Skipped     int    `db:"skipped" json:"skipped"`
+	Rescued     int    `db:"rescued" json:"rescued"`
+	Ignored     int    `db:"ignored" json:"ignored"`
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	taskStatSuccess = "success"
+	taskStatError   = "error"
+	taskStatStopped = "stopped"
+)
+
+// TaskStatRecord is the subset of task fields required to calculate statistics.
+type TaskStatRecord struct {
+	TemplateID int        `db:"template_id" json:"template_id"`
+	UserID     *int       `db:"user_id" json:"user_id"`
+	Status     string     `db:"status" json:"status"`
+	Created    time.Time  `db:"created" json:"created"`
+	Start      *time.Time `db:"start" json:"start"`
+	End        *time.Time `db:"end" json:"end"`
+}
+
+// TaskStatsFilter selects tasks created in the window [From, To)
+// of the whole project or of the single template.
+type TaskStatsFilter struct {
+	TemplateID *int
+	From       time.Time
+	To         time.Time
+}
+
+// TaskDayStats is the number of tasks created during the day (UTC).
+type TaskDayStats struct {
+	Date    string `json:"date"`
+	Total   int    `json:"total"`
+	Success int    `json:"success"`
+	Failed  int    `json:"failed"`
+	Stopped int    `json:"stopped"`
+}
+
+// TaskUserStats is the number of tasks started by the user.
+type TaskUserStats struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+}
+
+// TaskStats is the aggregated statistics of tasks. Ratios are calculated over finished tasks,
+// durations are in seconds and are nil if there is no data to calculate them.
+type TaskStats struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Total   int `json:"total"`
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	Stopped int `json:"stopped"`
+
+	SuccessRatio float64 `json:"success_ratio"`
+	FailureRatio float64 `json:"failure_ratio"`
+	StoppedRatio float64 `json:"stopped_ratio"`
+
+	DurationP50        *float64 `json:"duration_p50"`
+	DurationP95        *float64 `json:"duration_p95"`
+	MeanTimeToRecovery *float64 `json:"mean_time_to_recovery"`
+
+	RunsPerDay   []TaskDayStats  `json:"runs_per_day"`
+	BusiestUsers []TaskUserStats `json:"busiest_users"`
+}
+
+func (r TaskStatRecord) finishedAt() time.Time {
+	if r.End != nil {
+		return *r.End
+	}
+	return r.Created
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) *float64 {
+	if len(sorted) == 0 {
+		return nil
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	value := sorted[rank]
+	return &value
+}
+
+// meanTimeToRecovery returns the mean time between the first failure of a template
+// and its next success. Records must be sorted by creation time.
+func meanTimeToRecovery(records []TaskStatRecord) *float64 {
+	failedSince := make(map[int]time.Time)
+	var total float64
+	var recoveries int
+
+	for _, record := range records {
+		switch record.Status {
+		case taskStatError:
+			if _, ok := failedSince[record.TemplateID]; !ok {
+				failedSince[record.TemplateID] = record.finishedAt()
+			}
+		case taskStatSuccess:
+			since, ok := failedSince[record.TemplateID]
+			if !ok {
+				continue
+			}
+			delete(failedSince, record.TemplateID)
+			total += record.finishedAt().Sub(since).Seconds()
+			recoveries++
+		}
+	}
+
+	if recoveries == 0 {
+		return nil
+	}
+
+	mean := total / float64(recoveries)
+	return &mean
+}
+
+// CalculateTaskStats aggregates records of tasks created in the window [from, to).
+// Records must be sorted by creation time. At most topUsers busiest users are returned.
+func CalculateTaskStats(records []TaskStatRecord, from time.Time, to time.Time, topUsers int) TaskStats {
+	stats := TaskStats{
+		From:         from,
+		To:           to,
+		RunsPerDay:   []TaskDayStats{},
+		BusiestUsers: []TaskUserStats{},
+	}
+
+	days := make(map[string]int)
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+		date := day.Format("2006-01-02")
+		days[date] = len(stats.RunsPerDay)
+		stats.RunsPerDay = append(stats.RunsPerDay, TaskDayStats{Date: date})
+	}
+
+	userRuns := make(map[int]int)
+	var durations []float64
+
+	for _, record := range records {
+		stats.Total++
+
+		var day *TaskDayStats
+		if i, ok := days[record.Created.UTC().Format("2006-01-02")]; ok {
+			day = &stats.RunsPerDay[i]
+			day.Total++
+		}
+
+		switch record.Status {
+		case taskStatSuccess:
+			stats.Success++
+			if day != nil {
+				day.Success++
+			}
+		case taskStatError:
+			stats.Failed++
+			if day != nil {
+				day.Failed++
+			}
+		case taskStatStopped:
+			stats.Stopped++
+			if day != nil {
+				day.Stopped++
+			}
+		}
+
+		if record.UserID != nil {
+			userRuns[*record.UserID]++
+		}
+
+		if record.Start != nil && record.End != nil && record.Status != taskStatStopped {
+			durations = append(durations, record.End.Sub(*record.Start).Seconds())
+		}
+	}
+
+	if finished := stats.Success + stats.Failed + stats.Stopped; finished > 0 {
+		stats.SuccessRatio = float64(stats.Success) / float64(finished)
+		stats.FailureRatio = float64(stats.Failed) / float64(finished)
+		stats.StoppedRatio = float64(stats.Stopped) / float64(finished)
+	}
+
+	sort.Float64s(durations)
+	stats.DurationP50 = percentile(durations, 50)
+	stats.DurationP95 = percentile(durations, 95)
+	stats.MeanTimeToRecovery = meanTimeToRecovery(records)
+
+	for userID, count := range userRuns {
+		stats.BusiestUsers = append(stats.BusiestUsers, TaskUserStats{UserID: userID, Count: count})
+	}
+
+	sort.Slice(stats.BusiestUsers, func(i, j int) bool {
+		if stats.BusiestUsers[i].Count != stats.BusiestUsers[j].Count {
+			return stats.BusiestUsers[i].Count > stats.BusiestUsers[j].Count
+		}
+		return stats.BusiestUsers[i].UserID < stats.BusiestUsers[j].UserID
+	})
+
+	if len(stats.BusiestUsers) > topUsers {
+		stats.BusiestUsers = stats.BusiestUsers[:topUsers]
+	}
+
+	return stats
+}
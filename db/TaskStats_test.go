@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateTaskStats(t *testing.T) {
+	from := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(3 * 24 * time.Hour)
+
+	user1, user2 := 1, 2
+
+	record := func(templateID int, userID *int, status string, created time.Time, duration time.Duration) TaskStatRecord {
+		end := created.Add(duration)
+		return TaskStatRecord{
+			TemplateID: templateID,
+			UserID:     userID,
+			Status:     status,
+			Created:    created,
+			Start:      &created,
+			End:        &end,
+		}
+	}
+
+	records := []TaskStatRecord{
+		record(1, &user1, "success", from.Add(time.Hour), 10*time.Second),
+		record(1, &user1, "error", from.Add(2*time.Hour), 20*time.Second),
+		record(1, &user2, "error", from.Add(3*time.Hour), 30*time.Second),
+		record(1, &user1, "success", from.Add(26*time.Hour), 40*time.Second),
+		record(2, nil, "stopped", from.Add(50*time.Hour), 50*time.Second),
+	}
+
+	stats := CalculateTaskStats(records, from, to, 1)
+
+	if stats.Total != 5 || stats.Success != 2 || stats.Failed != 2 || stats.Stopped != 1 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+
+	if stats.SuccessRatio != 0.4 {
+		t.Fatalf("unexpected success ratio %f", stats.SuccessRatio)
+	}
+
+	if len(stats.RunsPerDay) != 3 || stats.RunsPerDay[0].Total != 3 || stats.RunsPerDay[1].Success != 1 || stats.RunsPerDay[2].Stopped != 1 {
+		t.Fatalf("unexpected runs per day: %+v", stats.RunsPerDay)
+	}
+
+	// durations of stopped tasks are ignored
+	if *stats.DurationP50 != 20 || *stats.DurationP95 != 40 {
+		t.Fatalf("unexpected durations: %f %f", *stats.DurationP50, *stats.DurationP95)
+	}
+
+	// recovered 26h40s after the first failure finished at 2h20s
+	expectedMTTR := (24*time.Hour + 20*time.Second).Seconds()
+	if *stats.MeanTimeToRecovery != expectedMTTR {
+		t.Fatalf("unexpected MTTR %f", *stats.MeanTimeToRecovery)
+	}
+
+	if len(stats.BusiestUsers) != 1 || stats.BusiestUsers[0].UserID != user1 || stats.BusiestUsers[0].Count != 3 {
+		t.Fatalf("unexpected busiest users: %+v", stats.BusiestUsers)
+	}
+}
+
+func TestCalculateTaskStatsEmpty(t *testing.T) {
+	from := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	stats := CalculateTaskStats(nil, from, from.Add(24*time.Hour), 5)
+
+	if stats.DurationP50 != nil || stats.MeanTimeToRecovery != nil || len(stats.RunsPerDay) != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import "time"
+
+// Team is a group of users which can be granted access to projects.
+type Team struct {
+	ID      int       `db:"id" json:"id"`
+	Name    string    `db:"name" json:"name" binding:"required"`
+	Created time.Time `db:"created" json:"created"`
+}
+
+// TeamUser is a member of the team.
+type TeamUser struct {
+	TeamID int `db:"team_id" json:"team_id"`
+	UserID int `db:"user_id" json:"user_id" binding:"required"`
+}
+
+// ProjectTeam gives the role in the project to all members of the team.
+type ProjectTeam struct {
+	ProjectID int             `db:"project_id" json:"project_id"`
+	TeamID    int             `db:"team_id" json:"team_id" binding:"required"`
+	Role      ProjectUserRole `db:"role" json:"role"`
+}
+
+// TeamWithProjectRole is a team attached to the project.
+type TeamWithProjectRole struct {
+	Role ProjectUserRole `db:"role" json:"role"`
+	Team
+}
@@ -20,8 +20,31 @@ type Template struct {
 
 	Removed bool `db:"removed" json:"-"`
 
+	// Managed templates are created from the project sync spec and are read-only.
+	Managed bool `db:"managed" json:"managed"`
+
 	Description *string `db:"description" json:"description"`
 
+	// Validate runs the pre-flight validation of the playbook before tasks.
+	Validate bool `db:"validate" json:"validate"`
+	// LintProfile is the ansible-lint profile used by the validation, ansible-lint is not run if it is empty.
+	LintProfile string `db:"lint_profile" json:"lint_profile"`
+	// LintThreshold is the lowest severity of lint findings which fails the task.
+	// Lint findings never fail the task if it is empty.
+	LintThreshold FindingSeverity `db:"lint_threshold" json:"lint_threshold"`
+
+	// CheckFirst launches run the playbook with --check --diff and wait for approval
+	// before the real run.
+	CheckFirst bool `db:"check_first" json:"check_first"`
+
+	// RequiredApprovals is the number of approvals of members with ApproverRole
+	// or a more privileged role needed to run tasks, tasks run without approvals if it is 0.
+	RequiredApprovals int             `db:"required_approvals" json:"required_approvals"`
+	ApproverRole      ProjectUserRole `db:"approver_role" json:"approver_role"`
+	// ApprovalExpiry is the time in minutes after which tasks pending approval
+	// are cancelled, tasks wait for approvals forever if it is 0.
+	ApprovalExpiry int `db:"approval_expiry" json:"approval_expiry"`
+
 	VaultPassID *int      `db:"vault_pass_id" json:"vault_pass_id"`
 	VaultPass   AccessKey `db:"-" json:"-"`
 }
\ No newline at end of file
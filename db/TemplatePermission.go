@@ -0,0 +1,103 @@
+package db
+
+// TemplateAccess is the level of access to a template. Every level includes the lower ones.
+type TemplateAccess string
+
+const (
+	TemplateNoAccess TemplateAccess = ""
+	TemplateView     TemplateAccess = "view"
+	TemplateRun      TemplateAccess = "run"
+	TemplateEdit     TemplateAccess = "edit"
+)
+
+// templateAccessLevels lists access levels from the lowest to the highest.
+var templateAccessLevels = []TemplateAccess{TemplateNoAccess, TemplateView, TemplateRun, TemplateEdit}
+
+func (a TemplateAccess) level() int {
+	for i, level := range templateAccessLevels {
+		if level == a {
+			return i
+		}
+	}
+	return 0
+}
+
+// IsValid returns false for unknown access levels.
+func (a TemplateAccess) IsValid() bool {
+	return a.level() > 0
+}
+
+// Allows returns true if the access level includes the required one.
+func (a TemplateAccess) Allows(required TemplateAccess) bool {
+	return a.level() >= required.level()
+}
+
+func minTemplateAccess(a TemplateAccess, b TemplateAccess) TemplateAccess {
+	if a.level() < b.level() {
+		return a
+	}
+	return b
+}
+
+// TemplatePermission grants access to the template to a member of the project or to a team.
+// A template without permissions is available to all members according to their roles.
+type TemplatePermission struct {
+	ID         int            `db:"id" json:"id"`
+	ProjectID  int            `db:"project_id" json:"project_id"`
+	TemplateID int            `db:"template_id" json:"template_id"`
+	UserID     *int           `db:"user_id" json:"user_id"`
+	TeamID     *int           `db:"team_id" json:"team_id"`
+	Access     TemplateAccess `db:"access" json:"access" binding:"required"`
+}
+
+// IsGrantedTo returns true if the permission is granted to the user or to one of the teams.
+func (p TemplatePermission) IsGrantedTo(userID int, teamIDs []int) bool {
+	if p.UserID != nil {
+		return *p.UserID == userID
+	}
+
+	if p.TeamID != nil {
+		for _, teamID := range teamIDs {
+			if *p.TeamID == teamID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetRoleTemplateAccess returns the access to templates given by the project role.
+func GetRoleTemplateAccess(role ProjectUserRole) TemplateAccess {
+	switch {
+	case role.Can(CanManageProjectResources):
+		return TemplateEdit
+	case role.Can(CanRunProjectTasks):
+		return TemplateRun
+	case role.IsValid():
+		return TemplateView
+	}
+	return TemplateNoAccess
+}
+
+// ResolveTemplateAccess returns the access of the project member who belongs to the teams
+// to the template with the given permissions. Permissions only restrict access: a member
+// gets the lower of the highest granted level and the level of the role. Members who manage
+// the project users are not restricted, so that they can always change permissions.
+func ResolveTemplateAccess(role ProjectUserRole, userID int, teamIDs []int, permissions []TemplatePermission) TemplateAccess {
+	roleAccess := GetRoleTemplateAccess(role)
+
+	if len(permissions) == 0 || role.Can(CanManageProjectUsers) {
+		return roleAccess
+	}
+
+	granted := TemplateNoAccess
+
+	for _, permission := range permissions {
+		if permission.IsGrantedTo(userID, teamIDs) && permission.Access.level() > granted.level() {
+			granted = permission.Access
+		}
+	}
+
+	return minTemplateAccess(granted, roleAccess)
+}
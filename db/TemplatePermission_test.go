@@ -0,0 +1,47 @@
+package db
+
+import "testing"
+
+func TestResolveTemplateAccess(t *testing.T) {
+	if ResolveTemplateAccess(ProjectTaskRunner, 1, nil, nil) != TemplateRun {
+		t.Fatal("template without permissions must be available according to the role")
+	}
+
+	user1, user2, team := 1, 2, 10
+
+	permissions := []TemplatePermission{
+		{UserID: &user1, Access: TemplateEdit},
+		{UserID: &user2, Access: TemplateView},
+		{TeamID: &team, Access: TemplateRun},
+	}
+
+	if ResolveTemplateAccess(ProjectTaskRunner, 1, nil, permissions) != TemplateRun {
+		t.Fatal("permission must not give more access than the role")
+	}
+
+	if ResolveTemplateAccess(ProjectManager, 2, nil, permissions) != TemplateView {
+		t.Fatal("permission must restrict access of the role")
+	}
+
+	if ResolveTemplateAccess(ProjectManager, 3, nil, permissions) != TemplateNoAccess {
+		t.Fatal("restricted template must be hidden from members without permissions")
+	}
+
+	if ResolveTemplateAccess(ProjectManager, 2, []int{team}, permissions) != TemplateRun {
+		t.Fatal("member must get the highest access granted to the member or to the teams")
+	}
+
+	if ResolveTemplateAccess(ProjectOwner, 3, nil, permissions) != TemplateEdit {
+		t.Fatal("owner must not be restricted")
+	}
+}
+
+func TestTemplateAccess_Allows(t *testing.T) {
+	if !TemplateEdit.Allows(TemplateRun) || TemplateView.Allows(TemplateRun) || TemplateNoAccess.Allows(TemplateView) {
+		t.Fatal("access levels must include the lower ones")
+	}
+
+	if TemplateAccess("admin").IsValid() || TemplateNoAccess.IsValid() || !TemplateRun.IsValid() {
+		t.Fatal("unknown access must be invalid")
+	}
+}
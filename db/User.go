@@ -15,6 +15,19 @@ type User struct {
 	Admin    bool      `db:"admin" json:"admin"`
 	External bool      `db:"external" json:"external"`
 	Alert    bool      `db:"alert" json:"alert"`
+
+	// EmailUnverified is set for new local users if email verification is enabled,
+	// they can not log in until they follow the link sent to their email.
+	EmailUnverified bool `db:"email_unverified" json:"email_unverified"`
+
+	// Service accounts are used by automation, they have no password
+	// and access the API only with their tokens.
+	Service bool `db:"service" json:"service"`
+
+	// OidcIssuer and OidcSubject identify the account of the OpenID Connect provider
+	// the user logs in with, they are empty for other users.
+	OidcIssuer  string `db:"oidc_issuer" json:"-"`
+	OidcSubject string `db:"oidc_subject" json:"-"`
 }
 
 // UserWithPwd extends User structure with field for unhashed password received from JSON.
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+// UserTotp is a TOTP second factor of the user. The enrolment is completed
+// when the user confirms it with a valid code. Only hashes of recovery codes are stored.
+type UserTotp struct {
+	ID            int       `db:"id" json:"-"`
+	UserID        int       `db:"user_id" json:"-"`
+	Secret        string    `db:"secret" json:"-"`
+	RecoveryCodes string    `db:"recovery_codes" json:"-"`
+	Confirmed     bool      `db:"confirmed" json:"confirmed"`
+	Created       time.Time `db:"created" json:"created"`
+
+	// LastStep is the time step of the last accepted code,
+	// codes can not be accepted again within their validity window.
+	LastStep int64 `db:"last_step" json:"-"`
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetRecoveryCodes replaces recovery codes with hashes of the given ones.
+func (t *UserTotp) SetRecoveryCodes(codes []string) {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	t.RecoveryCodes = strings.Join(hashes, " ")
+}
+
+// UseCode checks the TOTP code and remembers its time step if it is valid,
+// so every code can be used only once.
+func (t *UserTotp) UseCode(code string, now time.Time) bool {
+	step, ok := util.ValidateTotpCode(t.Secret, code, now, t.LastStep)
+	if ok {
+		t.LastStep = step
+	}
+	return ok
+}
+
+// UseRecoveryCode removes the recovery code if it is valid,
+// so every code can be used only once.
+func (t *UserTotp) UseRecoveryCode(code string) bool {
+	hash := hashRecoveryCode(code)
+	hashes := strings.Fields(t.RecoveryCodes)
+
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			t.RecoveryCodes = strings.Join(append(hashes[:i], hashes[i+1:]...), " ")
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+func TestUserTotp_UseCode(t *testing.T) {
+	secret, err := util.GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totp := UserTotp{Secret: secret}
+	now := time.Now()
+
+	code, _ := util.GetTotpCode(secret, now)
+	if !totp.UseCode(code, now) {
+		t.Fatal("valid code must be accepted")
+	}
+
+	if totp.UseCode(code, now) {
+		t.Fatal("code must be accepted only once")
+	}
+
+	previous, _ := util.GetTotpCode(secret, now.Add(-30*time.Second))
+	if totp.UseCode(previous, now) {
+		t.Fatal("code older than the accepted one must be rejected")
+	}
+
+	next, _ := util.GetTotpCode(secret, now.Add(30*time.Second))
+	if !totp.UseCode(next, now) {
+		t.Fatal("code of the next period must be accepted")
+	}
+}
+
+func TestUserTotp_UseRecoveryCode(t *testing.T) {
+	totp := UserTotp{}
+	totp.SetRecoveryCodes([]string{"abcde-12345", "fghij-67890"})
+
+	if totp.UseRecoveryCode("00000-00000") {
+		t.Fatal("unknown code must be rejected")
+	}
+
+	if !totp.UseRecoveryCode(" ABCDE12345 ") {
+		t.Fatal("code must be accepted regardless of case and separators")
+	}
+
+	if totp.UseRecoveryCode("abcde-12345") {
+		t.Fatal("code must be accepted only once")
+	}
+
+	if !totp.UseRecoveryCode("fghij-67890") {
+		t.Fatal("other codes must remain valid")
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/metrics"
 	"github.com/ansible-semaphore/semaphore/util"
 	"go.etcd.io/bbolt"
 	"reflect"
 	"sort"
+	"time"
 )
 
 const MaxID = 2147483647
@@ -62,10 +64,6 @@ func makeBucketId(props db.ObjectProperties, ids ...int) []byte {
 	return []byte(id)
 }
 
-func (d *BoltDb) Migrate() error {
-	return nil
-}
-
 func (d *BoltDb) Connect() error {
 	var filename string
 	if d.Filename == "" {
@@ -91,8 +89,27 @@ func (d *BoltDb) Close() error {
 	return d.db.Close()
 }
 
+func (d *BoltDb) Ping() error {
+	return d.view(func(tx *bbolt.Tx) error {
+		tx.Cursor().First()
+		return nil
+	})
+}
+
+// view executes a read-only transaction and records its latency.
+func (d *BoltDb) view(fn func(tx *bbolt.Tx) error) error {
+	defer metrics.ObserveDBCall("bolt", "view", time.Now())
+	return d.db.View(fn)
+}
+
+// update executes a read-write transaction and records its latency.
+func (d *BoltDb) update(fn func(tx *bbolt.Tx) error) error {
+	defer metrics.ObserveDBCall("bolt", "update", time.Now())
+	return d.db.Update(fn)
+}
+
 func (d *BoltDb) getObject(bucketID int, props db.ObjectProperties, objectID objectID, object interface{}) (err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -303,7 +320,7 @@ func unmarshalObjects(rawData enumerable, props db.ObjectProperties, params db.R
 }
 
 func (d *BoltDb) getObjects(bucketID int, props db.ObjectProperties, params db.RetrieveQueryParams, filter func(interface{}) bool, objects interface{}) error {
-	return d.db.View(func(tx *bbolt.Tx) error {
+	return d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		var c enumerable
 		if b == nil {
@@ -387,7 +404,7 @@ func (d *BoltDb) deleteObject(bucketID int, props db.ObjectProperties, objectID
 		}
 	}
 
-	return d.db.Update(func (tx *bbolt.Tx) error {
+	return d.update(func (tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -400,7 +417,7 @@ func (d *BoltDb) deleteObjectSoft(bucketID int, props db.ObjectProperties, objec
 	var data map[string]interface{}
 
 	// load data
-	err := d.db.View(func(tx *bbolt.Tx) error {
+	err := d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -433,7 +450,7 @@ func (d *BoltDb) deleteObjectSoft(bucketID int, props db.ObjectProperties, objec
 		return err
 	}
 
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -445,7 +462,7 @@ func (d *BoltDb) deleteObjectSoft(bucketID int, props db.ObjectProperties, objec
 
 // updateObject updates data for object in database.
 func (d *BoltDb) updateObject(bucketID int, props db.ObjectProperties, object interface{}) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(makeBucketId(props, bucketID))
 		if b == nil {
 			return db.ErrNotFound
@@ -495,7 +512,7 @@ func (d *BoltDb) updateObject(bucketID int, props db.ObjectProperties, object in
 }
 
 func (d *BoltDb) createObject(bucketID int, props db.ObjectProperties, object interface{}) (interface{}, error) {
-	err := d.db.Update(func(tx *bbolt.Tx) error {
+	err := d.update(func(tx *bbolt.Tx) error {
 		b, err := tx.CreateBucketIfNotExists(makeBucketId(props, bucketID))
 
 		if err != nil {
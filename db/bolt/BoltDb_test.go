@@ -98,7 +98,7 @@ func TestMarshalObject_UserWithPwd(t *testing.T) {
 
 	str := string(bytes)
 
-	if str != `{"id":0,"created":"0001-01-01T00:00:00Z","username":"fiftin","name":"","email":"","password":"345345234523452345234","admin":false,"external":false,"alert":false}` {
+	if str != `{"id":0,"created":"0001-01-01T00:00:00Z","username":"fiftin","name":"","email":"","password":"345345234523452345234","admin":false,"external":false,"alert":false,"email_unverified":false,"service":false,"oidc_issuer":"","oidc_subject":""}` {
 		t.Fatal(fmt.Errorf("incorrect marshalling result"))
 	}
 
@@ -0,0 +1,47 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) CreateAccountToken(token db.AccountToken) (db.AccountToken, error) {
+	token.Created = time.Now()
+
+	newToken, err := d.createObject(0, db.AccountTokenProps, token)
+	if err != nil {
+		return db.AccountToken{}, err
+	}
+
+	return newToken.(db.AccountToken), nil
+}
+
+func (d *BoltDb) GetAccountToken(tokenID string) (token db.AccountToken, err error) {
+	err = d.getObject(0, db.AccountTokenProps, strObjectID(tokenID), &token)
+	return
+}
+
+func (d *BoltDb) UseAccountToken(tokenID string) error {
+	return d.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(makeBucketId(db.AccountTokenProps, 0))
+		if b == nil {
+			return db.ErrNotFound
+		}
+
+		var token db.AccountToken
+		if err := unmarshalObject(b.Get(strObjectID(tokenID).ToBytes()), &token); err != nil || token.Used {
+			return db.ErrNotFound
+		}
+
+		token.Used = true
+
+		data, err := marshalObject(token)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(strObjectID(tokenID).ToBytes(), data)
+	})
+}
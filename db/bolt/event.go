@@ -73,7 +73,7 @@ func (d *BoltDb) CreateEvent(evt db.Event) (newEvent db.Event, err error) {
 	newEvent = evt
 	newEvent.Created = time.Now()
 
-	err = d.db.Update(func(tx *bbolt.Tx) error {
+	err = d.update(func(tx *bbolt.Tx) error {
 		b, err2 := tx.CreateBucketIfNotExists([]byte("events"))
 		if err2 != nil {
 			return err2
@@ -98,7 +98,7 @@ func (d *BoltDb) CreateEvent(evt db.Event) (newEvent db.Event, err error) {
 }
 
 func (d *BoltDb) GetUserEvents(userID int, params db.RetrieveQueryParams) (events []db.Event, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("events"))
 		if b == nil {
 			return nil
@@ -109,7 +109,7 @@ func (d *BoltDb) GetUserEvents(userID int, params db.RetrieveQueryParams) (event
 			if evt.ProjectID == nil {
 				return false
 			}
-			_, err2 := d.GetProjectUser(*evt.ProjectID, userID)
+			_, err2 := db.GetProjectMemberRole(d, *evt.ProjectID, userID)
 			return err2 == nil
 		})
 
@@ -120,7 +120,7 @@ func (d *BoltDb) GetUserEvents(userID int, params db.RetrieveQueryParams) (event
 }
 
 func (d *BoltDb) GetEvents(projectID int, params db.RetrieveQueryParams) (events []db.Event, err error) {
-	err = d.db.View(func(tx *bbolt.Tx) error {
+	err = d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("events"))
 		if b == nil {
 			return nil
@@ -0,0 +1,165 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+// migrateProjectUserRoles replaces the admin flag of project members with roles.
+// Admins become owners, other members become managers. Members which already
+// have a role are not changed, so the migration can be applied many times.
+func migrateProjectUserRoles(tx *bbolt.Tx) error {
+	prefix := []byte(db.ProjectUserProps.TableName + "_")
+
+	return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		// the bucket must not be modified while iterating over it
+		changes := make(map[string][]byte)
+
+		err := b.ForEach(func(k, v []byte) error {
+			var obj map[string]interface{}
+			if err := json.Unmarshal(v, &obj); err != nil {
+				return err
+			}
+
+			if _, ok := obj["role"]; ok {
+				return nil
+			}
+
+			role := db.ProjectManager
+			if admin, ok := obj["admin"].(bool); ok && admin {
+				role = db.ProjectOwner
+			}
+
+			obj["role"] = role
+			delete(obj, "admin")
+
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+
+			changes[string(k)] = data
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for k, v := range changes {
+			if err = b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// migrateAPITokens replaces API tokens stored in plain text by their hashes.
+// Tokens created before scopes were introduced had all rights of the user,
+// so they get the admin scope. Tokens with scopes are already hashed.
+func migrateAPITokens(tx *bbolt.Tx) error {
+	prefix := []byte(db.TokenProps.TableName + "_")
+
+	// hashes of migrated tokens by their old IDs
+	hashes := make(map[string]string)
+
+	err := tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		changes := make(map[string][]byte)
+
+		err := b.ForEach(func(k, v []byte) error {
+			var obj map[string]interface{}
+			if err := json.Unmarshal(v, &obj); err != nil {
+				return err
+			}
+
+			if scopes, ok := obj["scopes"].(string); ok && scopes != "" {
+				return nil
+			}
+
+			hash := db.HashAPIToken(string(k))
+			obj["id"] = hash
+			obj["scopes"] = db.APITokenScopeAdmin
+
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+
+			hashes[string(k)] = hash
+			changes[string(k)] = data
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for k, v := range changes {
+			if err = b.Delete([]byte(k)); err != nil {
+				return err
+			}
+			if err = b.Put([]byte(hashes[k]), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil || len(hashes) == 0 {
+		return err
+	}
+
+	// the global index of tokens is keyed by token IDs too
+	b := tx.Bucket(makeBucketId(globalTokenObject, 0))
+	if b == nil {
+		return nil
+	}
+
+	for id, hash := range hashes {
+		v := b.Get([]byte(id))
+		if v == nil {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err = json.Unmarshal(v, &obj); err != nil {
+			return err
+		}
+		obj["id"] = hash
+
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		if err = b.Delete([]byte(id)); err != nil {
+			return err
+		}
+		if err = b.Put([]byte(hash), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *BoltDb) Migrate() error {
+	if err := d.update(migrateProjectUserRoles); err != nil {
+		return err
+	}
+	return d.update(migrateAPITokens)
+}
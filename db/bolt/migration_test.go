@@ -0,0 +1,116 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func TestMigrateProjectUserRoles(t *testing.T) {
+	store := createBoltDb()
+	err := store.Connect()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = store.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(makeBucketId(db.ProjectUserProps, 1))
+		if err != nil {
+			return err
+		}
+		if err = b.Put(intObjectID(1).ToBytes(), []byte(`{"project_id":1,"user_id":1,"admin":true}`)); err != nil {
+			return err
+		}
+		if err = b.Put(intObjectID(2).ToBytes(), []byte(`{"project_id":1,"user_id":2,"admin":false}`)); err != nil {
+			return err
+		}
+		return b.Put(intObjectID(3).ToBytes(), []byte(`{"project_id":1,"user_id":3,"role":"guest"}`))
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the migration must not change already converted members
+	for i := 0; i < 2; i++ {
+		if err = store.Migrate(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	expected := map[int]db.ProjectUserRole{1: db.ProjectOwner, 2: db.ProjectManager, 3: db.ProjectGuest}
+
+	for userID, role := range expected {
+		user, err := store.GetProjectUser(1, userID)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if user.Role != role {
+			t.Fatalf("user %d must have role %s, got %s", userID, role, user.Role)
+		}
+	}
+}
+
+func TestMigrateAPITokens(t *testing.T) {
+	store := createBoltDb()
+	err := store.Connect()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = store.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(makeBucketId(db.TokenProps, 1))
+		if err != nil {
+			return err
+		}
+		if err = b.Put([]byte("legacytoken"), []byte(`{"id":"legacytoken","created":"2021-01-01T00:00:00Z","expired":false,"user_id":1}`)); err != nil {
+			return err
+		}
+
+		b, err = tx.CreateBucketIfNotExists(makeBucketId(globalTokenObject, 0))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("legacytoken"), []byte(`{"id":"legacytoken","user_id":1}`))
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	scoped, err := store.CreateAPIToken(db.APIToken{ID: db.HashAPIToken("scopedtoken"), UserID: 1, Scopes: "read"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the migration must not change already hashed tokens
+	for i := 0; i < 2; i++ {
+		if err = store.Migrate(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if _, err = store.GetAPIToken("legacytoken"); err == nil {
+		t.Fatal("token must not be found by the plain text")
+	}
+
+	token, err := store.GetAPIToken(db.HashAPIToken("legacytoken"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.UserID != 1 || !token.IsAdmin() || !token.IsActive(time.Now()) {
+		t.Fatal("legacy token must keep all rights of the user")
+	}
+
+	token, err = store.GetAPIToken(scoped.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.Scopes != "read" {
+		t.Fatal("scopes of hashed tokens must not be changed")
+	}
+}
@@ -29,7 +29,7 @@ func (d *BoltDb) GetProjects(userID int) (projects []db.Project, err error) {
 	}
 
 	for _, v := range allProjects {
-		_, err2 := d.GetProjectUser(v.ID, userID)
+		_, err2 := db.GetProjectMemberRole(d, v.ID, userID)
 		if err2 == nil {
 			projects = append(projects, v)
 		} else if err2 != db.ErrNotFound {
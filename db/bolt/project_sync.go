@@ -0,0 +1,36 @@
+package bolt
+
+import (
+	"github.com/ansible-semaphore/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) GetProjectSync(projectID int) (sync db.ProjectSync, err error) {
+	err = d.getObject(0, db.ProjectSyncProps, intObjectID(projectID), &sync)
+	return
+}
+
+func (d *BoltDb) GetProjectSyncs() (syncs []db.ProjectSync, err error) {
+	err = d.getObjects(0, db.ProjectSyncProps, db.RetrieveQueryParams{}, nil, &syncs)
+	return
+}
+
+func (d *BoltDb) SetProjectSync(sync db.ProjectSync) error {
+	return d.update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(makeBucketId(db.ProjectSyncProps, 0))
+		if err != nil {
+			return err
+		}
+
+		str, err := marshalObject(sync)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(intObjectID(sync.ProjectID).ToBytes(), str)
+	})
+}
+
+func (d *BoltDb) DeleteProjectSync(projectID int) error {
+	return d.deleteObject(0, db.ProjectSyncProps, intObjectID(projectID))
+}
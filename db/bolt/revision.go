@@ -0,0 +1,48 @@
+package bolt
+
+import (
+	"sort"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func (d *BoltDb) CreateRevision(revision db.Revision) (db.Revision, error) {
+	newRevision, err := d.createObject(revision.ProjectID, db.RevisionProps, revision)
+	if err != nil {
+		return db.Revision{}, err
+	}
+	return newRevision.(db.Revision), nil
+}
+
+func (d *BoltDb) GetRevision(projectID int, revisionID int) (revision db.Revision, err error) {
+	err = d.getObject(projectID, db.RevisionProps, intObjectID(revisionID), &revision)
+	return
+}
+
+func (d *BoltDb) GetRevisions(projectID int, objectType db.RevisionObjectType, objectID int) (revisions []db.Revision, err error) {
+	err = d.getObjects(projectID, db.RevisionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		revision := i.(db.Revision)
+		return revision.ObjectType == objectType && revision.ObjectID == objectID
+	}, &revisions)
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number > revisions[j].Number
+	})
+
+	return
+}
+
+func (d *BoltDb) GetLastRevision(projectID int, objectType db.RevisionObjectType, objectID int) (revision db.Revision, err error) {
+	revisions, err := d.GetRevisions(projectID, objectType, objectID)
+	if err != nil {
+		return
+	}
+
+	if len(revisions) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+
+	revision = revisions[0]
+	return
+}
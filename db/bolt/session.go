@@ -2,12 +2,14 @@ package bolt
 
 import (
 	"github.com/ansible-semaphore/semaphore/db"
+	"sort"
 	"time"
 )
 
 
 var globalTokenObject = db.ObjectProperties{
-	TableName:          "token",
+	TableName:         "token",
+	PrimaryColumnName: "id",
 }
 
 type globalToken struct {
@@ -61,8 +63,56 @@ func (d *BoltDb) ExpireAPIToken(userID int, tokenID string) (err error) {
 	return
 }
 
+func (d *BoltDb) TouchAPIToken(userID int, tokenID string, ip string) (err error) {
+	var token db.APIToken
+	err = d.getObject(userID, db.TokenProps, strObjectID(tokenID), &token)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	token.LastUsed = &now
+	token.LastIP = ip
+	err = d.updateObject(userID, db.TokenProps, token)
+	return
+}
+
 func (d *BoltDb) GetSession(userID int, sessionID int) (session db.Session, err error) {
 	err = d.getObject(userID, db.SessionProps, intObjectID(sessionID), &session)
+	if err == nil && session.Expired {
+		err = db.ErrNotFound
+	}
+	return
+}
+
+func (d *BoltDb) GetSessions(userID int) (sessions []db.Session, err error) {
+	err = d.getObjects(userID, db.SessionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return !i.(db.Session).Expired
+	}, &sessions)
+	if err != nil {
+		return
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActive.After(sessions[j].LastActive)
+	})
+	return
+}
+
+func (d *BoltDb) ExpireSessions(userID int, exceptSessionID int) (err error) {
+	sessions, err := d.GetSessions(userID)
+	if err != nil {
+		return
+	}
+
+	for _, session := range sessions {
+		if session.ID == exceptSessionID {
+			continue
+		}
+		session.Expired = true
+		if err = d.updateObject(userID, db.SessionProps, session); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -88,8 +138,19 @@ func (d *BoltDb) TouchSession(userID int, sessionID int) (err error) {
 	return
 }
 
+func (d *BoltDb) VerifySession(userID int, sessionID int) (err error) {
+	var session db.Session
+	err = d.getObject(userID, db.SessionProps, intObjectID(sessionID), &session)
+	if err != nil {
+		return
+	}
+	session.Verified = true
+	err = d.updateObject(userID, db.SessionProps, session)
+	return
+}
+
 func (d *BoltDb) GetAPITokens(userID int) (tokens []db.APIToken, err error) {
-	err = d.getObjects(userID, db.SessionProps, db.RetrieveQueryParams{}, nil, &tokens)
+	err = d.getObjects(userID, db.TokenProps, db.RetrieveQueryParams{}, nil, &tokens)
 	return
 }
 
@@ -0,0 +1,50 @@
+package bolt
+
+import "github.com/ansible-semaphore/semaphore/db"
+
+func (d *BoltDb) GetSubscriptions(userID int) (subscriptions []db.Subscription, err error) {
+	err = d.getObjects(userID, db.SubscriptionProps, db.RetrieveQueryParams{}, nil, &subscriptions)
+	return
+}
+
+func (d *BoltDb) GetProjectSubscriptions(projectID int) (subscriptions []db.Subscription, err error) {
+	// subscriptions are stored by users, members of the project can be added directly or by teams
+	users, err := d.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		var userSubscriptions []db.Subscription
+		err = d.getObjects(user.ID, db.SubscriptionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+			return i.(db.Subscription).ProjectID == projectID
+		}, &userSubscriptions)
+		if err != nil {
+			return
+		}
+		subscriptions = append(subscriptions, userSubscriptions...)
+	}
+
+	return
+}
+
+func (d *BoltDb) GetSubscription(userID int, subscriptionID int) (subscription db.Subscription, err error) {
+	err = d.getObject(userID, db.SubscriptionProps, intObjectID(subscriptionID), &subscription)
+	return
+}
+
+func (d *BoltDb) CreateSubscription(subscription db.Subscription) (db.Subscription, error) {
+	newSubscription, err := d.createObject(subscription.UserID, db.SubscriptionProps, subscription)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	return newSubscription.(db.Subscription), nil
+}
+
+func (d *BoltDb) UpdateSubscription(subscription db.Subscription) error {
+	return d.updateObject(subscription.UserID, db.SubscriptionProps, subscription)
+}
+
+func (d *BoltDb) DeleteSubscription(userID int, subscriptionID int) error {
+	return d.deleteObject(userID, db.SubscriptionProps, intObjectID(subscriptionID))
+}
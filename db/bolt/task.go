@@ -97,6 +97,55 @@ func (d *BoltDb) GetProjectTasks(projectID int, params db.RetrieveQueryParams) (
 	return d.getTasks(projectID, nil, params)
 }
 
+// GetTaskStatRecords iterates tasks from the newest to the oldest one
+// and stops at the first task created before the window.
+func (d *BoltDb) GetTaskStatRecords(projectID int, filter db.TaskStatsFilter) (records []db.TaskStatRecord, err error) {
+	err = d.view(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(makeBucketId(db.TaskProps, 0))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var task db.Task
+			if err := unmarshalObject(v, &task); err != nil {
+				return err
+			}
+
+			if task.Created.Before(filter.From) {
+				break
+			}
+
+			if task.ProjectID != projectID || !task.Created.Before(filter.To) {
+				continue
+			}
+
+			if filter.TemplateID != nil && task.TemplateID != *filter.TemplateID {
+				continue
+			}
+
+			records = append(records, db.TaskStatRecord{
+				TemplateID: task.TemplateID,
+				UserID:     task.UserID,
+				Status:     task.Status,
+				Created:    task.Created,
+				Start:      task.Start,
+				End:        task.End,
+			})
+		}
+
+		return nil
+	})
+
+	// reverse to sort by creation time
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	return
+}
+
 func (d *BoltDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 	// check if task exists in the project
 	_, err = d.GetTask(projectID, taskID)
@@ -110,10 +159,22 @@ func (d *BoltDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 		return
 	}
 
-	_ = d.db.Update(func(tx *bbolt.Tx) error {
+	_ = d.update(func(tx *bbolt.Tx) error {
 		return tx.DeleteBucket(makeBucketId(db.TaskOutputProps, taskID))
 	})
 
+	_ = d.update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(makeBucketId(db.TaskFindingProps, taskID))
+	})
+
+	_ = d.update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(makeBucketId(db.TaskHostStatProps, taskID))
+	})
+
+	_ = d.update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(makeBucketId(db.TaskApprovalProps, taskID))
+	})
+
 	return
 }
 
@@ -129,3 +190,74 @@ func (d *BoltDb) GetTaskOutputs(projectID int, taskID int) (outputs []db.TaskOut
 
 	return
 }
+
+func (d *BoltDb) GetTaskFindings(projectID int, taskID int) (findings []db.TaskFinding, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	err = d.getObjects(taskID, db.TaskFindingProps, db.RetrieveQueryParams{}, nil, &findings)
+
+	return
+}
+
+func (d *BoltDb) CreateTaskFinding(finding db.TaskFinding) (db.TaskFinding, error) {
+	newFinding, err := d.createObject(finding.TaskID, db.TaskFindingProps, finding)
+	if err != nil {
+		return db.TaskFinding{}, err
+	}
+	return newFinding.(db.TaskFinding), nil
+}
+
+func (d *BoltDb) GetTaskHostStats(projectID int, taskID int) (stats []db.TaskHostStat, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	err = d.getObjects(taskID, db.TaskHostStatProps, db.RetrieveQueryParams{}, nil, &stats)
+
+	return
+}
+
+func (d *BoltDb) CreateTaskHostStat(stat db.TaskHostStat) (db.TaskHostStat, error) {
+	newStat, err := d.createObject(stat.TaskID, db.TaskHostStatProps, stat)
+	if err != nil {
+		return db.TaskHostStat{}, err
+	}
+	return newStat.(db.TaskHostStat), nil
+}
+
+func (d *BoltDb) GetTasksByStatus(status string) (tasks []db.Task, err error) {
+	err = d.getObjects(0, db.TaskProps, db.RetrieveQueryParams{}, func(tsk interface{}) bool {
+		return tsk.(db.Task).Status == status
+	}, &tasks)
+
+	return
+}
+
+func (d *BoltDb) GetTaskApprovals(projectID int, taskID int) (approvals []db.TaskApproval, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	err = d.getObjects(taskID, db.TaskApprovalProps, db.RetrieveQueryParams{}, nil, &approvals)
+
+	return
+}
+
+func (d *BoltDb) CreateTaskApproval(approval db.TaskApproval) (db.TaskApproval, error) {
+	newApproval, err := d.createObject(approval.TaskID, db.TaskApprovalProps, approval)
+	if err != nil {
+		return db.TaskApproval{}, err
+	}
+	return newApproval.(db.TaskApproval), nil
+}
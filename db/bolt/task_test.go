@@ -0,0 +1,86 @@
+package bolt
+
+import (
+	"github.com/ansible-semaphore/semaphore/db"
+	"testing"
+	"time"
+)
+
+func TestGetTaskStatRecords(t *testing.T) {
+	store := createStore()
+	err := store.Connect()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i, status := range []string{"success", "error", "success"} {
+		_, err = store.CreateTask(db.Task{
+			ProjectID:  1,
+			TemplateID: i%2 + 1,
+			Status:     status,
+		})
+
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	_, err = store.CreateTask(db.Task{
+		ProjectID:  2,
+		TemplateID: 3,
+		Status:     "success",
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	now := time.Now()
+
+	records, err := store.GetTaskStatRecords(1, db.TaskStatsFilter{
+		From: now.Add(-time.Hour),
+		To:   now.Add(time.Hour),
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	if records[0].Status != "success" || records[1].Status != "error" {
+		t.Fatal("records must be sorted by creation time")
+	}
+
+	templateID := 2
+
+	records, err = store.GetTaskStatRecords(1, db.TaskStatsFilter{
+		TemplateID: &templateID,
+		From:       now.Add(-time.Hour),
+		To:         now.Add(time.Hour),
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	records, err = store.GetTaskStatRecords(1, db.TaskStatsFilter{
+		From: now.Add(time.Hour),
+		To:   now.Add(2 * time.Hour),
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(records) != 0 {
+		t.Fatalf("expected no records, got %d", len(records))
+	}
+}
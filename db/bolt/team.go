@@ -0,0 +1,190 @@
+package bolt
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) GetTeams(params db.RetrieveQueryParams) (teams []db.Team, err error) {
+	if params.SortBy == "" {
+		params.SortBy = "name"
+	}
+	err = d.getObjects(0, db.TeamProps, params, nil, &teams)
+	return
+}
+
+func (d *BoltDb) GetTeam(teamID int) (team db.Team, err error) {
+	err = d.getObject(0, db.TeamProps, intObjectID(teamID), &team)
+	return
+}
+
+func (d *BoltDb) CreateTeam(team db.Team) (db.Team, error) {
+	team.Created = time.Now()
+
+	newTeam, err := d.createObject(0, db.TeamProps, team)
+	if err != nil {
+		return db.Team{}, err
+	}
+
+	return newTeam.(db.Team), nil
+}
+
+func (d *BoltDb) UpdateTeam(team db.Team) error {
+	return d.updateObject(0, db.TeamProps, team)
+}
+
+// DeleteTeam deletes the team with its members and detaches it from projects.
+func (d *BoltDb) DeleteTeam(teamID int) error {
+	if _, err := d.GetTeam(teamID); err != nil {
+		return err
+	}
+
+	return d.update(func(tx *bbolt.Tx) error {
+		projectTeamPrefix := []byte(db.ProjectTeamProps.TableName + "_")
+
+		err := tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if !bytes.HasPrefix(name, projectTeamPrefix) {
+				return nil
+			}
+			return b.Delete(intObjectID(teamID).ToBytes())
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.DeleteBucket(makeBucketId(db.TeamUserProps, teamID))
+		if err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+
+		return tx.Bucket(makeBucketId(db.TeamProps, 0)).Delete(intObjectID(teamID).ToBytes())
+	})
+}
+
+func (d *BoltDb) GetUserTeams(userID int) (teams []db.Team, err error) {
+	allTeams, err := d.GetTeams(db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, team := range allTeams {
+		var teamUser db.TeamUser
+		err = d.getObject(team.ID, db.TeamUserProps, intObjectID(userID), &teamUser)
+
+		if err == db.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return
+		}
+
+		teams = append(teams, team)
+	}
+
+	err = nil
+	return
+}
+
+func (d *BoltDb) GetTeamUsers(teamID int) (users []db.User, err error) {
+	var teamUsers []db.TeamUser
+	err = d.getObjects(teamID, db.TeamUserProps, db.RetrieveQueryParams{}, nil, &teamUsers)
+	if err != nil {
+		return
+	}
+
+	for _, teamUser := range teamUsers {
+		var user db.User
+		user, err = d.GetUser(teamUser.UserID)
+		if err != nil {
+			return
+		}
+		users = append(users, user)
+	}
+
+	return
+}
+
+func (d *BoltDb) CreateTeamUser(teamUser db.TeamUser) (db.TeamUser, error) {
+	newTeamUser, err := d.createObject(teamUser.TeamID, db.TeamUserProps, teamUser)
+	if err != nil {
+		return db.TeamUser{}, err
+	}
+
+	return newTeamUser.(db.TeamUser), nil
+}
+
+func (d *BoltDb) DeleteTeamUser(teamID int, userID int) error {
+	return d.deleteObject(teamID, db.TeamUserProps, intObjectID(userID))
+}
+
+func (d *BoltDb) GetProjectTeams(projectID int) (teams []db.TeamWithProjectRole, err error) {
+	var projectTeams []db.ProjectTeam
+	err = d.getObjects(projectID, db.ProjectTeamProps, db.RetrieveQueryParams{}, nil, &projectTeams)
+	if err != nil {
+		return
+	}
+
+	for _, projectTeam := range projectTeams {
+		var team db.Team
+		team, err = d.GetTeam(projectTeam.TeamID)
+		if err != nil {
+			return
+		}
+		teams = append(teams, db.TeamWithProjectRole{Role: projectTeam.Role, Team: team})
+	}
+
+	return
+}
+
+func (d *BoltDb) GetProjectTeam(projectID int, teamID int) (projectTeam db.ProjectTeam, err error) {
+	err = d.getObject(projectID, db.ProjectTeamProps, intObjectID(teamID), &projectTeam)
+	return
+}
+
+func (d *BoltDb) CreateProjectTeam(projectTeam db.ProjectTeam) (db.ProjectTeam, error) {
+	newProjectTeam, err := d.createObject(projectTeam.ProjectID, db.ProjectTeamProps, projectTeam)
+	if err != nil {
+		return db.ProjectTeam{}, err
+	}
+
+	return newProjectTeam.(db.ProjectTeam), nil
+}
+
+func (d *BoltDb) UpdateProjectTeam(projectTeam db.ProjectTeam) error {
+	return d.updateObject(projectTeam.ProjectID, db.ProjectTeamProps, projectTeam)
+}
+
+func (d *BoltDb) DeleteProjectTeam(projectID int, teamID int) error {
+	return d.deleteObject(projectID, db.ProjectTeamProps, intObjectID(teamID))
+}
+
+func (d *BoltDb) GetProjectTeamRoles(projectID int, userID int) (roles []db.ProjectUserRole, err error) {
+	var projectTeams []db.ProjectTeam
+	err = d.getObjects(projectID, db.ProjectTeamProps, db.RetrieveQueryParams{}, nil, &projectTeams)
+	if err != nil {
+		return
+	}
+
+	for _, projectTeam := range projectTeams {
+		var teamUser db.TeamUser
+		err = d.getObject(projectTeam.TeamID, db.TeamUserProps, intObjectID(userID), &teamUser)
+
+		if err == db.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return
+		}
+
+		roles = append(roles, projectTeam.Role)
+	}
+
+	err = nil
+	return
+}
@@ -0,0 +1,95 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func TestTeams(t *testing.T) {
+	store := createStore()
+	err := store.Connect()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	usr, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "123456",
+		User: db.User{Email: "dev@example.com", Name: "Developer", Username: "dev"},
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	team, err := store.CreateTeam(db.Team{Name: "Developers"})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.CreateTeamUser(db.TeamUser{TeamID: team.ID, UserID: usr.ID}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = db.GetProjectMemberRole(store, proj.ID, usr.ID); err != db.ErrNotFound {
+		t.Fatal("user must not be a member of the project before the team is attached")
+	}
+
+	_, err = store.CreateProjectTeam(db.ProjectTeam{ProjectID: proj.ID, TeamID: team.ID, Role: db.ProjectTaskRunner})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	role, err := db.GetProjectMemberRole(store, proj.ID, usr.ID)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if role != db.ProjectTaskRunner {
+		t.Fatalf("expected role %s, got %s", db.ProjectTaskRunner, role)
+	}
+
+	projects, err := store.GetProjects(usr.ID)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(projects) != 1 {
+		t.Fatal("project of the team must be visible to team members")
+	}
+
+	if err = store.DeleteTeam(team.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	teams, err := store.GetProjectTeams(proj.ID)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(teams) != 0 {
+		t.Fatal("deleted team must be detached from projects")
+	}
+
+	teams2, err := store.GetUserTeams(usr.ID)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(teams2) != 0 {
+		t.Fatal("deleted team must not be listed")
+	}
+}
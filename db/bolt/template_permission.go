@@ -0,0 +1,27 @@
+package bolt
+
+import "github.com/ansible-semaphore/semaphore/db"
+
+func (d *BoltDb) GetTemplatePermissions(projectID int, templateID int) (permissions []db.TemplatePermission, err error) {
+	err = d.getObjects(projectID, db.TemplatePermissionProps, db.RetrieveQueryParams{}, func(i interface{}) bool {
+		return i.(db.TemplatePermission).TemplateID == templateID
+	}, &permissions)
+	return
+}
+
+func (d *BoltDb) GetProjectTemplatePermissions(projectID int) (permissions []db.TemplatePermission, err error) {
+	err = d.getObjects(projectID, db.TemplatePermissionProps, db.RetrieveQueryParams{}, nil, &permissions)
+	return
+}
+
+func (d *BoltDb) CreateTemplatePermission(permission db.TemplatePermission) (db.TemplatePermission, error) {
+	newPermission, err := d.createObject(permission.ProjectID, db.TemplatePermissionProps, permission)
+	if err != nil {
+		return db.TemplatePermission{}, err
+	}
+	return newPermission.(db.TemplatePermission), nil
+}
+
+func (d *BoltDb) DeleteTemplatePermission(projectID int, permissionID int) error {
+	return d.deleteObject(projectID, db.TemplatePermissionProps, intObjectID(permissionID))
+}
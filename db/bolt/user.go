@@ -57,6 +57,11 @@ func (d *BoltDb) CreateUser(user db.UserWithPwd) (newUser db.User, err error) {
 		return
 	}
 
+	err = db.ValidatePassword(user.Pwd)
+	if err != nil {
+		return
+	}
+
 	pwdHash, err := bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
 
 	if err != nil {
@@ -95,26 +100,49 @@ func (d *BoltDb) UpdateUser(user db.UserWithPwd) error {
 	var password string
 
 	if user.Pwd != "" {
+		if err := db.ValidatePassword(user.Pwd); err != nil {
+			return err
+		}
+
 		var pwdHash []byte
 		pwdHash, err := bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
 		if err != nil {
 			return err
 		}
 		password = string(pwdHash)
-	} else {
-		oldUser, err := d.GetUser(user.ID)
-		if err != nil {
-			return err
-		}
+	}
+
+	oldUser, err := d.GetUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if password == "" {
 		password = oldUser.Password
 	}
 
 	user.Password = password
+	// the identity of the provider is not a part of the user profile
+	user.OidcIssuer = oldUser.OidcIssuer
+	user.OidcSubject = oldUser.OidcSubject
+
+	return d.updateObject(0, db.UserProps, user)
+}
 
+func (d *BoltDb) VerifyUserEmail(userID int) error {
+	user, err := d.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	user.EmailUnverified = false
 	return d.updateObject(0, db.UserProps, user)
 }
 
 func (d *BoltDb) SetUserPassword(userID int, password string) error {
+	if err := db.ValidatePassword(password); err != nil {
+		return err
+	}
+
 	pwdHash, err := bcrypt.GenerateFromPassword([]byte(password), 11)
 	if err != nil {
 		return err
@@ -143,7 +171,7 @@ func (d *BoltDb) GetProjectUser(projectID, userID int) (user db.ProjectUser, err
 }
 
 
-func (d *BoltDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (users []db.User, err error) {
+func (d *BoltDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (users []db.UserWithProjectRole, err error) {
 	var projectUsers []db.ProjectUser
 	err = d.getObjects(projectID, db.ProjectUserProps, params, nil, &projectUsers)
 	if err != nil {
@@ -155,8 +183,7 @@ func (d *BoltDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (
 		if err != nil {
 			return
 		}
-		usr.Admin = projUser.Admin
-		users = append(users, usr)
+		users = append(users, db.UserWithProjectRole{Role: projUser.Role, User: usr})
 	}
 	return
 }
@@ -197,3 +224,31 @@ func (d *BoltDb) GetUserByLoginOrEmail(login string, email string) (existingUser
 	err = db.ErrNotFound
 	return
 }
+
+func (d *BoltDb) GetUserByOidcIdentity(issuer string, subject string) (existingUser db.User, err error) {
+	var users []db.User
+	err = d.getObjects(0, db.UserProps, db.RetrieveQueryParams{}, nil, &users)
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		if user.OidcSubject != "" && user.OidcIssuer == issuer && user.OidcSubject == subject {
+			existingUser = user
+			return
+		}
+	}
+
+	err = db.ErrNotFound
+	return
+}
+
+func (d *BoltDb) SetUserOidcIdentity(userID int, issuer string, subject string) error {
+	user, err := d.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	user.OidcIssuer = issuer
+	user.OidcSubject = subject
+	return d.updateObject(0, db.UserProps, user)
+}
@@ -40,19 +40,29 @@ func TestBoltDb_UpdateProjectUser(t *testing.T) {
 	projUser, err := store.CreateProjectUser(db.ProjectUser{
 		ProjectID: proj1.ID,
 		UserID: usr.ID,
-		Admin: true,
+		Role: db.ProjectManager,
 	})
 
 	if err != nil {
 		t.Fatal(err.Error())
 	}
 
-	projUser.Admin = true
+	projUser.Role = db.ProjectOwner
 	err = store.UpdateProjectUser(projUser)
 
 	if err != nil {
 		t.Fatal(err.Error())
 	}
+
+	updated, err := store.GetProjectUser(proj1.ID, usr.ID)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if updated.Role != db.ProjectOwner {
+		t.Fatal("role must be updated")
+	}
 }
 
 func TestGetUsers(t *testing.T) {
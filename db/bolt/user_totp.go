@@ -0,0 +1,47 @@
+package bolt
+
+import (
+	"github.com/ansible-semaphore/semaphore/db"
+	"time"
+)
+
+func (d *BoltDb) GetUserTotp(userID int) (totp db.UserTotp, err error) {
+	var totps []db.UserTotp
+	err = d.getObjects(userID, db.UserTotpProps, db.RetrieveQueryParams{}, nil, &totps)
+	if err != nil {
+		return
+	}
+	if len(totps) == 0 {
+		err = db.ErrNotFound
+		return
+	}
+	totp = totps[0]
+	return
+}
+
+func (d *BoltDb) CreateUserTotp(totp db.UserTotp) (db.UserTotp, error) {
+	err := d.DeleteUserTotp(totp.UserID)
+	if err != nil && err != db.ErrNotFound {
+		return db.UserTotp{}, err
+	}
+
+	totp.Created = time.Now()
+
+	newTotp, err := d.createObject(totp.UserID, db.UserTotpProps, totp)
+	if err != nil {
+		return db.UserTotp{}, err
+	}
+	return newTotp.(db.UserTotp), nil
+}
+
+func (d *BoltDb) UpdateUserTotp(totp db.UserTotp) error {
+	return d.updateObject(totp.UserID, db.UserTotpProps, totp)
+}
+
+func (d *BoltDb) DeleteUserTotp(userID int) error {
+	totp, err := d.GetUserTotp(userID)
+	if err != nil {
+		return err
+	}
+	return d.deleteObject(userID, db.UserTotpProps, intObjectID(totp.ID))
+}
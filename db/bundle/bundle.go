@@ -0,0 +1,142 @@
+// Package bundle converts projects to portable bundles and back.
+//
+// A bundle contains the project settings with its keys, repositories,
+// inventories, environments, templates and schedules. Objects reference each
+// other by the IDs they had in the exported project; these IDs are replaced
+// with new ones on import. Secrets are included only if the bundle is
+// exported with a passphrase, in which case they are encrypted with it.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Version is the version of the bundle format written by Export.
+const Version = 1
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+type Bundle struct {
+	Version  int       `json:"version" yaml:"version"`
+	Exported time.Time `json:"exported" yaml:"exported"`
+
+	// Encryption is set if secrets are included in the bundle.
+	Encryption *Encryption `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+
+	Project      Project       `json:"project" yaml:"project"`
+	Keys         []Key         `json:"keys" yaml:"keys"`
+	Repositories []Repository  `json:"repositories" yaml:"repositories"`
+	Inventories  []Inventory   `json:"inventories" yaml:"inventories"`
+	Environments []Environment `json:"environments" yaml:"environments"`
+	Templates    []Template    `json:"templates" yaml:"templates"`
+	Schedules    []Schedule    `json:"schedules" yaml:"schedules"`
+}
+
+// Encryption describes how secrets of the bundle are encrypted.
+type Encryption struct {
+	// Cipher is always "aes-256-gcm" with the key derived by scrypt.
+	Cipher string `json:"cipher" yaml:"cipher"`
+	Salt   string `json:"salt" yaml:"salt"`
+	// Check is an encrypted known value used to verify the passphrase.
+	Check string `json:"check" yaml:"check"`
+}
+
+type Project struct {
+	Name      string `json:"name" yaml:"name"`
+	Alert     bool   `json:"alert" yaml:"alert"`
+	AlertChat string `json:"alert_chat,omitempty" yaml:"alert_chat,omitempty"`
+}
+
+type Key struct {
+	ID   int    `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	// Secret is the encrypted login/password or SSH key.
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+type Repository struct {
+	ID       int    `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	GitURL   string `json:"git_url" yaml:"git_url"`
+	SSHKeyID int    `json:"ssh_key_id" yaml:"ssh_key_id"`
+}
+
+type Inventory struct {
+	ID          int    `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Inventory   string `json:"inventory" yaml:"inventory"`
+	SSHKeyID    *int   `json:"ssh_key_id,omitempty" yaml:"ssh_key_id,omitempty"`
+	BecomeKeyID *int   `json:"become_key_id,omitempty" yaml:"become_key_id,omitempty"`
+}
+
+type Environment struct {
+	ID   int    `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	JSON string `json:"json" yaml:"json"`
+	// Password is encrypted like the secrets of keys.
+	Password *string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+type Template struct {
+	ID                int     `json:"id" yaml:"id"`
+	Alias             string  `json:"alias" yaml:"alias"`
+	Playbook          string  `json:"playbook" yaml:"playbook"`
+	Description       *string `json:"description,omitempty" yaml:"description,omitempty"`
+	Arguments         *string `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+	OverrideArguments bool    `json:"override_args" yaml:"override_args"`
+	InventoryID       int     `json:"inventory_id" yaml:"inventory_id"`
+	RepositoryID      int     `json:"repository_id" yaml:"repository_id"`
+	EnvironmentID     *int    `json:"environment_id,omitempty" yaml:"environment_id,omitempty"`
+	VaultPassID       *int    `json:"vault_pass_id,omitempty" yaml:"vault_pass_id,omitempty"`
+
+	Validate      bool   `json:"validate,omitempty" yaml:"validate,omitempty"`
+	LintProfile   string `json:"lint_profile,omitempty" yaml:"lint_profile,omitempty"`
+	LintThreshold string `json:"lint_threshold,omitempty" yaml:"lint_threshold,omitempty"`
+	CheckFirst    bool   `json:"check_first,omitempty" yaml:"check_first,omitempty"`
+
+	RequiredApprovals int    `json:"required_approvals,omitempty" yaml:"required_approvals,omitempty"`
+	ApproverRole      string `json:"approver_role,omitempty" yaml:"approver_role,omitempty"`
+	ApprovalExpiry    int    `json:"approval_expiry,omitempty" yaml:"approval_expiry,omitempty"`
+}
+
+type Schedule struct {
+	TemplateID int    `json:"template_id" yaml:"template_id"`
+	CronFormat string `json:"cron_format" yaml:"cron_format"`
+}
+
+// Marshal encodes the bundle in the given format, JSON if it is empty.
+func Marshal(bundle Bundle, format string) ([]byte, error) {
+	switch format {
+	case "", FormatJSON:
+		return json.MarshalIndent(bundle, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(bundle)
+	default:
+		return nil, fmt.Errorf("unknown bundle format %q", format)
+	}
+}
+
+// Unmarshal decodes the bundle from JSON or YAML.
+func Unmarshal(data []byte) (bundle Bundle, err error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, &bundle)
+	} else {
+		err = yaml.Unmarshal(data, &bundle)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("invalid bundle: %s", err.Error())
+	}
+
+	return
+}
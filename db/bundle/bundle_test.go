@@ -0,0 +1,211 @@
+package bundle
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/db/bolt"
+	"github.com/ansible-semaphore/semaphore/util"
+)
+
+func createStore(t *testing.T) db.Store {
+	store := &bolt.BoltDb{Filename: "/tmp/test_semaphore_bundle_" + strconv.Itoa(int(time.Now().UnixNano()))}
+	if err := store.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func createProject(t *testing.T, store db.Store) db.Project {
+	project, err := store.CreateProject(db.Project{Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "SSH",
+		Type:      db.AccessKeySSH,
+		ProjectID: &project.ID,
+		SshKey:    db.SshKey{PrivateKey: "private key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shift IDs of the imported project from the exported ones
+	_, _ = store.CreateAccessKey(db.AccessKey{Name: "Unused", Type: db.AccessKeyNone, ProjectID: &project.ID})
+
+	repository, err := store.CreateRepository(db.Repository{
+		Name: "Repo", ProjectID: project.ID, GitURL: "git@example.com:test.git", SSHKeyID: key.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := store.CreateInventory(db.Inventory{
+		Name: "Inventory", ProjectID: project.ID, Type: "static", Inventory: "localhost", SSHKeyID: &key.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := "vault"
+	environment, err := store.CreateEnvironment(db.Environment{
+		Name: "Env", ProjectID: project.ID, JSON: "{}", Password: &password,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := store.CreateTemplate(db.Template{
+		ProjectID:     project.ID,
+		InventoryID:   inventory.ID,
+		RepositoryID:  repository.ID,
+		EnvironmentID: &environment.ID,
+		Alias:         "Deploy",
+		Playbook:      "deploy.yml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateSchedule(db.Schedule{ProjectID: project.ID, TemplateID: template.ID, CronFormat: "* * * * *"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return project
+}
+
+func TestExportImport(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil }()
+
+	store := createStore(t)
+	defer store.Close()
+
+	project := createProject(t, store)
+
+	exported, err := Export(store, project.ID, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal(exported, FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = Import(store, bundle, "wrong", ""); err == nil {
+		t.Fatal("wrong passphrase must be rejected")
+	}
+
+	if _, err = Import(store, bundle, "", ""); err == nil {
+		t.Fatal("passphrase must be required for encrypted secrets")
+	}
+
+	imported, err := Import(store, bundle, "secret", "Copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.ID == project.ID || imported.Name != "Copy" {
+		t.Fatal("new project must be created")
+	}
+
+	templates, err := store.GetTemplates(imported.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 {
+		t.Fatalf("expected 1 template, got %d", len(templates))
+	}
+
+	inventory, err := store.GetInventory(imported.ID, templates[0].InventoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := store.GetAccessKey(imported.ID, *inventory.SSHKeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "SSH" || key.SshKey.PrivateKey != "private key" {
+		t.Fatal("key must be imported with its secret")
+	}
+
+	environment, err := store.GetEnvironment(imported.ID, *templates[0].EnvironmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if environment.Password == nil || *environment.Password != "vault" {
+		t.Fatal("environment password must be imported")
+	}
+
+	schedules, err := store.GetTemplateSchedules(imported.ID, templates[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 1 || schedules[0].CronFormat != "* * * * *" {
+		t.Fatal("schedule must be imported")
+	}
+}
+
+func TestExportWithoutSecrets(t *testing.T) {
+	util.Config = &util.ConfigType{}
+	defer func() { util.Config = nil }()
+
+	store := createStore(t)
+	defer store.Close()
+
+	project := createProject(t, store)
+
+	exported, err := Export(store, project.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal(exported, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundle.Encryption != nil || bundle.Keys[0].Secret != nil || bundle.Environments[0].Password != nil {
+		t.Fatal("secrets must be omitted without passphrase")
+	}
+
+	if _, err = Import(store, bundle, "", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportUnknownReference(t *testing.T) {
+	store := createStore(t)
+	defer store.Close()
+
+	bundle := Bundle{
+		Version:   Version,
+		Project:   Project{Name: "Test"},
+		Templates: []Template{{ID: 1, Alias: "Deploy", InventoryID: 1, RepositoryID: 1}},
+	}
+
+	if _, err := Import(store, bundle, "", ""); err == nil {
+		t.Fatal("unknown references must be rejected")
+	}
+
+	if _, err := store.GetProject(1); err != db.ErrNotFound {
+		t.Fatal("project must be deleted if import fails")
+	}
+}
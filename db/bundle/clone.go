@@ -0,0 +1,35 @@
+package bundle
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+// Clone creates a copy of the project with the given name. Keys are copied
+// with their secrets. It returns new IDs of templates by their old IDs.
+func Clone(store db.Store, projectID int, name string, withSchedules bool) (db.Project, map[int]int, error) {
+	// the bundle never leaves memory, the passphrase only satisfies encryption of secrets
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return db.Project{}, nil, err
+	}
+	passphrase := base64.StdEncoding.EncodeToString(random)
+
+	bundle, err := Export(store, projectID, passphrase)
+	if err != nil {
+		return db.Project{}, nil, err
+	}
+
+	if !withSchedules {
+		bundle.Schedules = nil
+	}
+
+	project, i, err := importBundle(store, bundle, passphrase, name)
+	if err != nil {
+		return db.Project{}, nil, err
+	}
+
+	return project, i.templates, nil
+}
@@ -0,0 +1,312 @@
+package bundle
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+type exporter struct {
+	store     db.Store
+	projectID int
+	box       *cipherBox
+	bundle    Bundle
+
+	keys         map[int]bool
+	repositories map[int]bool
+	inventories  map[int]bool
+	environments map[int]bool
+}
+
+// Export creates the bundle of the project. Secrets are included only if
+// the passphrase is not empty.
+func Export(store db.Store, projectID int, passphrase string) (Bundle, error) {
+	project, err := store.GetProject(projectID)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	e := exporter{
+		store:     store,
+		projectID: projectID,
+		bundle: Bundle{
+			Version:  Version,
+			Exported: time.Now(),
+			Project: Project{
+				Name:      project.Name,
+				Alert:     project.Alert,
+				AlertChat: project.AlertChat,
+			},
+			Keys:         []Key{},
+			Repositories: []Repository{},
+			Inventories:  []Inventory{},
+			Environments: []Environment{},
+			Templates:    []Template{},
+			Schedules:    []Schedule{},
+		},
+		keys:         make(map[int]bool),
+		repositories: make(map[int]bool),
+		inventories:  make(map[int]bool),
+		environments: make(map[int]bool),
+	}
+
+	if passphrase != "" {
+		e.bundle.Encryption, e.box, err = newEncryption(passphrase)
+		if err != nil {
+			return Bundle{}, err
+		}
+	}
+
+	if err = e.exportAll(); err != nil {
+		return Bundle{}, err
+	}
+
+	return e.bundle, nil
+}
+
+// exportAll adds all objects of the project which are not removed. Removed
+// objects are added only if they are still used by other objects.
+func (e *exporter) exportAll() error {
+	keys, err := e.store.GetAccessKeys(e.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !key.Removed {
+			if err = e.addKey(key.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	repositories, err := e.store.GetRepositories(e.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, repository := range repositories {
+		if !repository.Removed {
+			if err = e.addRepository(repository.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	inventories, err := e.store.GetInventories(e.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, inventory := range inventories {
+		if !inventory.Removed {
+			if err = e.addInventory(inventory.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	environments, err := e.store.GetEnvironments(e.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, environment := range environments {
+		if !environment.Removed {
+			if err = e.addEnvironment(environment.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	templates, err := e.store.GetTemplates(e.projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+	for _, template := range templates {
+		if template.Removed {
+			continue
+		}
+		if err = e.addTemplate(template); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *exporter) encrypt(plaintext string) (*string, error) {
+	if e.box == nil {
+		return nil, nil
+	}
+
+	secret, err := e.box.encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func (e *exporter) addKey(keyID int) error {
+	if e.keys[keyID] {
+		return nil
+	}
+
+	key, err := e.store.GetAccessKey(e.projectID, keyID)
+	if err != nil {
+		return err
+	}
+
+	var plaintext []byte
+	switch key.Type {
+	case db.AccessKeySSH:
+		plaintext, err = json.Marshal(key.SshKey)
+	case db.AccessKeyLoginPassword:
+		plaintext, err = json.Marshal(key.LoginPassword)
+	}
+	if err != nil {
+		return err
+	}
+
+	exported := Key{ID: key.ID, Name: key.Name, Type: key.Type}
+	if plaintext != nil {
+		if exported.Secret, err = e.encrypt(string(plaintext)); err != nil {
+			return err
+		}
+	}
+
+	e.keys[keyID] = true
+	e.bundle.Keys = append(e.bundle.Keys, exported)
+	return nil
+}
+
+func (e *exporter) addOptionalKey(keyID *int) error {
+	if keyID == nil {
+		return nil
+	}
+	return e.addKey(*keyID)
+}
+
+func (e *exporter) addRepository(repositoryID int) error {
+	if e.repositories[repositoryID] {
+		return nil
+	}
+
+	repository, err := e.store.GetRepository(e.projectID, repositoryID)
+	if err != nil {
+		return err
+	}
+
+	if err = e.addKey(repository.SSHKeyID); err != nil {
+		return err
+	}
+
+	e.repositories[repositoryID] = true
+	e.bundle.Repositories = append(e.bundle.Repositories, Repository{
+		ID:       repository.ID,
+		Name:     repository.Name,
+		GitURL:   repository.GitURL,
+		SSHKeyID: repository.SSHKeyID,
+	})
+	return nil
+}
+
+func (e *exporter) addInventory(inventoryID int) error {
+	if e.inventories[inventoryID] {
+		return nil
+	}
+
+	inventory, err := e.store.GetInventory(e.projectID, inventoryID)
+	if err != nil {
+		return err
+	}
+
+	if err = e.addOptionalKey(inventory.SSHKeyID); err != nil {
+		return err
+	}
+	if err = e.addOptionalKey(inventory.BecomeKeyID); err != nil {
+		return err
+	}
+
+	e.inventories[inventoryID] = true
+	e.bundle.Inventories = append(e.bundle.Inventories, Inventory{
+		ID:          inventory.ID,
+		Name:        inventory.Name,
+		Type:        inventory.Type,
+		Inventory:   inventory.Inventory,
+		SSHKeyID:    inventory.SSHKeyID,
+		BecomeKeyID: inventory.BecomeKeyID,
+	})
+	return nil
+}
+
+func (e *exporter) addEnvironment(environmentID int) error {
+	if e.environments[environmentID] {
+		return nil
+	}
+
+	environment, err := e.store.GetEnvironment(e.projectID, environmentID)
+	if err != nil {
+		return err
+	}
+
+	exported := Environment{ID: environment.ID, Name: environment.Name, JSON: environment.JSON}
+	if environment.Password != nil && *environment.Password != "" {
+		if exported.Password, err = e.encrypt(*environment.Password); err != nil {
+			return err
+		}
+	}
+
+	e.environments[environmentID] = true
+	e.bundle.Environments = append(e.bundle.Environments, exported)
+	return nil
+}
+
+func (e *exporter) addTemplate(template db.Template) (err error) {
+	if err = e.addInventory(template.InventoryID); err != nil {
+		return
+	}
+	if err = e.addRepository(template.RepositoryID); err != nil {
+		return
+	}
+	if template.EnvironmentID != nil {
+		if err = e.addEnvironment(*template.EnvironmentID); err != nil {
+			return
+		}
+	}
+	if err = e.addOptionalKey(template.VaultPassID); err != nil {
+		return
+	}
+
+	e.bundle.Templates = append(e.bundle.Templates, Template{
+		ID:                template.ID,
+		Alias:             template.Alias,
+		Playbook:          template.Playbook,
+		Description:       template.Description,
+		Arguments:         template.Arguments,
+		OverrideArguments: template.OverrideArguments,
+		InventoryID:       template.InventoryID,
+		RepositoryID:      template.RepositoryID,
+		EnvironmentID:     template.EnvironmentID,
+		VaultPassID:       template.VaultPassID,
+		Validate:          template.Validate,
+		LintProfile:       template.LintProfile,
+		LintThreshold:     string(template.LintThreshold),
+		CheckFirst:        template.CheckFirst,
+		RequiredApprovals: template.RequiredApprovals,
+		ApproverRole:      string(template.ApproverRole),
+		ApprovalExpiry:    template.ApprovalExpiry,
+	})
+
+	schedules, err := e.store.GetTemplateSchedules(e.projectID, template.ID)
+	if err != nil {
+		return
+	}
+
+	for _, schedule := range schedules {
+		e.bundle.Schedules = append(e.bundle.Schedules, Schedule{
+			TemplateID: template.ID,
+			CronFormat: schedule.CronFormat,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,316 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+type importer struct {
+	store     db.Store
+	projectID int
+	box       *cipherBox
+
+	keys         map[int]int
+	repositories map[int]int
+	inventories  map[int]int
+	environments map[int]int
+	templates    map[int]int
+}
+
+// Import creates a new project from the bundle. The project gets the name
+// from the bundle unless name is not empty. The passphrase is required if
+// the bundle contains secrets. If any object can not be created the project
+// is deleted.
+func Import(store db.Store, bundle Bundle, passphrase string, name string) (project db.Project, err error) {
+	project, _, err = importBundle(store, bundle, passphrase, name)
+	return
+}
+
+func importBundle(store db.Store, bundle Bundle, passphrase string, name string) (project db.Project, i importer, err error) {
+	if bundle.Version < 1 || bundle.Version > Version {
+		err = fmt.Errorf("unsupported bundle version %d", bundle.Version)
+		return
+	}
+
+	i = importer{
+		store:        store,
+		keys:         make(map[int]int),
+		repositories: make(map[int]int),
+		inventories:  make(map[int]int),
+		environments: make(map[int]int),
+		templates:    make(map[int]int),
+	}
+
+	if bundle.Encryption != nil {
+		if i.box, err = bundle.Encryption.open(passphrase); err != nil {
+			return
+		}
+	}
+
+	if name == "" {
+		name = bundle.Project.Name
+	}
+
+	if name == "" {
+		err = fmt.Errorf("project name can not be empty")
+		return
+	}
+
+	project, err = store.CreateProject(db.Project{
+		Name:      name,
+		Alert:     bundle.Project.Alert,
+		AlertChat: bundle.Project.AlertChat,
+	})
+	if err != nil {
+		return
+	}
+
+	i.projectID = project.ID
+
+	if err = i.importAll(bundle); err != nil {
+		_ = store.DeleteProject(project.ID)
+		project = db.Project{}
+	}
+
+	return
+}
+
+func (i *importer) importAll(bundle Bundle) error {
+	for _, key := range bundle.Keys {
+		if err := i.importKey(key); err != nil {
+			return err
+		}
+	}
+
+	for _, repository := range bundle.Repositories {
+		if err := i.importRepository(repository); err != nil {
+			return err
+		}
+	}
+
+	for _, inventory := range bundle.Inventories {
+		if err := i.importInventory(inventory); err != nil {
+			return err
+		}
+	}
+
+	for _, environment := range bundle.Environments {
+		if err := i.importEnvironment(environment); err != nil {
+			return err
+		}
+	}
+
+	for _, template := range bundle.Templates {
+		if err := i.importTemplate(template); err != nil {
+			return err
+		}
+	}
+
+	for _, schedule := range bundle.Schedules {
+		templateID, err := i.lookup("template", i.templates, schedule.TemplateID)
+		if err != nil {
+			return err
+		}
+
+		_, err = i.store.CreateSchedule(db.Schedule{
+			ProjectID:  i.projectID,
+			TemplateID: templateID,
+			CronFormat: schedule.CronFormat,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the new ID of the object which had the given ID in the
+// exported project.
+func (i *importer) lookup(kind string, ids map[int]int, id int) (int, error) {
+	newID, ok := ids[id]
+	if !ok {
+		return 0, fmt.Errorf("%s %d not found in bundle", kind, id)
+	}
+	return newID, nil
+}
+
+func (i *importer) lookupOptional(kind string, ids map[int]int, id *int) (*int, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	newID, err := i.lookup(kind, ids, *id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newID, nil
+}
+
+func (i *importer) decrypt(secret *string) (string, error) {
+	if secret == nil {
+		return "", nil
+	}
+
+	if i.box == nil {
+		return "", fmt.Errorf("bundle contains secrets but no encryption")
+	}
+
+	return i.box.decrypt(*secret)
+}
+
+func (i *importer) importKey(key Key) error {
+	plaintext, err := i.decrypt(key.Secret)
+	if err != nil {
+		return fmt.Errorf("can not decrypt key %q: %s", key.Name, err.Error())
+	}
+
+	accessKey := db.AccessKey{
+		Name:      key.Name,
+		Type:      key.Type,
+		ProjectID: &i.projectID,
+	}
+
+	if plaintext != "" {
+		switch key.Type {
+		case db.AccessKeySSH:
+			err = json.Unmarshal([]byte(plaintext), &accessKey.SshKey)
+		case db.AccessKeyLoginPassword:
+			err = json.Unmarshal([]byte(plaintext), &accessKey.LoginPassword)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	created, err := i.store.CreateAccessKey(accessKey)
+	if err != nil {
+		return err
+	}
+
+	i.keys[key.ID] = created.ID
+	return nil
+}
+
+func (i *importer) importRepository(repository Repository) error {
+	keyID, err := i.lookup("key", i.keys, repository.SSHKeyID)
+	if err != nil {
+		return err
+	}
+
+	created, err := i.store.CreateRepository(db.Repository{
+		Name:      repository.Name,
+		ProjectID: i.projectID,
+		GitURL:    repository.GitURL,
+		SSHKeyID:  keyID,
+	})
+	if err != nil {
+		return err
+	}
+
+	i.repositories[repository.ID] = created.ID
+	return nil
+}
+
+func (i *importer) importInventory(inventory Inventory) error {
+	sshKeyID, err := i.lookupOptional("key", i.keys, inventory.SSHKeyID)
+	if err != nil {
+		return err
+	}
+
+	becomeKeyID, err := i.lookupOptional("key", i.keys, inventory.BecomeKeyID)
+	if err != nil {
+		return err
+	}
+
+	created, err := i.store.CreateInventory(db.Inventory{
+		Name:        inventory.Name,
+		ProjectID:   i.projectID,
+		Inventory:   inventory.Inventory,
+		SSHKeyID:    sshKeyID,
+		BecomeKeyID: becomeKeyID,
+		Type:        inventory.Type,
+	})
+	if err != nil {
+		return err
+	}
+
+	i.inventories[inventory.ID] = created.ID
+	return nil
+}
+
+func (i *importer) importEnvironment(environment Environment) error {
+	password, err := i.decrypt(environment.Password)
+	if err != nil {
+		return fmt.Errorf("can not decrypt environment %q: %s", environment.Name, err.Error())
+	}
+
+	env := db.Environment{
+		Name:      environment.Name,
+		ProjectID: i.projectID,
+		JSON:      environment.JSON,
+	}
+
+	if password != "" {
+		env.Password = &password
+	}
+
+	created, err := i.store.CreateEnvironment(env)
+	if err != nil {
+		return err
+	}
+
+	i.environments[environment.ID] = created.ID
+	return nil
+}
+
+func (i *importer) importTemplate(template Template) error {
+	inventoryID, err := i.lookup("inventory", i.inventories, template.InventoryID)
+	if err != nil {
+		return err
+	}
+
+	repositoryID, err := i.lookup("repository", i.repositories, template.RepositoryID)
+	if err != nil {
+		return err
+	}
+
+	environmentID, err := i.lookupOptional("environment", i.environments, template.EnvironmentID)
+	if err != nil {
+		return err
+	}
+
+	vaultPassID, err := i.lookupOptional("key", i.keys, template.VaultPassID)
+	if err != nil {
+		return err
+	}
+
+	created, err := i.store.CreateTemplate(db.Template{
+		ProjectID:         i.projectID,
+		InventoryID:       inventoryID,
+		RepositoryID:      repositoryID,
+		EnvironmentID:     environmentID,
+		Alias:             template.Alias,
+		Playbook:          template.Playbook,
+		Arguments:         template.Arguments,
+		OverrideArguments: template.OverrideArguments,
+		Description:       template.Description,
+		VaultPassID:       vaultPassID,
+		Validate:          template.Validate,
+		LintProfile:       template.LintProfile,
+		LintThreshold:     db.FindingSeverity(template.LintThreshold),
+		CheckFirst:        template.CheckFirst,
+		RequiredApprovals: template.RequiredApprovals,
+		ApproverRole:      db.ProjectUserRole(template.ApproverRole),
+		ApprovalExpiry:    template.ApprovalExpiry,
+	})
+	if err != nil {
+		return err
+	}
+
+	i.templates[template.ID] = created.ID
+	return nil
+}
@@ -0,0 +1,121 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const encryptionCipher = "aes-256-gcm"
+
+// encryptionCheck is encrypted into the bundle to detect wrong passphrases
+// before any object is imported.
+const encryptionCheck = "semaphore"
+
+type cipherBox struct {
+	gcm cipher.AEAD
+}
+
+func newCipherBox(passphrase string, salt []byte) (*cipherBox, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cipherBox{gcm: gcm}, nil
+}
+
+// newEncryption creates the encryption with a random salt for the passphrase.
+func newEncryption(passphrase string) (*Encryption, *cipherBox, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, err
+	}
+
+	box, err := newCipherBox(passphrase, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check, err := box.encrypt(encryptionCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Encryption{
+		Cipher: encryptionCipher,
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+		Check:  check,
+	}, box, nil
+}
+
+// open returns the cipher of the encryption if the passphrase is correct.
+func (e *Encryption) open(passphrase string) (*cipherBox, error) {
+	if e.Cipher != encryptionCipher {
+		return nil, fmt.Errorf("unsupported bundle cipher %q", e.Cipher)
+	}
+
+	if passphrase == "" {
+		return nil, fmt.Errorf("bundle contains encrypted secrets, passphrase required")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	box, err := newCipherBox(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := box.decrypt(e.Check)
+	if err != nil || check != encryptionCheck {
+		return nil, fmt.Errorf("wrong bundle passphrase")
+	}
+
+	return box, nil
+}
+
+func (b *cipherBox) encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b.gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func (b *cipherBox) decrypt(secret string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := b.gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	plaintext, err := b.gcm.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
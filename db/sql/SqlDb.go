@@ -5,6 +5,7 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/ansible-semaphore/semaphore/metrics"
 	"github.com/ansible-semaphore/semaphore/util"
 	"github.com/go-gorp/gorp/v3"
 	_ "github.com/go-sql-driver/mysql" // imports mysql driver
@@ -14,6 +15,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type SqlDb struct {
@@ -98,6 +100,8 @@ func (d *SqlDb) insert(primaryKeyColumnName string, query string, args ...interf
 
 	switch d.sql.Dialect.(type) {
 	case gorp.PostgresDialect:
+		defer metrics.ObserveDBCall("sql", "insert", time.Now())
+
 		query += " returning " + primaryKeyColumnName
 
 		err := d.sql.QueryRow(d.prepareQuery(query), args...).Scan(&insertId)
@@ -123,15 +127,21 @@ func (d *SqlDb) insert(primaryKeyColumnName string, query string, args ...interf
 }
 
 func (d *SqlDb) exec(query string, args ...interface{}) (sql.Result, error) {
+	defer metrics.ObserveDBCall("sql", "exec", time.Now())
+
 	q := d.prepareQuery(query)
 	return d.sql.Exec(q, args...)
 }
 
 func (d *SqlDb) selectOne(holder interface{}, query string, args ...interface{}) error {
+	defer metrics.ObserveDBCall("sql", "select", time.Now())
+
 	return d.sql.SelectOne(holder, d.prepareQuery(query), args...)
 }
 
 func (d *SqlDb) selectAll(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	defer metrics.ObserveDBCall("sql", "select", time.Now())
+
 	q := d.prepareQuery(query)
 	return d.sql.Select(i, q, args...)
 }
@@ -281,6 +291,10 @@ func (d *SqlDb) Close() error {
 	return d.sql.Db.Close()
 }
 
+func (d *SqlDb) Ping() error {
+	return d.sql.Db.Ping()
+}
+
 func (d *SqlDb) Connect() error {
 	sqlDb, err := connect()
 	if err != nil {
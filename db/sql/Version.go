@@ -82,5 +82,22 @@ func init() {
 		{Major: 2, Minor: 7, Patch: 10},
 		{Major: 2, Minor: 7, Patch: 12},
 		{Major: 2, Minor: 7, Patch: 13},
+		{Major: 2, Minor: 8},
+		{Major: 2, Minor: 8, Patch: 1},
+		{Major: 2, Minor: 8, Patch: 2},
+		{Major: 2, Minor: 8, Patch: 3},
+		{Major: 2, Minor: 8, Patch: 4},
+		{Major: 2, Minor: 8, Patch: 5},
+		{Major: 2, Minor: 8, Patch: 6},
+		{Major: 2, Minor: 8, Patch: 7},
+		{Major: 2, Minor: 8, Patch: 8},
+		{Major: 2, Minor: 8, Patch: 9},
+		{Major: 2, Minor: 8, Patch: 10},
+		{Major: 2, Minor: 8, Patch: 11},
+		{Major: 2, Minor: 8, Patch: 12},
+		{Major: 2, Minor: 8, Patch: 13},
+		{Major: 2, Minor: 8, Patch: 14},
+		{Major: 2, Minor: 8, Patch: 15},
+		{Major: 2, Minor: 8, Patch: 16},
 	}
 }
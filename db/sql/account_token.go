@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func (d *SqlDb) CreateAccountToken(token db.AccountToken) (db.AccountToken, error) {
+	token.Created = db.GetParsedTime(time.Now())
+
+	_, err := d.exec(
+		"insert into account_token (id, kind, user_id, email, project_id, `role`, created_by, created, expires, used) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		token.ID,
+		token.Kind,
+		token.UserID,
+		token.Email,
+		token.ProjectID,
+		token.Role,
+		token.CreatedBy,
+		token.Created,
+		token.Expires,
+		token.Used)
+
+	return token, err
+}
+
+func (d *SqlDb) GetAccountToken(tokenID string) (token db.AccountToken, err error) {
+	err = d.selectOne(&token, "select * from account_token where id=?", tokenID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) UseAccountToken(tokenID string) error {
+	return validateMutationResult(d.exec("update account_token set used=true where id=? and used=false", tokenID))
+}
@@ -16,9 +16,10 @@ func (d *SqlDb) GetEnvironments(projectID int, params db.RetrieveQueryParams) ([
 
 func (d *SqlDb) UpdateEnvironment(env db.Environment) error {
 	_, err := d.exec(
-		"update project__environment set name=?, json=? where id=?",
+		"update project__environment set name=?, json=?, managed=? where id=?",
 		env.Name,
 		env.JSON,
+		env.Managed,
 		env.ID)
 	return err
 }
@@ -26,11 +27,12 @@ func (d *SqlDb) UpdateEnvironment(env db.Environment) error {
 func (d *SqlDb) CreateEnvironment(env db.Environment) (newEnv db.Environment, err error) {
 	insertID, err := d.insert(
 		"id",
-		"insert into project__environment (project_id, name, json, password) values (?, ?, ?, ?)",
+		"insert into project__environment (project_id, name, json, password, managed) values (?, ?, ?, ?, ?)",
 		env.ProjectID,
 		env.Name,
 		env.JSON,
-		env.Password)
+		env.Password,
+		env.Managed)
 
 	if err != nil {
 		return
@@ -55,8 +55,9 @@ func (d *SqlDb) GetUserEvents(userID int, params db.RetrieveQueryParams) ([]db.E
 		From("event").
 		LeftJoin("project as p on event.project_id=p.id").
 		OrderBy("created desc").
-		LeftJoin("project__user as pu on pu.project_id=p.id").
-		Where("p.id IS NULL or pu.user_id=?", userID)
+		Where("p.id IS NULL or p.id in (select project_id from project__user where user_id=?) "+
+			"or p.id in (select pt.project_id from project__team as pt "+
+			"join team__user as tu on tu.team_id=pt.team_id where tu.user_id=?)", userID, userID)
 
 	return d.getEvents(q, params)
 }
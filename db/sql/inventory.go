@@ -28,11 +28,13 @@ func (d *SqlDb) DeleteInventorySoft(projectID int, inventoryID int) error {
 
 func (d *SqlDb) UpdateInventory(inventory db.Inventory) error {
 	_, err := d.exec(
-		"update project__inventory set name=?, type=?, ssh_key_id=?, inventory=? where id=?",
+		"update project__inventory set name=?, type=?, ssh_key_id=?, become_key_id=?, inventory=?, managed=? where id=?",
 		inventory.Name,
 		inventory.Type,
 		inventory.SSHKeyID,
+		inventory.BecomeKeyID,
 		inventory.Inventory,
+		inventory.Managed,
 		inventory.ID)
 
 	return err
@@ -41,12 +43,14 @@ func (d *SqlDb) UpdateInventory(inventory db.Inventory) error {
 func (d *SqlDb) CreateInventory(inventory db.Inventory) (newInventory db.Inventory, err error) {
 	insertID, err := d.insert(
 		"id",
-		"insert into project__inventory (project_id, name, type, ssh_key_id, inventory) values (?, ?, ?, ?, ?)",
+		"insert into project__inventory (project_id, name, type, ssh_key_id, become_key_id, inventory, managed) values (?, ?, ?, ?, ?, ?, ?)",
 		inventory.ProjectID,
 		inventory.Name,
 		inventory.Type,
 		inventory.SSHKeyID,
-		inventory.Inventory)
+		inventory.BecomeKeyID,
+		inventory.Inventory,
+		inventory.Managed)
 
 	if err != nil {
 		return
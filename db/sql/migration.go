@@ -3,6 +3,7 @@ package sql
 import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
+	"github.com/ansible-semaphore/semaphore/db"
 	"github.com/go-gorp/gorp/v3"
 	"regexp"
 	"time"
@@ -18,6 +19,32 @@ var (
 	dropForeignKey = regexp.MustCompile(`(?i)\bdrop foreign key\b`)
 )
 
+// dataMigrations convert data which can not be converted by SQL queries.
+// They are applied after the queries of the version in the same transaction.
+var dataMigrations = map[string]func(d *SqlDb, tx *gorp.Transaction) error{
+	"2.8.5": migrateAPITokens,
+}
+
+// migrateAPITokens replaces API tokens stored in plain text by their hashes.
+// Tokens created before scopes were introduced had all rights of the user,
+// so they get the admin scope.
+func migrateAPITokens(d *SqlDb, tx *gorp.Transaction) error {
+	var ids []string
+	if _, err := tx.Select(&ids, d.prepareQuery("select id from user__token where scopes = ''")); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		_, err := tx.Exec(d.prepareQuery("update user__token set id = ?, scopes = ? where id = ?"),
+			db.HashAPIToken(id), db.APITokenScopeAdmin, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // prepareMigration converts migration SQLite-query to current dialect.
 // Supported MySQL and Postgres dialects.
 func (d *SqlDb) prepareMigration(query string) string {
@@ -79,6 +106,13 @@ func (d *SqlDb) applyMigration(version *Version) error {
 		}
 	}
 
+	if migrate, ok := dataMigrations[version.VersionString()]; ok {
+		if err = migrate(d, tx); err != nil {
+			handleRollbackError(tx.Rollback())
+			return err
+		}
+	}
+
 	if _, err := tx.Exec(d.prepareQuery("insert into migrations(version, upgraded_date) values (?, ?)"), version.VersionString(), time.Now()); err != nil {
 		handleRollbackError(tx.Rollback())
 		return err
@@ -0,0 +1,14 @@
+create table `user__subscription`
+(
+    `id` integer primary key autoincrement,
+    `user_id` int not null references `user` (`id`) on delete cascade,
+    `project_id` int not null references project (`id`) on delete cascade,
+    `template_id` int null references project__template (`id`) on delete cascade,
+    `on_failure` boolean not null default false,
+    `on_success` boolean not null default false,
+    `on_recovery` boolean not null default false,
+    `on_stopped` boolean not null default false,
+    `email` boolean not null default false,
+    `telegram` boolean not null default false,
+    `telegram_chat` varchar(255) not null default ''
+);
@@ -0,0 +1,2 @@
+alter table `user__subscription` add `on_repeated_failure` boolean not null default false;
+alter table `user__subscription` add `repeated_failure_every` int not null default 0;
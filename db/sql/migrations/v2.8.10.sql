@@ -0,0 +1 @@
+alter table `user` add `service` boolean not null default false;
@@ -0,0 +1,14 @@
+create table `project__sync`
+(
+    `project_id` int not null primary key references `project` (`id`) on delete cascade,
+    `repository_id` int not null references `project__repository` (`id`) on delete cascade,
+    `path` varchar(255) not null,
+    `auto_apply` boolean not null default false,
+    `last_commit` varchar(40) not null default '',
+    `last_synced` datetime null,
+    `last_error` varchar(1000) not null default ''
+);
+
+alter table `project__template` add `managed` boolean not null default false;
+alter table `project__inventory` add `managed` boolean not null default false;
+alter table `project__environment` add `managed` boolean not null default false;
@@ -0,0 +1,17 @@
+create table `project__revision`
+(
+    `id` integer primary key autoincrement,
+    `project_id` int not null references `project` (`id`) on delete cascade,
+    `object_type` varchar(20) not null,
+    `object_id` int not null,
+    `number` int not null,
+    `user_id` int null references `user` (`id`) on delete set null,
+    `created` datetime not null,
+    `data` longtext not null,
+
+    unique (`object_type`, `object_id`, `number`)
+);
+
+alter table `task` add `template_revision_id` int null references `project__revision` (`id`) on delete set null;
+alter table `task` add `inventory_revision_id` int null references `project__revision` (`id`) on delete set null;
+alter table `task` add `environment_revision_id` int null references `project__revision` (`id`) on delete set null;
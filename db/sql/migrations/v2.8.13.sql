@@ -0,0 +1,17 @@
+alter table `project__template` add `validate` boolean not null default false;
+alter table `project__template` add `lint_profile` varchar(50) not null default '';
+alter table `project__template` add `lint_threshold` varchar(20) not null default '';
+
+alter table `task` add `validate_only` boolean not null default false;
+
+create table `task__finding`
+(
+    `id` integer primary key autoincrement,
+    `task_id` int not null references `task` (`id`) on delete cascade,
+    `tool` varchar(20) not null,
+    `rule` varchar(255) not null,
+    `severity` varchar(20) not null,
+    `message` text not null,
+    `path` varchar(255) not null,
+    `line` int not null
+);
@@ -0,0 +1,20 @@
+alter table `project__template` add `check_first` boolean not null default false;
+
+alter table `task` add `diff` boolean not null default false;
+alter table `task` add `await_approval` boolean not null default false;
+alter table `task` add `check_task_id` int null references `task` (`id`) on delete set null;
+alter table `task` add `commit_hash` varchar(64) null;
+
+create table `task__host_stat`
+(
+    `id` integer primary key autoincrement,
+    `task_id` int not null references `task` (`id`) on delete cascade,
+    `host` varchar(255) not null,
+    `ok` int not null,
+    `changed` int not null,
+    `unreachable` int not null,
+    `failed` int not null,
+    `skipped` int not null,
+    `rescued` int not null,
+    `ignored` int not null
+);
@@ -0,0 +1,14 @@
+alter table `project__template` add `required_approvals` int not null default 0;
+alter table `project__template` add `approver_role` varchar(50) not null default '';
+alter table `project__template` add `approval_expiry` int not null default 0;
+
+create table `task__approval`
+(
+    `id` integer primary key autoincrement,
+    `task_id` int not null references `task` (`id`) on delete cascade,
+    `user_id` int not null references `user` (`id`) on delete cascade,
+    `approved` boolean not null,
+    `created` datetime not null,
+
+    unique (`task_id`, `user_id`)
+);
@@ -0,0 +1 @@
+alter table `user__totp` add `last_step` bigint not null default 0;
@@ -0,0 +1,2 @@
+create index task__project_id__created
+    on task (project_id, created);
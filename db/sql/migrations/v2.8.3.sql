@@ -0,0 +1,2 @@
+alter table `user` add `oidc_issuer` varchar(255) not null default '';
+alter table `user` add `oidc_subject` varchar(255) not null default '';
@@ -0,0 +1,12 @@
+create table `user__totp`
+(
+    `id` integer primary key autoincrement,
+    `user_id` int not null references `user` (`id`) on delete cascade,
+    `secret` varchar(255) not null,
+    `recovery_codes` text not null,
+    `confirmed` boolean not null default false,
+    `created` datetime not null
+);
+
+alter table `session` add `verification_method` varchar(20) not null default '';
+alter table `session` add `verified` boolean not null default false;
@@ -0,0 +1,5 @@
+alter table `user__token` add `name` varchar(255) not null default '';
+alter table `user__token` add `expires` datetime null;
+alter table `user__token` add `last_used` datetime null;
+alter table `user__token` add `last_ip` varchar(45) not null default '';
+alter table `user__token` add `scopes` varchar(1000) not null default '';
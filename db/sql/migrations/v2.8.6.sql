@@ -0,0 +1,5 @@
+alter table `project__user` add `role` varchar(50) not null default 'manager';
+
+update `project__user` set `role` = 'owner' where `admin` = true;
+
+alter table `project__user` drop column `admin`;
@@ -0,0 +1,8 @@
+create table `project__template_permission`
+(
+    `id` integer primary key autoincrement,
+    `project_id` int not null references project (`id`) on delete cascade,
+    `template_id` int not null references project__template (`id`) on delete cascade,
+    `user_id` int not null references `user` (`id`) on delete cascade,
+    `access` varchar(20) not null
+);
@@ -0,0 +1,45 @@
+create table `team`
+(
+    `id` integer primary key autoincrement,
+    `name` varchar(255) not null,
+    `created` datetime not null
+);
+
+create table `team__user`
+(
+    `team_id` int not null,
+    `user_id` int not null,
+
+    unique (`team_id`, `user_id`),
+    foreign key (`team_id`) references `team` (`id`) on delete cascade,
+    foreign key (`user_id`) references `user` (`id`) on delete cascade
+);
+
+create table `project__team`
+(
+    `project_id` int not null,
+    `team_id` int not null,
+    `role` varchar(50) not null,
+
+    unique (`project_id`, `team_id`),
+    foreign key (`project_id`) references project (`id`) on delete cascade,
+    foreign key (`team_id`) references `team` (`id`) on delete cascade
+);
+
+-- template permissions can be granted to a user or to a team
+create table `project__template_permission_new`
+(
+    `id` integer primary key autoincrement,
+    `project_id` int not null references project (`id`) on delete cascade,
+    `template_id` int not null references project__template (`id`) on delete cascade,
+    `user_id` int null references `user` (`id`) on delete cascade,
+    `team_id` int null references `team` (`id`) on delete cascade,
+    `access` varchar(20) not null
+);
+
+insert into `project__template_permission_new` (`project_id`, `template_id`, `user_id`, `access`)
+select `project_id`, `template_id`, `user_id`, `access` from `project__template_permission`;
+
+drop table `project__template_permission`;
+
+alter table `project__template_permission_new` rename to `project__template_permission`;
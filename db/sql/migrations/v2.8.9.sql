@@ -0,0 +1,15 @@
+create table `account_token`
+(
+    `id` varchar(44) not null primary key,
+    `kind` varchar(50) not null,
+    `user_id` int null references `user` (`id`) on delete cascade,
+    `email` varchar(255) not null,
+    `project_id` int null references `project` (`id`) on delete cascade,
+    `role` varchar(50) not null default '',
+    `created_by` int null references `user` (`id`) on delete set null,
+    `created` datetime not null,
+    `expires` datetime not null,
+    `used` boolean not null default false
+);
+
+alter table `user` add `email_unverified` boolean not null default false;
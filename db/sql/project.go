@@ -26,8 +26,9 @@ func (d *SqlDb) CreateProject(project db.Project) (newProject db.Project, err er
 func (d *SqlDb) GetProjects(userID int) (projects []db.Project, err error) {
 	query, args, err := squirrel.Select("p.*").
 		From("project as p").
-		Join("project__user as pu on pu.project_id=p.id").
-		Where("pu.user_id=?", userID).
+		Where("p.id in (select project_id from project__user where user_id=?) "+
+			"or p.id in (select pt.project_id from project__team as pt "+
+			"join team__user as tu on tu.team_id=pt.team_id where tu.user_id=?)", userID, userID).
 		OrderBy("p.name").
 		ToSql()
 
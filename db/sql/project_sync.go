@@ -0,0 +1,61 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func (d *SqlDb) GetProjectSync(projectID int) (sync db.ProjectSync, err error) {
+	err = d.selectOne(&sync, "select * from project__sync where project_id=?", projectID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) GetProjectSyncs() (syncs []db.ProjectSync, err error) {
+	_, err = d.selectAll(&syncs, "select * from project__sync")
+	return
+}
+
+func (d *SqlDb) SetProjectSync(sync db.ProjectSync) error {
+	res, err := d.exec(
+		"update project__sync set repository_id=?, path=?, auto_apply=?, last_commit=?, last_synced=?, last_error=? "+
+			"where project_id=?",
+		sync.RepositoryID,
+		sync.Path,
+		sync.AutoApply,
+		sync.LastCommit,
+		sync.LastSynced,
+		sync.LastError,
+		sync.ProjectID)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil || affected > 0 {
+		return err
+	}
+
+	_, err = d.exec(
+		"insert into project__sync (project_id, repository_id, path, auto_apply, last_commit, last_synced, last_error) "+
+			"values (?, ?, ?, ?, ?, ?, ?)",
+		sync.ProjectID,
+		sync.RepositoryID,
+		sync.Path,
+		sync.AutoApply,
+		sync.LastCommit,
+		sync.LastSynced,
+		sync.LastError)
+
+	return err
+}
+
+func (d *SqlDb) DeleteProjectSync(projectID int) error {
+	return validateMutationResult(d.exec("delete from project__sync where project_id=?", projectID))
+}
@@ -0,0 +1,65 @@
+package sql
+
+import (
+	"database/sql"
+
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func (d *SqlDb) CreateRevision(revision db.Revision) (newRevision db.Revision, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__revision (project_id, object_type, object_id, number, user_id, created, data) values (?, ?, ?, ?, ?, ?, ?)",
+		revision.ProjectID,
+		revision.ObjectType,
+		revision.ObjectID,
+		revision.Number,
+		revision.UserID,
+		revision.Created,
+		revision.Data)
+
+	if err != nil {
+		return
+	}
+
+	newRevision = revision
+	newRevision.ID = insertID
+
+	return
+}
+
+func (d *SqlDb) GetRevision(projectID int, revisionID int) (revision db.Revision, err error) {
+	err = d.selectOne(&revision,
+		"select * from project__revision where project_id=? and id=?",
+		projectID,
+		revisionID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) GetRevisions(projectID int, objectType db.RevisionObjectType, objectID int) (revisions []db.Revision, err error) {
+	_, err = d.selectAll(&revisions,
+		"select * from project__revision where project_id=? and object_type=? and object_id=? order by number desc",
+		projectID,
+		objectType,
+		objectID)
+	return
+}
+
+func (d *SqlDb) GetLastRevision(projectID int, objectType db.RevisionObjectType, objectID int) (revision db.Revision, err error) {
+	err = d.selectOne(&revision,
+		"select * from project__revision where project_id=? and object_type=? and object_id=? order by number desc limit 1",
+		projectID,
+		objectType,
+		objectID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
@@ -33,6 +33,12 @@ func (d *SqlDb) ExpireAPIToken(userID int, tokenID string) (err error) {
 	return validateMutationResult(res, err)
 }
 
+func (d *SqlDb) TouchAPIToken(userID int, tokenID string, ip string) error {
+	_, err := d.exec("update user__token set last_used=?, last_ip=? where id=? and user_id=?", time.Now(), ip, tokenID, userID)
+
+	return err
+}
+
 func (d *SqlDb) GetSession(userID int, sessionID int) (session db.Session, err error) {
 	err = d.selectOne(&session, "select * from session where id=? and user_id=? and expired=false", sessionID, userID)
 
@@ -49,12 +55,30 @@ func (d *SqlDb) ExpireSession(userID int, sessionID int) error {
 	return validateMutationResult(res, err)
 }
 
+func (d *SqlDb) GetSessions(userID int) (sessions []db.Session, err error) {
+	_, err = d.selectAll(&sessions, "select * from session where user_id=? and expired=? order by last_active desc", userID, false)
+
+	return
+}
+
+func (d *SqlDb) ExpireSessions(userID int, exceptSessionID int) error {
+	_, err := d.exec("update session set expired=? where user_id=? and id<>?", true, userID, exceptSessionID)
+
+	return err
+}
+
 func (d *SqlDb) TouchSession(userID int, sessionID int) error {
 	_, err := d.exec("update session set last_active=? where id=? and user_id=?", time.Now(), sessionID, userID)
 
 	return err
 }
 
+func (d *SqlDb) VerifySession(userID int, sessionID int) error {
+	res, err := d.exec("update session set verified=? where id=? and user_id=?", true, sessionID, userID)
+
+	return validateMutationResult(res, err)
+}
+
 func (d *SqlDb) GetAPITokens(userID int) (tokens []db.APIToken, err error) {
 	_, err = d.selectAll(&tokens, d.prepareQuery("select * from user__token where user_id=?"), userID)
 
@@ -0,0 +1,86 @@
+package sql
+
+import (
+	"database/sql"
+	"github.com/ansible-semaphore/semaphore/db"
+)
+
+func (d *SqlDb) GetSubscriptions(userID int) (subscriptions []db.Subscription, err error) {
+	_, err = d.selectAll(&subscriptions,
+		"select * from user__subscription where user_id=? order by id",
+		userID)
+	return
+}
+
+func (d *SqlDb) GetProjectSubscriptions(projectID int) (subscriptions []db.Subscription, err error) {
+	_, err = d.selectAll(&subscriptions,
+		"select * from user__subscription where project_id=? order by id",
+		projectID)
+	return
+}
+
+func (d *SqlDb) GetSubscription(userID int, subscriptionID int) (subscription db.Subscription, err error) {
+	err = d.selectOne(&subscription,
+		"select * from user__subscription where user_id=? and id=?",
+		userID,
+		subscriptionID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateSubscription(subscription db.Subscription) (newSubscription db.Subscription, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into user__subscription (user_id, project_id, template_id, on_failure, on_repeated_failure, on_success, "+
+			"on_recovery, on_stopped, repeated_failure_every, email, telegram, telegram_chat) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		subscription.UserID,
+		subscription.ProjectID,
+		subscription.TemplateID,
+		subscription.OnFailure,
+		subscription.OnRepeatedFailure,
+		subscription.OnSuccess,
+		subscription.OnRecovery,
+		subscription.OnStopped,
+		subscription.RepeatedFailureEvery,
+		subscription.Email,
+		subscription.Telegram,
+		subscription.TelegramChat)
+
+	if err != nil {
+		return
+	}
+
+	newSubscription = subscription
+	newSubscription.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateSubscription(subscription db.Subscription) error {
+	_, err := d.exec("update user__subscription set project_id=?, template_id=?, on_failure=?, on_repeated_failure=?, on_success=?, "+
+		"on_recovery=?, on_stopped=?, repeated_failure_every=?, email=?, telegram=?, telegram_chat=? where user_id=? and id=?",
+		subscription.ProjectID,
+		subscription.TemplateID,
+		subscription.OnFailure,
+		subscription.OnRepeatedFailure,
+		subscription.OnSuccess,
+		subscription.OnRecovery,
+		subscription.OnStopped,
+		subscription.RepeatedFailureEvery,
+		subscription.Email,
+		subscription.Telegram,
+		subscription.TelegramChat,
+		subscription.UserID,
+		subscription.ID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteSubscription(userID int, subscriptionID int) error {
+	return validateMutationResult(
+		d.exec("delete from user__subscription where user_id=? and id=?", userID, subscriptionID))
+}
@@ -13,10 +13,14 @@ func (d *SqlDb) CreateTask(task db.Task) (db.Task, error) {
 
 func (d *SqlDb) UpdateTask(task db.Task) error {
 	_, err := d.exec(
-		"update task set status=?, start=?, end=? where id=?",
+		"update task set status=?, start=?, end=?, template_revision_id=?, inventory_revision_id=?, environment_revision_id=?, commit_hash=? where id=?",
 		task.Status,
 		task.Start,
 		task.End,
+		task.TemplateRevisionID,
+		task.InventoryRevisionID,
+		task.EnvironmentRevisionID,
+		task.CommitHash,
 		task.ID)
 
 	return err
@@ -84,6 +88,27 @@ func (d *SqlDb) GetProjectTasks(projectID int, params db.RetrieveQueryParams) ([
 	return d.getTasks(projectID, nil, params)
 }
 
+func (d *SqlDb) GetTaskStatRecords(projectID int, filter db.TaskStatsFilter) (records []db.TaskStatRecord, err error) {
+	q := squirrel.Select("template_id, user_id, status, created, `start`, `end`").
+		From("task").
+		Where("project_id=? AND created>=? AND created<?", projectID, filter.From, filter.To).
+		OrderBy("created asc, id asc")
+
+	if filter.TemplateID != nil {
+		q = q.Where("template_id=?", *filter.TemplateID)
+	}
+
+	query, args, err := q.ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&records, query, args...)
+
+	return
+}
+
 func (d *SqlDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 	// check if task exists in the project
 	_, err = d.GetTask(projectID, taskID)
@@ -98,6 +123,24 @@ func (d *SqlDb) DeleteTaskWithOutputs(projectID int, taskID int) (err error) {
 		return
 	}
 
+	_, err = d.exec("delete from task__finding where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__host_stat where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec("delete from task__approval where task_id=?", taskID)
+
+	if err != nil {
+		return
+	}
+
 	_, err = d.exec("delete from task where id=?", taskID)
 	return
 }
@@ -115,3 +158,88 @@ func (d *SqlDb) GetTaskOutputs(projectID int, taskID int) (output []db.TaskOutpu
 		taskID)
 	return
 }
+
+func (d *SqlDb) GetTaskFindings(projectID int, taskID int) (findings []db.TaskFinding, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&findings,
+		"select task_id, tool, rule, severity, message, path, line from task__finding where task_id=? order by id asc",
+		taskID)
+	return
+}
+
+func (d *SqlDb) CreateTaskFinding(finding db.TaskFinding) (db.TaskFinding, error) {
+	_, err := d.exec(
+		"insert into task__finding (task_id, tool, rule, severity, message, path, line) values (?, ?, ?, ?, ?, ?, ?)",
+		finding.TaskID,
+		finding.Tool,
+		finding.Rule,
+		finding.Severity,
+		finding.Message,
+		finding.Path,
+		finding.Line)
+	return finding, err
+}
+
+func (d *SqlDb) GetTaskHostStats(projectID int, taskID int) (stats []db.TaskHostStat, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&stats,
+		"select task_id, host, ok, changed, unreachable, failed, skipped, rescued, ignored from task__host_stat where task_id=? order by id asc",
+		taskID)
+	return
+}
+
+func (d *SqlDb) CreateTaskHostStat(stat db.TaskHostStat) (db.TaskHostStat, error) {
+	_, err := d.exec(
+		"insert into task__host_stat (task_id, host, ok, changed, unreachable, failed, skipped, rescued, ignored) values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		stat.TaskID,
+		stat.Host,
+		stat.Ok,
+		stat.Changed,
+		stat.Unreachable,
+		stat.Failed,
+		stat.Skipped,
+		stat.Rescued,
+		stat.Ignored)
+	return stat, err
+}
+
+func (d *SqlDb) GetTasksByStatus(status string) (tasks []db.Task, err error) {
+	_, err = d.selectAll(&tasks, "select * from task where status=? order by id asc", status)
+	return
+}
+
+func (d *SqlDb) GetTaskApprovals(projectID int, taskID int) (approvals []db.TaskApproval, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&approvals,
+		"select task_id, user_id, approved, created from task__approval where task_id=? order by id asc",
+		taskID)
+	return
+}
+
+func (d *SqlDb) CreateTaskApproval(approval db.TaskApproval) (db.TaskApproval, error) {
+	_, err := d.exec(
+		"insert into task__approval (task_id, user_id, approved, created) values (?, ?, ?, ?)",
+		approval.TaskID,
+		approval.UserID,
+		approval.Approved,
+		approval.Created)
+	return approval, err
+}
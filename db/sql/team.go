@@ -0,0 +1,173 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ansible-semaphore/semaphore/db"
+	"github.com/masterminds/squirrel"
+)
+
+func (d *SqlDb) GetTeams(params db.RetrieveQueryParams) (teams []db.Team, err error) {
+	sortDirection := "ASC"
+	if params.SortInverted {
+		sortDirection = "DESC"
+	}
+
+	query, args, err := squirrel.Select("*").
+		From("team").
+		OrderBy("name " + sortDirection).
+		ToSql()
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.selectAll(&teams, query, args...)
+	return
+}
+
+func (d *SqlDb) GetTeam(teamID int) (team db.Team, err error) {
+	err = d.selectOne(&team, "select * from team where id=?", teamID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateTeam(team db.Team) (newTeam db.Team, err error) {
+	team.Created = time.Now()
+
+	insertID, err := d.insert(
+		"id",
+		"insert into team (name, created) values (?, ?)",
+		team.Name,
+		team.Created)
+
+	if err != nil {
+		return
+	}
+
+	newTeam = team
+	newTeam.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateTeam(team db.Team) error {
+	_, err := d.exec("update team set name=? where id=?", team.Name, team.ID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteTeam(teamID int) error {
+	res, err := d.exec("delete from team where id=?", teamID)
+
+	return validateMutationResult(res, err)
+}
+
+func (d *SqlDb) GetUserTeams(userID int) (teams []db.Team, err error) {
+	_, err = d.selectAll(&teams,
+		"select t.* from team as t join team__user as tu on tu.team_id=t.id where tu.user_id=? order by t.name",
+		userID)
+	return
+}
+
+func (d *SqlDb) GetTeamUsers(teamID int) (users []db.User, err error) {
+	_, err = d.selectAll(&users,
+		"select u.* from `user` as u join team__user as tu on tu.user_id=u.id where tu.team_id=? order by u.name",
+		teamID)
+	return
+}
+
+func (d *SqlDb) CreateTeamUser(teamUser db.TeamUser) (newTeamUser db.TeamUser, err error) {
+	_, err = d.exec(
+		"insert into team__user (team_id, user_id) values (?, ?)",
+		teamUser.TeamID,
+		teamUser.UserID)
+
+	if err != nil {
+		return
+	}
+
+	newTeamUser = teamUser
+	return
+}
+
+func (d *SqlDb) DeleteTeamUser(teamID int, userID int) error {
+	res, err := d.exec("delete from team__user where team_id=? and user_id=?", teamID, userID)
+
+	return validateMutationResult(res, err)
+}
+
+func (d *SqlDb) GetProjectTeams(projectID int) (teams []db.TeamWithProjectRole, err error) {
+	_, err = d.selectAll(&teams,
+		"select t.*, pt.role from project__team as pt join team as t on t.id=pt.team_id where pt.project_id=? order by t.name",
+		projectID)
+	return
+}
+
+func (d *SqlDb) GetProjectTeam(projectID int, teamID int) (projectTeam db.ProjectTeam, err error) {
+	err = d.selectOne(&projectTeam,
+		"select * from project__team where project_id=? and team_id=?",
+		projectID,
+		teamID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateProjectTeam(projectTeam db.ProjectTeam) (newProjectTeam db.ProjectTeam, err error) {
+	_, err = d.exec(
+		"insert into project__team (project_id, team_id, `role`) values (?, ?, ?)",
+		projectTeam.ProjectID,
+		projectTeam.TeamID,
+		projectTeam.Role)
+
+	if err != nil {
+		return
+	}
+
+	newProjectTeam = projectTeam
+	return
+}
+
+func (d *SqlDb) UpdateProjectTeam(projectTeam db.ProjectTeam) error {
+	_, err := d.exec(
+		"update project__team set `role`=? where project_id=? and team_id=?",
+		projectTeam.Role,
+		projectTeam.ProjectID,
+		projectTeam.TeamID)
+
+	return err
+}
+
+func (d *SqlDb) DeleteProjectTeam(projectID int, teamID int) error {
+	res, err := d.exec("delete from project__team where project_id=? and team_id=?", projectID, teamID)
+
+	return validateMutationResult(res, err)
+}
+
+func (d *SqlDb) GetProjectTeamRoles(projectID int, userID int) (roles []db.ProjectUserRole, err error) {
+	var projectTeams []db.ProjectTeam
+
+	_, err = d.selectAll(&projectTeams,
+		"select pt.* from project__team as pt join team__user as tu on tu.team_id=pt.team_id "+
+			"where pt.project_id=? and tu.user_id=?",
+		projectID,
+		userID)
+
+	if err != nil {
+		return
+	}
+
+	for _, projectTeam := range projectTeams {
+		roles = append(roles, projectTeam.Role)
+	}
+
+	return
+}
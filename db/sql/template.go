@@ -9,8 +9,9 @@ import (
 func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, err error) {
 	insertID, err := d.insert(
 		"id",
-		"insert into project__template (project_id, inventory_id, repository_id, environment_id, alias, playbook, arguments, override_args)" +
-			"values (?, ?, ?, ?, ?, ?, ?, ?)",
+		"insert into project__template (project_id, inventory_id, repository_id, environment_id, alias, playbook, arguments, override_args, description, vault_pass_id, managed, " +
+			"validate, lint_profile, lint_threshold, check_first, required_approvals, approver_role, approval_expiry) " +
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		template.ProjectID,
 		template.InventoryID,
 		template.RepositoryID,
@@ -18,7 +19,17 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 		template.Alias,
 		template.Playbook,
 		template.Arguments,
-		template.OverrideArguments)
+		template.OverrideArguments,
+		template.Description,
+		template.VaultPassID,
+		template.Managed,
+		template.Validate,
+		template.LintProfile,
+		template.LintThreshold,
+		template.CheckFirst,
+		template.RequiredApprovals,
+		template.ApproverRole,
+		template.ApprovalExpiry)
 
 	if err != nil {
 		return
@@ -38,7 +49,9 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 
 func (d *SqlDb) UpdateTemplate(template db.Template) error {
 	_, err := d.exec("update project__template set inventory_id=?, repository_id=?, environment_id=?, alias=?, " +
-		"playbook=?, arguments=?, override_args=? where removed = false and id=? and project_id=?",
+		"playbook=?, arguments=?, override_args=?, description=?, vault_pass_id=?, managed=?, validate=?, lint_profile=?, lint_threshold=?, check_first=?, " +
+		"required_approvals=?, approver_role=?, approval_expiry=? " +
+		"where removed = false and id=? and project_id=?",
 		template.InventoryID,
 		template.RepositoryID,
 		template.EnvironmentID,
@@ -46,6 +59,16 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 		template.Playbook,
 		template.Arguments,
 		template.OverrideArguments,
+		template.Description,
+		template.VaultPassID,
+		template.Managed,
+		template.Validate,
+		template.LintProfile,
+		template.LintThreshold,
+		template.CheckFirst,
+		template.RequiredApprovals,
+		template.ApproverRole,
+		template.ApprovalExpiry,
 		template.ID,
 		template.ProjectID)
 	
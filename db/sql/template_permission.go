@@ -0,0 +1,44 @@
+package sql
+
+import "github.com/ansible-semaphore/semaphore/db"
+
+func (d *SqlDb) GetTemplatePermissions(projectID int, templateID int) (permissions []db.TemplatePermission, err error) {
+	_, err = d.selectAll(&permissions,
+		"select * from project__template_permission where project_id=? and template_id=?",
+		projectID,
+		templateID)
+	return
+}
+
+func (d *SqlDb) GetProjectTemplatePermissions(projectID int) (permissions []db.TemplatePermission, err error) {
+	_, err = d.selectAll(&permissions,
+		"select * from project__template_permission where project_id=?",
+		projectID)
+	return
+}
+
+func (d *SqlDb) CreateTemplatePermission(permission db.TemplatePermission) (newPermission db.TemplatePermission, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into project__template_permission (project_id, template_id, user_id, team_id, access) values (?, ?, ?, ?, ?)",
+		permission.ProjectID,
+		permission.TemplateID,
+		permission.UserID,
+		permission.TeamID,
+		permission.Access)
+
+	if err != nil {
+		return
+	}
+
+	newPermission = permission
+	newPermission.ID = insertID
+
+	return
+}
+
+func (d *SqlDb) DeleteTemplatePermission(projectID int, permissionID int) error {
+	res, err := d.exec("delete from project__template_permission where project_id=? and id=?", projectID, permissionID)
+
+	return validateMutationResult(res, err)
+}
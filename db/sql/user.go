@@ -36,6 +36,11 @@ func (d *SqlDb) CreateUser(user db.UserWithPwd) (newUser db.User, err error) {
 		return
 	}
 
+	err = db.ValidatePassword(user.Pwd)
+	if err != nil {
+		return
+	}
+
 	pwdHash, err := bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
 
 	if err != nil {
@@ -64,6 +69,10 @@ func (d *SqlDb) UpdateUser(user db.UserWithPwd) error {
 	var err error
 
 	if user.Pwd != "" {
+		if err = db.ValidatePassword(user.Pwd); err != nil {
+			return err
+		}
+
 		var pwdHash []byte
 		pwdHash, err = bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
 		if err != nil {
@@ -92,7 +101,36 @@ func (d *SqlDb) UpdateUser(user db.UserWithPwd) error {
 	return err
 }
 
+func (d *SqlDb) GetUserByOidcIdentity(issuer string, subject string) (existingUser db.User, err error) {
+	err = d.selectOne(
+		&existingUser,
+		d.prepareQuery("select * from `user` where oidc_subject != '' and oidc_issuer=? and oidc_subject=?"),
+		issuer, subject)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) SetUserOidcIdentity(userID int, issuer string, subject string) error {
+	_, err := d.exec("update `user` set oidc_issuer=?, oidc_subject=? where id=?", issuer, subject, userID)
+
+	return err
+}
+
+func (d *SqlDb) VerifyUserEmail(userID int) error {
+	_, err := d.exec("update `user` set email_unverified=false where id=?", userID)
+
+	return err
+}
+
 func (d *SqlDb) SetUserPassword(userID int, password string) error {
+	if err := db.ValidatePassword(password); err != nil {
+		return err
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), 11)
 	if err != nil {
 		return err
@@ -105,10 +143,10 @@ func (d *SqlDb) SetUserPassword(userID int, password string) error {
 
 func (d *SqlDb) CreateProjectUser(projectUser db.ProjectUser) (newProjectUser db.ProjectUser, err error) {
 	_, err = d.exec(
-		"insert into project__user (project_id, user_id, `admin`) values (?, ?, ?)",
+		"insert into project__user (project_id, user_id, `role`) values (?, ?, ?)",
 		projectUser.ProjectID,
 		projectUser.UserID,
-		projectUser.Admin)
+		projectUser.Role)
 
 	if err != nil {
 		return
@@ -133,8 +171,8 @@ func (d *SqlDb) GetProjectUser(projectID, userID int) (db.ProjectUser, error) {
 	return user, err
 }
 
-func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (users []db.User, err error) {
-	q := squirrel.Select("u.*").Column("pu.admin").
+func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (users []db.UserWithProjectRole, err error) {
+	q := squirrel.Select("u.*").Column("pu.role").
 		From("project__user as pu").
 		LeftJoin("`user` as u on pu.user_id=u.id").
 		Where("pu.project_id=?", projectID)
@@ -147,7 +185,7 @@ func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (u
 	switch params.SortBy {
 	case "name", "username", "email":
 		q = q.OrderBy("u." + params.SortBy + " " + sortDirection)
-	case "admin":
+	case "role":
 		q = q.OrderBy("pu." + params.SortBy + " " + sortDirection)
 	default:
 		q = q.OrderBy("u.name " + sortDirection)
@@ -166,8 +204,8 @@ func (d *SqlDb) GetProjectUsers(projectID int, params db.RetrieveQueryParams) (u
 
 func (d *SqlDb) UpdateProjectUser(projectUser db.ProjectUser) error {
 	_, err := d.exec(
-		"update `project__user` set admin=? where user_id=? and project_id = ?",
-		projectUser.Admin,
+		"update `project__user` set `role`=? where user_id=? and project_id = ?",
+		projectUser.Role,
 		projectUser.UserID,
 		projectUser.ProjectID)
 
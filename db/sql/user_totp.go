@@ -0,0 +1,58 @@
+package sql
+
+import (
+	"database/sql"
+	"github.com/ansible-semaphore/semaphore/db"
+	"time"
+)
+
+func (d *SqlDb) GetUserTotp(userID int) (totp db.UserTotp, err error) {
+	err = d.selectOne(&totp, "select * from user__totp where user_id=?", userID)
+
+	if err == sql.ErrNoRows {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) CreateUserTotp(totp db.UserTotp) (newTotp db.UserTotp, err error) {
+	_, err = d.exec("delete from user__totp where user_id=?", totp.UserID)
+
+	if err != nil {
+		return
+	}
+
+	totp.Created = db.GetParsedTime(time.Now())
+
+	insertID, err := d.insert(
+		"id",
+		"insert into user__totp (user_id, secret, recovery_codes, confirmed, created) values (?, ?, ?, ?, ?)",
+		totp.UserID,
+		totp.Secret,
+		totp.RecoveryCodes,
+		totp.Confirmed,
+		totp.Created)
+
+	if err != nil {
+		return
+	}
+
+	newTotp = totp
+	newTotp.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateUserTotp(totp db.UserTotp) error {
+	return validateMutationResult(d.exec(
+		"update user__totp set recovery_codes=?, confirmed=?, last_step=? where user_id=? and id=?",
+		totp.RecoveryCodes,
+		totp.Confirmed,
+		totp.LastStep,
+		totp.UserID,
+		totp.ID))
+}
+
+func (d *SqlDb) DeleteUserTotp(userID int) error {
+	return validateMutationResult(d.exec("delete from user__totp where user_id=?", userID))
+}
@@ -0,0 +1,128 @@
+// Package metrics collects runtime metrics of Semaphore and exposes them
+// in the Prometheus text format.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "semaphore"
+
+var (
+	// TaskQueueLength is the number of tasks waiting in the task pool queue.
+	TaskQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_queue_length",
+		Help:      "Number of tasks waiting in the queue.",
+	})
+
+	// RunningTasks is the number of tasks currently running.
+	RunningTasks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tasks_running",
+		Help:      "Number of tasks currently running.",
+	})
+
+	tasksFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_finished_total",
+		Help:      "Number of finished tasks by project, template and final status.",
+	}, []string{"project_id", "template_id", "status"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of finished tasks by project, template and final status.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+	}, []string{"project_id", "template_id", "status"})
+
+	scheduleRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "schedule_runs_total",
+		Help:      "Number of tasks started by schedules.",
+	}, []string{"project_id", "template_id"})
+
+	// WebsocketConnections is the number of open websocket connections.
+	WebsocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Number of open websocket connections.",
+	})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route, method and response code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	dbCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_call_duration_seconds",
+		Help:      "Latency of database calls by store and operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"store", "operation"})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		TaskQueueLength,
+		RunningTasks,
+		tasksFinished,
+		taskDuration,
+		scheduleRuns,
+		WebsocketConnections,
+		httpRequestDuration,
+		dbCallDuration,
+	)
+}
+
+// Handler returns the HTTP handler which serves all collected metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveTask records the final status and duration of a task.
+// The duration is not recorded for tasks which have never been started.
+func ObserveTask(projectID int, templateID int, status string, duration time.Duration) {
+	labels := prometheus.Labels{
+		"project_id":  strconv.Itoa(projectID),
+		"template_id": strconv.Itoa(templateID),
+		"status":      status,
+	}
+
+	tasksFinished.With(labels).Inc()
+
+	if duration > 0 {
+		taskDuration.With(labels).Observe(duration.Seconds())
+	}
+}
+
+// ObserveScheduleRun counts a task started by a schedule.
+func ObserveScheduleRun(projectID int, templateID int) {
+	scheduleRuns.WithLabelValues(strconv.Itoa(projectID), strconv.Itoa(templateID)).Inc()
+}
+
+// ObserveHTTPRequest records the latency of an HTTP request.
+// The route must be a path template, not the actual URL path,
+// to keep the number of series bounded.
+func ObserveHTTPRequest(route string, method string, code int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(route, method, strconv.Itoa(code)).Observe(duration.Seconds())
+}
+
+// ObserveDBCall records the latency of a database call started at the given time.
+// It is designed to be deferred:
+//
+//	defer metrics.ObserveDBCall("sql", "select", time.Now())
+func ObserveDBCall(store string, operation string, start time.Time) {
+	dbCallDuration.WithLabelValues(store, operation).Observe(time.Since(start).Seconds())
+}
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/gorilla/securecookie"
 )
@@ -42,6 +44,120 @@ type ldapMappings struct {
 	Mail string `json:"mail"`
 	UID  string `json:"uid"`
 	CN   string `json:"cn"`
+	// MemberOf is the attribute listing groups of the user,
+	// it is used if the group search filter is not set
+	MemberOf string `json:"member_of"`
+}
+
+// LDAP connection modes
+const (
+	LdapConnectionPlain    = "plain"
+	LdapConnectionStartTLS = "starttls"
+	LdapConnectionLDAPS    = "ldaps"
+)
+
+// LoginProtection limits failed login attempts per account and per IP address.
+// After a few failures every next attempt is delayed exponentially, and when the limit
+// is reached logins are blocked for LockoutDuration minutes. A negative account limit
+// disables the lockout of accounts.
+// The IP address limit is opt-in, attempts are counted by IP address only if MaxIPAttempts
+// is positive. They are counted by the address of the connection peer, forwarded headers are
+// used only in requests which come from TrustedProxies, the list of CIDRs or IP addresses.
+// Behind a reverse proxy TrustedProxies must be set, otherwise all clients share the address
+// of the proxy and failed attempts of one client block everyone.
+type LoginProtection struct {
+	MaxAccountAttempts int      `json:"max_account_attempts"`
+	MaxIPAttempts      int      `json:"max_ip_attempts"`
+	LockoutDuration    int      `json:"lockout_duration"`
+	TrustedProxies     []string `json:"trusted_proxies"`
+}
+
+// IsTrustedProxy returns true if the IP address belongs to one of trusted proxies.
+func (p LoginProtection) IsTrustedProxy(ip string) bool {
+	return isTrustedAddress(p.TrustedProxies, ip)
+}
+
+// isTrustedAddress returns true if the IP address matches one of IP addresses or CIDRs.
+func isTrustedAddress(trusted []string, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, proxy := range trusted {
+		if !strings.Contains(proxy, "/") {
+			if proxyAddr := net.ParseIP(proxy); proxyAddr != nil && proxyAddr.Equal(addr) {
+				return true
+			}
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ProxyAuth trusts users authenticated by a reverse proxy which passes them in headers.
+// Headers are trusted only in requests which come from TrustedProxies, the list of CIDRs or IP addresses.
+type ProxyAuth struct {
+	UserHeader     string   `json:"user_header"`
+	EmailHeader    string   `json:"email_header"`
+	NameHeader     string   `json:"name_header"`
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// GetUserHeader returns the header with the username, X-Forwarded-User by default.
+func (p ProxyAuth) GetUserHeader() string {
+	if p.UserHeader == "" {
+		return "X-Forwarded-User"
+	}
+	return p.UserHeader
+}
+
+// GetEmailHeader returns the header with the email, X-Forwarded-Email by default.
+func (p ProxyAuth) GetEmailHeader() string {
+	if p.EmailHeader == "" {
+		return "X-Forwarded-Email"
+	}
+	return p.EmailHeader
+}
+
+// IsTrustedProxy returns true if the IP address belongs to one of trusted proxies.
+func (p ProxyAuth) IsTrustedProxy(ip string) bool {
+	return isTrustedAddress(p.TrustedProxies, ip)
+}
+
+// LdapGroupMapping grants members of the LDAP group access to the project
+type LdapGroupMapping struct {
+	GroupDN   string `json:"group_dn"`
+	ProjectID int    `json:"project_id"`
+	// Role is the project role of group members: owner, manager, task_runner or guest
+	Role string `json:"role"`
+	// Admin is deprecated, it means the owner role if Role is empty
+	Admin bool `json:"admin"`
+}
+
+// OidcProvider is an OpenID Connect identity provider. Claims are mapped
+// to user fields by the *Claim fields, which have reasonable defaults.
+type OidcProvider struct {
+	DisplayName   string   `json:"display_name"`
+	ProviderURL   string   `json:"provider_url"`
+	ClientID      string   `json:"client_id"`
+	ClientSecret  string   `json:"client_secret"`
+	RedirectURL   string   `json:"redirect_url"`
+	Scopes        []string `json:"scopes"`
+	UsernameClaim string   `json:"username_claim"`
+	NameClaim     string   `json:"name_claim"`
+	EmailClaim    string   `json:"email_claim"`
+
+	// BindByEmail allows accounts of the provider to log in as existing external
+	// users (e.g. LDAP users) with the same verified email. Enable it only for
+	// providers which are trusted to verify the emails of all their accounts.
+	BindByEmail bool `json:"bind_by_email"`
 }
 
 //ConfigType mapping between Config and the json file that sets it
@@ -84,6 +200,32 @@ type ConfigType struct {
 	LdapSearchFilter string       `json:"ldap_searchfilter"`
 	LdapMappings     ldapMappings `json:"ldap_mappings"`
 
+	// ldap connection; the mode is plain, starttls or ldaps,
+	// if it is empty, ldap_needtls switches between plain and starttls
+	LdapConnectionMode string `json:"ldap_connection_mode"`
+	LdapCACert         string `json:"ldap_ca_cert"`
+	LdapClientCert     string `json:"ldap_client_cert"`
+	LdapClientKey      string `json:"ldap_client_key"`
+	LdapServerName     string `json:"ldap_server_name"`
+	LdapPoolSize       int    `json:"ldap_pool_size"`
+
+	// ldap group synchronisation; the group search filter gets the user DN,
+	// e.g. (&(objectClass=groupOfNames)(member=%s))
+	LdapGroupSearchDN     string             `json:"ldap_group_searchdn"`
+	LdapGroupSearchFilter string             `json:"ldap_group_searchfilter"`
+	LdapGroupMappings     []LdapGroupMapping `json:"ldap_group_mappings"`
+	// LdapSyncInterval is the period of the background group synchronisation in minutes, 0 disables it
+	LdapSyncInterval int `json:"ldap_sync_interval"`
+
+	// ProjectSyncInterval is the period in minutes of applying project specs from repositories, 0 disables it
+	ProjectSyncInterval int `json:"project_sync_interval"`
+
+	// OpenID Connect providers by ID
+	OidcProviders map[string]OidcProvider `json:"oidc_providers"`
+
+	// ProxyAuth is used if ProxyAuthEnable is set
+	ProxyAuth ProxyAuth `json:"proxy_auth"`
+
 	// telegram alerting
 	TelegramChat  string `json:"telegram_chat"`
 	TelegramToken string `json:"telegram_token"`
@@ -92,6 +234,27 @@ type ConfigType struct {
 	ConcurrencyMode  string `json:"concurrency_mode"`
 	MaxParallelTasks int    `json:"max_parallel_tasks"`
 
+	// prometheus metrics; if MetricsListen is set, metrics are served
+	// on that address instead of the main one
+	MetricsToken  string `json:"metrics_token"`
+	MetricsListen string `json:"metrics_listen"`
+
+	// session lifetimes in minutes: sessions expire after SessionIdleTimeout
+	// of inactivity (7 days by default) or SessionLifetime after login (0 means no limit)
+	SessionIdleTimeout int `json:"session_idle_timeout"`
+	SessionLifetime    int `json:"session_lifetime"`
+
+	// PasswordPolicy is enforced when passwords of local users are set
+	PasswordPolicy PasswordPolicy `json:"password_policy"`
+
+	// LoginProtection throttles failed login attempts
+	LoginProtection LoginProtection `json:"login_protection"`
+
+	// lifetimes of links sent by email in minutes: password reset links (1 hour by default),
+	// email verification links and invitations (7 days by default)
+	PasswordResetLifetime int `json:"password_reset_lifetime"`
+	InvitationLifetime    int `json:"invitation_lifetime"`
+
 	// configType field ordering with bools at end reduces struct size
 	// (maligned check)
 
@@ -100,6 +263,19 @@ type ConfigType struct {
 	TelegramAlert bool `json:"telegram_alert"`
 	LdapEnable    bool `json:"ldap_enable"`
 	LdapNeedTLS   bool `json:"ldap_needtls"`
+	MetricsEnable bool `json:"metrics_enable"`
+
+	// LdapSkipVerify disables verification of the LDAP server certificate
+	LdapSkipVerify bool `json:"ldap_skip_verify"`
+
+	// ProxyAuthEnable trusts users authenticated by the reverse proxy
+	ProxyAuthEnable bool `json:"proxy_auth_enable"`
+
+	// TotpRequired enforces two-factor authentication for non-external users
+	TotpRequired bool `json:"totp_required"`
+
+	// EmailVerification requires new local users to verify their email before they can log in
+	EmailVerification bool `json:"email_verification"`
 }
 
 //Config exposes the application configuration storage for use in the application
@@ -167,6 +343,70 @@ func validateConfig() {
 	if Config.MaxParallelTasks < 1 {
 		Config.MaxParallelTasks = 10
 	}
+
+	if Config.LdapPoolSize < 1 {
+		Config.LdapPoolSize = 5
+	}
+
+	if Config.LoginProtection.MaxAccountAttempts == 0 {
+		Config.LoginProtection.MaxAccountAttempts = 10
+	}
+
+	if Config.LoginProtection.LockoutDuration < 1 {
+		Config.LoginProtection.LockoutDuration = 15
+	}
+}
+
+// GetLdapConnectionMode returns the connection mode of the LDAP server.
+func (conf *ConfigType) GetLdapConnectionMode() (string, error) {
+	switch conf.LdapConnectionMode {
+	case "":
+		if conf.LdapNeedTLS {
+			return LdapConnectionStartTLS, nil
+		}
+		return LdapConnectionPlain, nil
+	case LdapConnectionPlain, LdapConnectionStartTLS, LdapConnectionLDAPS:
+		return conf.LdapConnectionMode, nil
+	default:
+		return "", fmt.Errorf("unsupported LDAP connection mode %s", conf.LdapConnectionMode)
+	}
+}
+
+// GetSessionIdleTimeout returns the time of inactivity after which sessions expire.
+func (conf *ConfigType) GetSessionIdleTimeout() time.Duration {
+	if conf.SessionIdleTimeout <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(conf.SessionIdleTimeout) * time.Minute
+}
+
+// GetSessionLifetime returns the time after login after which sessions expire, 0 means no limit.
+func (conf *ConfigType) GetSessionLifetime() time.Duration {
+	if conf.SessionLifetime <= 0 {
+		return 0
+	}
+	return time.Duration(conf.SessionLifetime) * time.Minute
+}
+
+// GetPasswordResetLifetime returns the time after which password reset links expire.
+func (conf *ConfigType) GetPasswordResetLifetime() time.Duration {
+	if conf.PasswordResetLifetime <= 0 {
+		return time.Hour
+	}
+	return time.Duration(conf.PasswordResetLifetime) * time.Minute
+}
+
+// GetInvitationLifetime returns the time after which invitations and email verification links expire.
+func (conf *ConfigType) GetInvitationLifetime() time.Duration {
+	if conf.InvitationLifetime <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(conf.InvitationLifetime) * time.Minute
+}
+
+// IsEmailConfigured returns true if emails can be sent, email alerts are switched separately.
+func (conf *ConfigType) IsEmailConfigured() bool {
+	return conf.EmailHost != "" && conf.EmailSender != ""
 }
 
 func validatePort() {
@@ -26,3 +26,36 @@ func TestValidatePort(t *testing.T) {
 		t.Error("Port value should be overwritten by env var, and it should be prefixed appropriately")
 	}
 }
+
+func TestGetLdapConnectionMode(t *testing.T) {
+	conf := ConfigType{LdapNeedTLS: true}
+	if mode, _ := conf.GetLdapConnectionMode(); mode != LdapConnectionStartTLS {
+		t.Error("legacy ldap_needtls option must select StartTLS")
+	}
+
+	conf.LdapConnectionMode = LdapConnectionLDAPS
+	if mode, _ := conf.GetLdapConnectionMode(); mode != LdapConnectionLDAPS {
+		t.Error("explicit connection mode must take precedence")
+	}
+
+	conf.LdapConnectionMode = "ssl"
+	if _, err := conf.GetLdapConnectionMode(); err == nil {
+		t.Error("unknown connection mode must be rejected")
+	}
+}
+
+func TestProxyAuthIsTrustedProxy(t *testing.T) {
+	auth := ProxyAuth{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.7", "invalid"}}
+
+	if !auth.IsTrustedProxy("10.1.2.3") {
+		t.Error("address of the trusted network must be trusted")
+	}
+
+	if !auth.IsTrustedProxy("192.0.2.7") {
+		t.Error("trusted address must be trusted")
+	}
+
+	if auth.IsTrustedProxy("192.0.2.8") || auth.IsTrustedProxy("") {
+		t.Error("other addresses must not be trusted")
+	}
+}
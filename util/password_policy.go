@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy defines rules for passwords of local users.
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSpecial   bool `json:"require_special"`
+	// Denylist contains passwords which can not be used, compared case-insensitively
+	Denylist []string `json:"denylist"`
+}
+
+// PasswordPolicyError is returned if the password does not satisfy the policy.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password " + e.Reason
+}
+
+// Validate returns PasswordPolicyError if the password does not satisfy the policy.
+func (p PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return &PasswordPolicyError{fmt.Sprintf("must be at least %d characters long", p.MinLength)}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	switch {
+	case p.RequireUppercase && !hasUpper:
+		return &PasswordPolicyError{"must contain an uppercase letter"}
+	case p.RequireLowercase && !hasLower:
+		return &PasswordPolicyError{"must contain a lowercase letter"}
+	case p.RequireDigit && !hasDigit:
+		return &PasswordPolicyError{"must contain a digit"}
+	case p.RequireSpecial && !hasSpecial:
+		return &PasswordPolicyError{"must contain a special character"}
+	}
+
+	for _, denied := range p.Denylist {
+		if strings.EqualFold(password, denied) {
+			return &PasswordPolicyError{"is too common"}
+		}
+	}
+
+	return nil
+}
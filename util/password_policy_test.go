@@ -0,0 +1,22 @@
+package util
+
+import "testing"
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		Denylist:         []string{"Passw0rd!"},
+	}
+
+	for _, password := range []string{"Sh0rt", "nouppercase1", "NoDigitsHere", "passw0rd!"} {
+		if err := policy.Validate(password); err == nil {
+			t.Fatalf("password %q must be rejected", password)
+		}
+	}
+
+	if err := policy.Validate("Correct1Horse"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestGetTotpCode(t *testing.T) {
+	// test vectors from RFC 6238 (SHA1, last 6 digits)
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for ts, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := GetTotpCode(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Fatalf("expected %s at %d, got %s", expected, ts, code)
+		}
+	}
+}
+
+func TestValidateTotpCode(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := GetTotpCode(secret, now.Add(-30*time.Second))
+
+	step, ok := ValidateTotpCode(secret, code, now, 0)
+	if !ok {
+		t.Fatal("code of the previous period must be accepted")
+	}
+
+	if _, ok = ValidateTotpCode(secret, code, now, step); ok {
+		t.Fatal("code of the last accepted period must be rejected")
+	}
+
+	if _, ok = ValidateTotpCode(secret, code, now.Add(2*time.Minute), 0); ok {
+		t.Fatal("outdated code must be rejected")
+	}
+}
@@ -0,0 +1,6 @@
+export default [
+  { value: 'owner', text: 'Owner' },
+  { value: 'manager', text: 'Manager' },
+  { value: 'task_runner', text: 'Task Runner' },
+  { value: 'guest', text: 'Guest' },
+];